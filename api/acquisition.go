@@ -1,9 +1,7 @@
 package api
 
 import (
-	"errors"
 	"net/http"
-	"strconv"
 	"time"
 )
 
@@ -57,122 +55,112 @@ func (c *Client) AddAcquisition(acquisition *Acquisition) (string, *http.Respons
 }
 
 func (c *Client) AddAcquisitionNote(id, text string) (*http.Response, error) {
-	var aerr *Error
-	var response *ModifiedResponse
-
-	note := &Note{
-		Text: text,
-	}
+	return c.AddContainerNote(&ContainerRef{Type: "acquisition", Id: id}, text)
+}
 
-	resp, err := c.New().Post("acquisitions/"+id+"/notes").BodyJSON(note).Receive(&response, &aerr)
+func (c *Client) GetAcquisitionNote(id, noteId string) (*Note, *http.Response, error) {
+	return c.GetContainerNote(&ContainerRef{Type: "acquisition", Id: id}, noteId)
+}
 
-	// Should not have to check this count
-	// https://github.com/scitran/core/issues/680
-	if err == nil && aerr == nil && response.ModifiedCount != 1 {
-		return resp, errors.New("Modifying acquisition " + id + " returned " + strconv.Itoa(response.ModifiedCount) + " instead of 1")
-	}
+func (c *Client) ModifyAcquisitionNote(id, noteId, text string) (*http.Response, error) {
+	return c.ModifyContainerNote(&ContainerRef{Type: "acquisition", Id: id}, noteId, text)
+}
 
-	return resp, Coalesce(err, aerr)
+func (c *Client) DeleteAcquisitionNote(id, noteId string) (*http.Response, error) {
+	return c.DeleteContainerNote(&ContainerRef{Type: "acquisition", Id: id}, noteId)
 }
 
 func (c *Client) AddAcquisitionTag(id, tag string) (*http.Response, error) {
-	var aerr *Error
-	var response *ModifiedResponse
-
-	var tagDoc interface{}
-	tagDoc = map[string]interface{}{
-		"value": tag,
-	}
-
-	resp, err := c.New().Post("acquisitions/"+id+"/tags").BodyJSON(tagDoc).Receive(&response, &aerr)
+	return c.AddContainerTag(&ContainerRef{Type: "acquisition", Id: id}, tag)
+}
 
-	// Should not have to check this count
-	// https://github.com/scitran/core/issues/680
-	if err == nil && aerr == nil && response.ModifiedCount != 1 {
-		return resp, errors.New("Modifying acquisition " + id + " returned " + strconv.Itoa(response.ModifiedCount) + " instead of 1")
-	}
+func (c *Client) RenameAcquisitionTag(id, tag, newTag string) (*http.Response, error) {
+	return c.RenameContainerTag(&ContainerRef{Type: "acquisition", Id: id}, tag, newTag)
+}
 
-	return resp, Coalesce(err, aerr)
+func (c *Client) DeleteAcquisitionTag(id, tag string) (*http.Response, error) {
+	return c.DeleteContainerTag(&ContainerRef{Type: "acquisition", Id: id}, tag)
 }
 
 func (c *Client) ModifyAcquisition(id string, acquisition *Acquisition) (*http.Response, error) {
-	var aerr *Error
-	var response *ModifiedResponse
-
-	resp, err := c.New().Put("acquisitions/"+id).BodyJSON(acquisition).Receive(&response, &aerr)
-
-	// Should not have to check this count
-	// https://github.com/scitran/core/issues/680
-	if err == nil && aerr == nil && response.ModifiedCount != 1 {
-		return resp, errors.New("Modifying acquisition " + id + " returned " + strconv.Itoa(response.ModifiedCount) + " instead of 1")
-	}
-
-	return resp, Coalesce(err, aerr)
+	return c.ModifyContainer(&ContainerRef{Type: "acquisition", Id: id}, acquisition)
 }
 
 func (c *Client) DeleteAcquisition(id string) (*http.Response, error) {
-	var aerr *Error
-	var response *DeletedResponse
+	return c.DeleteContainer(&ContainerRef{Type: "acquisition", Id: id})
+}
 
-	resp, err := c.New().Delete("acquisitions/"+id).Receive(&response, &aerr)
+func (c *Client) SetAcquisitionInfo(id string, set map[string]interface{}) (*http.Response, error) {
+	return c.SetContainerInfo(&ContainerRef{Type: "acquisition", Id: id}, set)
+}
 
-	// Should not have to check this count
-	// https://github.com/scitran/core/issues/680
-	if err == nil && aerr == nil && response.DeletedCount != 1 {
-		return resp, errors.New("Deleting acquisition " + id + " returned " + strconv.Itoa(response.DeletedCount) + " instead of 1")
-	}
+func (c *Client) ReplaceAcquisitionInfo(id string, replace map[string]interface{}) (*http.Response, error) {
+	return c.ReplaceContainerInfo(&ContainerRef{Type: "acquisition", Id: id}, replace)
+}
 
-	return resp, Coalesce(err, aerr)
+func (c *Client) DeleteAcquisitionInfoFields(id string, keys []string) (*http.Response, error) {
+	return c.DeleteContainerInfoFields(&ContainerRef{Type: "acquisition", Id: id}, keys)
 }
 
 func (c *Client) UploadToAcquisition(id string, files ...*UploadSource) (chan int64, chan error) {
-	url := "acquisitions/" + id + "/files"
-	return c.UploadSimple(url, nil, files...)
+	return c.UploadToContainer(&ContainerRef{Type: "acquisition", Id: id}, files...)
 }
 
 func (c *Client) ModifyAcquisitionFile(id string, filename string, attributes *FileFields) (*http.Response, *ModifiedAndJobsResponse, error) {
-	url := "acquisitions/" + id + "/files/" + filename
-	return c.modifyFileAttrs(url, attributes)
+	return c.ModifyContainerFile(&ContainerRef{Type: "acquisition", Id: id}, filename, attributes)
 }
 
 func (c *Client) SetAcquisitionFileInfo(id string, filename string, set map[string]interface{}) (*http.Response, error) {
-	url := "acquisitions/" + id + "/files/" + filename + "/info"
-	return c.setInfo(url, set)
+	return c.SetContainerFileInfo(&ContainerRef{Type: "acquisition", Id: id}, filename, set)
 }
 
 func (c *Client) ReplaceAcquisitionFileInfo(id string, filename string, replace map[string]interface{}) (*http.Response, error) {
-	url := "acquisitions/" + id + "/files/" + filename + "/info"
-	return c.replaceInfo(url, replace)
+	return c.ReplaceContainerFileInfo(&ContainerRef{Type: "acquisition", Id: id}, filename, replace)
 }
 
 func (c *Client) DeleteAcquisitionFileInfoFields(id string, filename string, keys []string) (*http.Response, error) {
-	url := "acquisitions/" + id + "/files/" + filename + "/info"
-	return c.deleteInfoFields(url, keys)
+	return c.DeleteContainerFileInfoFields(&ContainerRef{Type: "acquisition", Id: id}, filename, keys)
+}
+
+func (c *Client) RenameAcquisitionFile(id, filename, newName string) (*http.Response, error) {
+	return c.RenameContainerFile(&ContainerRef{Type: "acquisition", Id: id}, filename, newName)
+}
+
+func (c *Client) ReplaceAcquisitionFile(id, filename string, source *UploadSource) (chan int64, chan error) {
+	return c.ReplaceContainerFile(&ContainerRef{Type: "acquisition", Id: id}, filename, source)
+}
+
+func (c *Client) DeleteAcquisitionFile(id, filename string) (*http.Response, error) {
+	return c.DeleteContainerFile(&ContainerRef{Type: "acquisition", Id: id}, filename)
+}
+
+func (c *Client) AddAcquisitionFileTag(id, filename, tag string) (*http.Response, error) {
+	return c.AddContainerFileTag(&ContainerRef{Type: "acquisition", Id: id}, filename, tag)
+}
+
+func (c *Client) RenameAcquisitionFileTag(id, filename, tag, newTag string) (*http.Response, error) {
+	return c.RenameContainerFileTag(&ContainerRef{Type: "acquisition", Id: id}, filename, tag, newTag)
+}
+
+func (c *Client) DeleteAcquisitionFileTag(id, filename, tag string) (*http.Response, error) {
+	return c.DeleteContainerFileTag(&ContainerRef{Type: "acquisition", Id: id}, filename, tag)
 }
 
 func (c *Client) DownloadFromAcquisition(id string, filename string, destination *DownloadSource) (chan int64, chan error) {
-	url := "acquisitions/" + id + "/files/" + filename
-	return c.DownloadSimple(url, destination)
+	return c.DownloadFromContainer(&ContainerRef{Type: "acquisition", Id: id}, filename, destination)
 }
 
 // No progress reporting
 func (c *Client) UploadFileToAcquisition(id string, path string) error {
-	src := CreateUploadSourceFromFilenames(path)
-	progress, result := c.UploadToAcquisition(id, src...)
+	return c.UploadFileToContainer(&ContainerRef{Type: "acquisition", Id: id}, path)
+}
 
-	// drain and report
-	for range progress {
-	}
-	return <-result
+// No progress reporting
+func (c *Client) ReplaceFileInAcquisition(id, filename string, path string) error {
+	return c.ReplaceFileInContainer(&ContainerRef{Type: "acquisition", Id: id}, filename, path)
 }
 
 // No progress reporting
 func (c *Client) DownloadFileFromAcquisition(id, name string, path string) error {
-	src := CreateDownloadSourceFromFilename(path)
-	progress, result := c.DownloadFromAcquisition(id, name, src)
-
-	// drain and report
-	for range progress {
-	}
-	return <-result
+	return c.DownloadFileFromContainer(&ContainerRef{Type: "acquisition", Id: id}, name, path)
 }
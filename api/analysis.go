@@ -0,0 +1,296 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Analysis is a set of inputs and outputs attached to a project, session, acquisition or collection.
+// Analyses are either created ad hoc, with files uploaded by the client, or by a job that produces their outputs.
+type Analysis struct {
+	Id          string `json:"_id,omitempty"`
+	Name        string `json:"label,omitempty"`
+	Description string `json:"description,omitempty"`
+	UserId      string `json:"user,omitempty"`
+
+	// The container this analysis is attached to.
+	Parent *ContainerReference `json:"parent,omitempty"`
+
+	// The job that produces this analysis' outputs, if it was created with one.
+	JobId string `json:"job,omitempty"`
+
+	Inputs  []*File `json:"inputs,omitempty"`
+	Outputs []*File `json:"files,omitempty"`
+
+	Notes []*Note                `json:"notes,omitempty"`
+	Info  map[string]interface{} `json:"info,omitempty"`
+
+	Created  *time.Time `json:"created,omitempty"`
+	Modified *time.Time `json:"modified,omitempty"`
+}
+
+// analysisJobSubmission creates an analysis along with the job that will produce its outputs.
+type analysisJobSubmission struct {
+	Analysis *Analysis `json:"analysis"`
+	Job      *Job      `json:"job"`
+}
+
+// Analysis file groups, as named in routes.
+const (
+	analysisInputs  = "inputs"
+	analysisOutputs = "files"
+)
+
+// The following functions operate on the analyses of the container at url, such as "sessions/<id>".
+// Each container type has wrappers for them below.
+
+func (c *Client) getAnalyses(url string) ([]*Analysis, *http.Response, error) {
+	var aerr *Error
+	var analyses []*Analysis
+	resp, err := c.New().Get(url+"/analyses").Receive(&analyses, &aerr)
+	return analyses, resp, Coalesce(err, aerr)
+}
+
+func (c *Client) getAnalysis(url, analysisId string) (*Analysis, *http.Response, error) {
+	var aerr *Error
+	var analysis *Analysis
+	resp, err := c.New().Get(url+"/analyses/"+analysisId).Receive(&analysis, &aerr)
+	return analysis, resp, Coalesce(err, aerr)
+}
+
+func (c *Client) addAnalysis(url string, analysis *Analysis) (string, *http.Response, error) {
+	var aerr *Error
+	var response *IdResponse
+	var result string
+
+	resp, err := c.New().Post(url+"/analyses").BodyJSON(analysis).Receive(&response, &aerr)
+
+	if response != nil {
+		result = response.Id
+	}
+
+	return result, resp, Coalesce(err, aerr)
+}
+
+func (c *Client) addAnalysisWithJob(url string, analysis *Analysis, job *Job) (string, *http.Response, error) {
+	var aerr *Error
+	var response *IdResponse
+	var result string
+
+	submission := &analysisJobSubmission{
+		Analysis: analysis,
+		Job:      job,
+	}
+
+	resp, err := c.New().Post(url+"/analyses?job=true").BodyJSON(submission).Receive(&response, &aerr)
+
+	if response != nil {
+		result = response.Id
+	}
+
+	return result, resp, Coalesce(err, aerr)
+}
+
+func (c *Client) deleteAnalysis(url, analysisId string) (*http.Response, error) {
+	var aerr *Error
+	var response *DeletedResponse
+
+	resp, err := c.New().Delete(url+"/analyses/"+analysisId).Receive(&response, &aerr)
+
+	// Should not have to check this count
+	// https://github.com/scitran/core/issues/680
+	if err == nil && aerr == nil && response.DeletedCount != 1 {
+		return resp, errors.New("Deleting analysis " + analysisId + " returned " + strconv.Itoa(response.DeletedCount) + " instead of 1")
+	}
+
+	return resp, Coalesce(err, aerr)
+}
+
+func (c *Client) addAnalysisNote(url, analysisId, text string) (*http.Response, error) {
+	var aerr *Error
+	var response *ModifiedResponse
+
+	note := &Note{
+		Text: text,
+	}
+
+	resp, err := c.New().Post(url+"/analyses/"+analysisId+"/notes").BodyJSON(note).Receive(&response, &aerr)
+
+	// Should not have to check this count
+	// https://github.com/scitran/core/issues/680
+	if err == nil && aerr == nil && response.ModifiedCount != 1 {
+		return resp, errors.New("Modifying analysis " + analysisId + " returned " + strconv.Itoa(response.ModifiedCount) + " instead of 1")
+	}
+
+	return resp, Coalesce(err, aerr)
+}
+
+func (c *Client) uploadToAnalysis(url, analysisId, group string, files ...*UploadSource) (chan int64, chan error) {
+	return c.UploadSimple(url+"/analyses/"+analysisId+"/"+group, nil, files...)
+}
+
+func (c *Client) downloadFromAnalysis(url, analysisId, group, filename string, destination *DownloadSource) (chan int64, chan error) {
+	return c.DownloadSimple(url+"/analyses/"+analysisId+"/"+group+"/"+filename, destination)
+}
+
+func (c *Client) GetProjectAnalyses(id string) ([]*Analysis, *http.Response, error) {
+	return c.getAnalyses("projects/" + id)
+}
+
+func (c *Client) GetProjectAnalysis(id, analysisId string) (*Analysis, *http.Response, error) {
+	return c.getAnalysis("projects/"+id, analysisId)
+}
+
+func (c *Client) AddProjectAnalysis(id string, analysis *Analysis) (string, *http.Response, error) {
+	return c.addAnalysis("projects/"+id, analysis)
+}
+
+func (c *Client) AddProjectAnalysisWithJob(id string, analysis *Analysis, job *Job) (string, *http.Response, error) {
+	return c.addAnalysisWithJob("projects/"+id, analysis, job)
+}
+
+func (c *Client) DeleteProjectAnalysis(id, analysisId string) (*http.Response, error) {
+	return c.deleteAnalysis("projects/"+id, analysisId)
+}
+
+func (c *Client) AddProjectAnalysisNote(id, analysisId, text string) (*http.Response, error) {
+	return c.addAnalysisNote("projects/"+id, analysisId, text)
+}
+
+func (c *Client) UploadInputsToProjectAnalysis(id, analysisId string, files ...*UploadSource) (chan int64, chan error) {
+	return c.uploadToAnalysis("projects/"+id, analysisId, analysisInputs, files...)
+}
+
+func (c *Client) UploadOutputsToProjectAnalysis(id, analysisId string, files ...*UploadSource) (chan int64, chan error) {
+	return c.uploadToAnalysis("projects/"+id, analysisId, analysisOutputs, files...)
+}
+
+func (c *Client) DownloadInputFromProjectAnalysis(id, analysisId, filename string, destination *DownloadSource) (chan int64, chan error) {
+	return c.downloadFromAnalysis("projects/"+id, analysisId, analysisInputs, filename, destination)
+}
+
+func (c *Client) DownloadOutputFromProjectAnalysis(id, analysisId, filename string, destination *DownloadSource) (chan int64, chan error) {
+	return c.downloadFromAnalysis("projects/"+id, analysisId, analysisOutputs, filename, destination)
+}
+
+func (c *Client) GetSessionAnalyses(id string) ([]*Analysis, *http.Response, error) {
+	return c.getAnalyses("sessions/" + id)
+}
+
+func (c *Client) GetSessionAnalysis(id, analysisId string) (*Analysis, *http.Response, error) {
+	return c.getAnalysis("sessions/"+id, analysisId)
+}
+
+func (c *Client) AddSessionAnalysis(id string, analysis *Analysis) (string, *http.Response, error) {
+	return c.addAnalysis("sessions/"+id, analysis)
+}
+
+func (c *Client) AddSessionAnalysisWithJob(id string, analysis *Analysis, job *Job) (string, *http.Response, error) {
+	return c.addAnalysisWithJob("sessions/"+id, analysis, job)
+}
+
+func (c *Client) DeleteSessionAnalysis(id, analysisId string) (*http.Response, error) {
+	return c.deleteAnalysis("sessions/"+id, analysisId)
+}
+
+func (c *Client) AddSessionAnalysisNote(id, analysisId, text string) (*http.Response, error) {
+	return c.addAnalysisNote("sessions/"+id, analysisId, text)
+}
+
+func (c *Client) UploadInputsToSessionAnalysis(id, analysisId string, files ...*UploadSource) (chan int64, chan error) {
+	return c.uploadToAnalysis("sessions/"+id, analysisId, analysisInputs, files...)
+}
+
+func (c *Client) UploadOutputsToSessionAnalysis(id, analysisId string, files ...*UploadSource) (chan int64, chan error) {
+	return c.uploadToAnalysis("sessions/"+id, analysisId, analysisOutputs, files...)
+}
+
+func (c *Client) DownloadInputFromSessionAnalysis(id, analysisId, filename string, destination *DownloadSource) (chan int64, chan error) {
+	return c.downloadFromAnalysis("sessions/"+id, analysisId, analysisInputs, filename, destination)
+}
+
+func (c *Client) DownloadOutputFromSessionAnalysis(id, analysisId, filename string, destination *DownloadSource) (chan int64, chan error) {
+	return c.downloadFromAnalysis("sessions/"+id, analysisId, analysisOutputs, filename, destination)
+}
+
+func (c *Client) GetAcquisitionAnalyses(id string) ([]*Analysis, *http.Response, error) {
+	return c.getAnalyses("acquisitions/" + id)
+}
+
+func (c *Client) GetAcquisitionAnalysis(id, analysisId string) (*Analysis, *http.Response, error) {
+	return c.getAnalysis("acquisitions/"+id, analysisId)
+}
+
+func (c *Client) AddAcquisitionAnalysis(id string, analysis *Analysis) (string, *http.Response, error) {
+	return c.addAnalysis("acquisitions/"+id, analysis)
+}
+
+func (c *Client) AddAcquisitionAnalysisWithJob(id string, analysis *Analysis, job *Job) (string, *http.Response, error) {
+	return c.addAnalysisWithJob("acquisitions/"+id, analysis, job)
+}
+
+func (c *Client) DeleteAcquisitionAnalysis(id, analysisId string) (*http.Response, error) {
+	return c.deleteAnalysis("acquisitions/"+id, analysisId)
+}
+
+func (c *Client) AddAcquisitionAnalysisNote(id, analysisId, text string) (*http.Response, error) {
+	return c.addAnalysisNote("acquisitions/"+id, analysisId, text)
+}
+
+func (c *Client) UploadInputsToAcquisitionAnalysis(id, analysisId string, files ...*UploadSource) (chan int64, chan error) {
+	return c.uploadToAnalysis("acquisitions/"+id, analysisId, analysisInputs, files...)
+}
+
+func (c *Client) UploadOutputsToAcquisitionAnalysis(id, analysisId string, files ...*UploadSource) (chan int64, chan error) {
+	return c.uploadToAnalysis("acquisitions/"+id, analysisId, analysisOutputs, files...)
+}
+
+func (c *Client) DownloadInputFromAcquisitionAnalysis(id, analysisId, filename string, destination *DownloadSource) (chan int64, chan error) {
+	return c.downloadFromAnalysis("acquisitions/"+id, analysisId, analysisInputs, filename, destination)
+}
+
+func (c *Client) DownloadOutputFromAcquisitionAnalysis(id, analysisId, filename string, destination *DownloadSource) (chan int64, chan error) {
+	return c.downloadFromAnalysis("acquisitions/"+id, analysisId, analysisOutputs, filename, destination)
+}
+
+func (c *Client) GetCollectionAnalyses(id string) ([]*Analysis, *http.Response, error) {
+	return c.getAnalyses("collections/" + id)
+}
+
+func (c *Client) GetCollectionAnalysis(id, analysisId string) (*Analysis, *http.Response, error) {
+	return c.getAnalysis("collections/"+id, analysisId)
+}
+
+func (c *Client) AddCollectionAnalysis(id string, analysis *Analysis) (string, *http.Response, error) {
+	return c.addAnalysis("collections/"+id, analysis)
+}
+
+func (c *Client) AddCollectionAnalysisWithJob(id string, analysis *Analysis, job *Job) (string, *http.Response, error) {
+	return c.addAnalysisWithJob("collections/"+id, analysis, job)
+}
+
+func (c *Client) DeleteCollectionAnalysis(id, analysisId string) (*http.Response, error) {
+	return c.deleteAnalysis("collections/"+id, analysisId)
+}
+
+func (c *Client) AddCollectionAnalysisNote(id, analysisId, text string) (*http.Response, error) {
+	return c.addAnalysisNote("collections/"+id, analysisId, text)
+}
+
+func (c *Client) UploadInputsToCollectionAnalysis(id, analysisId string, files ...*UploadSource) (chan int64, chan error) {
+	return c.uploadToAnalysis("collections/"+id, analysisId, analysisInputs, files...)
+}
+
+func (c *Client) UploadOutputsToCollectionAnalysis(id, analysisId string, files ...*UploadSource) (chan int64, chan error) {
+	return c.uploadToAnalysis("collections/"+id, analysisId, analysisOutputs, files...)
+}
+
+func (c *Client) DownloadInputFromCollectionAnalysis(id, analysisId, filename string, destination *DownloadSource) (chan int64, chan error) {
+	return c.downloadFromAnalysis("collections/"+id, analysisId, analysisInputs, filename, destination)
+}
+
+func (c *Client) DownloadOutputFromCollectionAnalysis(id, analysisId, filename string, destination *DownloadSource) (chan int64, chan error) {
+	return c.downloadFromAnalysis("collections/"+id, analysisId, analysisOutputs, filename, destination)
+}
@@ -0,0 +1,211 @@
+package api
+
+import (
+	"archive/tar"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// BulkDownloadNode is a container to download, along with everything beneath it.
+type BulkDownloadNode struct {
+	// Level is the type of container, such as "session".
+	Level string `json:"level"`
+	Id    string `json:"_id"`
+}
+
+// BulkDownloadFilterValue lists values that a file must have, and values that it must not.
+type BulkDownloadFilterValue struct {
+	Plus  []string `json:"+,omitempty"`
+	Minus []string `json:"-,omitempty"`
+}
+
+// BulkDownloadFilter narrows the files of a bulk download. Files must pass every filter given.
+type BulkDownloadFilter struct {
+	Tags  *BulkDownloadFilterValue `json:"tags,omitempty"`
+	Types *BulkDownloadFilterValue `json:"types,omitempty"`
+}
+
+// BulkDownloadFile is a single file to download.
+type BulkDownloadFile struct {
+	// ContainerType is the type of the file's container, such as "acquisition".
+	ContainerType string `json:"container_name"`
+	ContainerId   string `json:"container_id"`
+	Name          string `json:"filename"`
+}
+
+// BulkDownloadRequest describes the files of a bulk download.
+// Set either Nodes, with any Filters, or Files.
+type BulkDownloadRequest struct {
+	Nodes   []*BulkDownloadNode   `json:"nodes,omitempty"`
+	Filters []*BulkDownloadFilter `json:"filters,omitempty"`
+
+	// Optional includes files that the server marks as optional.
+	Optional bool `json:"optional"`
+
+	Files []*BulkDownloadFile `json:"-"`
+}
+
+// BulkDownloadTicket is a ticket for a bulk download, as returned by CreateBulkDownloadTicket.
+type BulkDownloadTicket struct {
+	Ticket    string `json:"ticket"`
+	FileCount int    `json:"file_cnt"`
+	Size      int    `json:"size"`
+}
+
+// CreateBulkDownloadTicket asks for a ticket to download a set of containers or files as one tar archive.
+// The archive is then fetched with DownloadBulkTar or ExtractBulkDownload.
+func (c *Client) CreateBulkDownloadTicket(request *BulkDownloadRequest) (*BulkDownloadTicket, *http.Response, error) {
+	var aerr *Error
+	var ticket *BulkDownloadTicket
+
+	if len(request.Files) > 0 && len(request.Nodes) > 0 {
+		return nil, nil, errors.New("A bulk download request may set nodes or files, but not both")
+	}
+
+	req := c.New().Post("download").BodyJSON(request)
+	if len(request.Files) > 0 {
+		req = c.New().Post("download?bulk=true").BodyJSON(map[string]interface{}{"files": request.Files})
+	}
+
+	resp, err := req.Receive(&ticket, &aerr)
+	return ticket, resp, Coalesce(err, aerr)
+}
+
+// DownloadBulkTar writes the tar archive of a bulk download ticket to w, reporting downloaded bytes to progress.
+// DownloadBulkTar will not block sending to progress, and closes it once done.
+func (c *Client) DownloadBulkTar(ticket string, progress chan<- int64, w io.Writer) chan error {
+	resultChan := make(chan error, 1)
+
+	go func() {
+		resultChan <- c.readBulkDownload(ticket, progress, func(r io.Reader) error {
+			_, err := io.Copy(w, r)
+			return err
+		})
+	}()
+
+	return resultChan
+}
+
+// DownloadBulkTarSimple is a convenience wrapper around DownloadBulkTar.
+// It creates the progress channel for you.
+func (c *Client) DownloadBulkTarSimple(ticket string, w io.Writer) (chan int64, chan error) {
+
+	progress := make(chan int64, 10)
+
+	return progress, c.DownloadBulkTar(ticket, progress, w)
+}
+
+// ExtractBulkDownload unpacks the tar archive of a bulk download ticket into dir as it is downloaded,
+// reporting downloaded bytes to progress. Files already in dir are replaced.
+// ExtractBulkDownload will not block sending to progress, and closes it once done.
+//
+// Entries that would be written outside of dir, and entries other than files and directories, are refused.
+func (c *Client) ExtractBulkDownload(ticket string, progress chan<- int64, dir string) chan error {
+	resultChan := make(chan error, 1)
+
+	go func() {
+		resultChan <- c.readBulkDownload(ticket, progress, func(r io.Reader) error {
+			return extractTar(r, dir)
+		})
+	}()
+
+	return resultChan
+}
+
+// ExtractBulkDownloadSimple is a convenience wrapper around ExtractBulkDownload.
+// It creates the progress channel for you.
+func (c *Client) ExtractBulkDownloadSimple(ticket string, dir string) (chan int64, chan error) {
+
+	progress := make(chan int64, 10)
+
+	return progress, c.ExtractBulkDownload(ticket, progress, dir)
+}
+
+// readBulkDownload requests the archive of a ticket, and passes it to handle through a ProgressReader.
+func (c *Client) readBulkDownload(ticket string, progress chan<- int64, handle func(io.Reader) error) error {
+	reader := NewProgressReader(nil, progress)
+	defer reader.Close()
+
+	req, err := c.New().Get("download?ticket=" + url.QueryEscape(ticket)).Request()
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return errorFromResponse(resp)
+	}
+
+	reader.SetReader(resp.Body)
+	return handle(reader)
+}
+
+// extractTar writes the files of a tar archive beneath dir.
+func extractTar(r io.Reader, dir string) error {
+	archive := tar.NewReader(r)
+
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		// Names are checked again once joined to dir, as backslashes separate paths on Windows
+		name := path.Clean(header.Name)
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		relative, err := filepath.Rel(dir, target)
+		if err != nil || path.IsAbs(name) || filepath.IsAbs(filepath.FromSlash(name)) ||
+			relative == ".." || strings.HasPrefix(relative, ".."+string(filepath.Separator)) {
+			return errors.New("Refusing to extract " + strconv.Quote(header.Name) + " outside of " + dir)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(target, 0755)
+
+		case tar.TypeReg:
+			err = extractFile(archive, header, target)
+
+		default:
+			err = errors.New("Refusing to extract " + strconv.Quote(header.Name) + ", which is not a file or directory")
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// extractFile writes the current entry of a tar archive to target, with the entry's modification time.
+func extractFile(archive *tar.Reader, header *tar.Header, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+
+	out, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, archive)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Chtimes(target, header.ModTime, header.ModTime)
+}
@@ -18,6 +18,7 @@ type Collection struct {
 	Files    []*File    `json:"files,omitempty"`
 
 	Notes []*Note                `json:"notes,omitempty"`
+	Tags  []string               `json:"tags,omitempty"`
 	Info  map[string]interface{} `json:"info,omitempty"`
 
 	Public      *bool         `json:"public,omitempty"`
@@ -128,97 +129,112 @@ func (c *Client) AddSessionsToCollection(id string, sessionids []string) (*http.
 }
 
 func (c *Client) AddCollectionNote(id, text string) (*http.Response, error) {
-	var aerr *Error
-	var response *ModifiedResponse
-
-	note := &Note{
-		Text: text,
-	}
+	return c.AddContainerNote(&ContainerRef{Type: "collection", Id: id}, text)
+}
 
-	resp, err := c.New().Post("collections/"+id+"/notes").BodyJSON(note).Receive(&response, &aerr)
+func (c *Client) GetCollectionNote(id, noteId string) (*Note, *http.Response, error) {
+	return c.GetContainerNote(&ContainerRef{Type: "collection", Id: id}, noteId)
+}
 
-	// Should not have to check this count
-	// https://github.com/scitran/core/issues/680
-	if err == nil && aerr == nil && response.ModifiedCount != 1 {
-		return resp, errors.New("Modifying collection " + id + " returned " + strconv.Itoa(response.ModifiedCount) + " instead of 1")
-	}
+func (c *Client) ModifyCollectionNote(id, noteId, text string) (*http.Response, error) {
+	return c.ModifyContainerNote(&ContainerRef{Type: "collection", Id: id}, noteId, text)
+}
 
-	return resp, Coalesce(err, aerr)
+func (c *Client) DeleteCollectionNote(id, noteId string) (*http.Response, error) {
+	return c.DeleteContainerNote(&ContainerRef{Type: "collection", Id: id}, noteId)
 }
 
-func (c *Client) ModifyCollection(id string, collection *Collection) (*http.Response, error) {
-	var aerr *Error
-	var response *ModifiedResponse
+func (c *Client) AddCollectionTag(id, tag string) (*http.Response, error) {
+	return c.AddContainerTag(&ContainerRef{Type: "collection", Id: id}, tag)
+}
 
-	resp, err := c.New().Put("collections/"+id).BodyJSON(collection).Receive(&response, &aerr)
+func (c *Client) RenameCollectionTag(id, tag, newTag string) (*http.Response, error) {
+	return c.RenameContainerTag(&ContainerRef{Type: "collection", Id: id}, tag, newTag)
+}
 
-	// Should not have to check this count
-	// https://github.com/scitran/core/issues/680
-	if err == nil && aerr == nil && response.ModifiedCount != 1 {
-		return resp, errors.New("Modifying collection " + id + " returned " + strconv.Itoa(response.ModifiedCount) + " instead of 1")
-	}
+func (c *Client) DeleteCollectionTag(id, tag string) (*http.Response, error) {
+	return c.DeleteContainerTag(&ContainerRef{Type: "collection", Id: id}, tag)
+}
 
-	return resp, Coalesce(err, aerr)
+func (c *Client) ModifyCollection(id string, collection *Collection) (*http.Response, error) {
+	return c.ModifyContainer(&ContainerRef{Type: "collection", Id: id}, collection)
 }
 
 func (c *Client) DeleteCollection(id string) (*http.Response, error) {
-	var aerr *Error
+	return c.DeleteContainer(&ContainerRef{Type: "collection", Id: id})
+}
 
-	// Unlike other delete endpoints, this doesn't return anything. Which is good, but inconsistent.
-	// https://github.com/scitran/core/issues/680
-	resp, err := c.New().Delete("collections/"+id).Receive(nil, &aerr)
+func (c *Client) SetCollectionInfo(id string, set map[string]interface{}) (*http.Response, error) {
+	return c.SetContainerInfo(&ContainerRef{Type: "collection", Id: id}, set)
+}
 
-	return resp, Coalesce(err, aerr)
+func (c *Client) ReplaceCollectionInfo(id string, replace map[string]interface{}) (*http.Response, error) {
+	return c.ReplaceContainerInfo(&ContainerRef{Type: "collection", Id: id}, replace)
+}
+
+func (c *Client) DeleteCollectionInfoFields(id string, keys []string) (*http.Response, error) {
+	return c.DeleteContainerInfoFields(&ContainerRef{Type: "collection", Id: id}, keys)
 }
 
 func (c *Client) UploadToCollection(id string, files ...*UploadSource) (chan int64, chan error) {
-	url := "collections/" + id + "/files"
-	return c.UploadSimple(url, nil, files...)
+	return c.UploadToContainer(&ContainerRef{Type: "collection", Id: id}, files...)
 }
 
 func (c *Client) ModifyCollectionFile(id string, filename string, attributes *FileFields) (*http.Response, *ModifiedAndJobsResponse, error) {
-	url := "collections/" + id + "/files/" + filename
-	return c.modifyFileAttrs(url, attributes)
+	return c.ModifyContainerFile(&ContainerRef{Type: "collection", Id: id}, filename, attributes)
 }
 
 func (c *Client) SetCollectionFileInfo(id string, filename string, set map[string]interface{}) (*http.Response, error) {
-	url := "collections/" + id + "/files/" + filename + "/info"
-	return c.setInfo(url, set)
+	return c.SetContainerFileInfo(&ContainerRef{Type: "collection", Id: id}, filename, set)
 }
 
 func (c *Client) ReplaceCollectionFileInfo(id string, filename string, replace map[string]interface{}) (*http.Response, error) {
-	url := "collections/" + id + "/files/" + filename + "/info"
-	return c.replaceInfo(url, replace)
+	return c.ReplaceContainerFileInfo(&ContainerRef{Type: "collection", Id: id}, filename, replace)
 }
 
 func (c *Client) DeleteCollectionFileInfoFields(id string, filename string, keys []string) (*http.Response, error) {
-	url := "collections/" + id + "/files/" + filename + "/info"
-	return c.deleteInfoFields(url, keys)
+	return c.DeleteContainerFileInfoFields(&ContainerRef{Type: "collection", Id: id}, filename, keys)
+}
+
+func (c *Client) RenameCollectionFile(id, filename, newName string) (*http.Response, error) {
+	return c.RenameContainerFile(&ContainerRef{Type: "collection", Id: id}, filename, newName)
+}
+
+func (c *Client) ReplaceCollectionFile(id, filename string, source *UploadSource) (chan int64, chan error) {
+	return c.ReplaceContainerFile(&ContainerRef{Type: "collection", Id: id}, filename, source)
+}
+
+func (c *Client) DeleteCollectionFile(id, filename string) (*http.Response, error) {
+	return c.DeleteContainerFile(&ContainerRef{Type: "collection", Id: id}, filename)
+}
+
+func (c *Client) AddCollectionFileTag(id, filename, tag string) (*http.Response, error) {
+	return c.AddContainerFileTag(&ContainerRef{Type: "collection", Id: id}, filename, tag)
+}
+
+func (c *Client) RenameCollectionFileTag(id, filename, tag, newTag string) (*http.Response, error) {
+	return c.RenameContainerFileTag(&ContainerRef{Type: "collection", Id: id}, filename, tag, newTag)
+}
+
+func (c *Client) DeleteCollectionFileTag(id, filename, tag string) (*http.Response, error) {
+	return c.DeleteContainerFileTag(&ContainerRef{Type: "collection", Id: id}, filename, tag)
 }
 
 func (c *Client) DownloadFromCollection(id string, filename string, destination *DownloadSource) (chan int64, chan error) {
-	url := "collections/" + id + "/files/" + filename
-	return c.DownloadSimple(url, destination)
+	return c.DownloadFromContainer(&ContainerRef{Type: "collection", Id: id}, filename, destination)
 }
 
 // No progress reporting
 func (c *Client) UploadFileToCollection(id string, path string) error {
-	src := CreateUploadSourceFromFilenames(path)
-	progress, result := c.UploadToCollection(id, src...)
+	return c.UploadFileToContainer(&ContainerRef{Type: "collection", Id: id}, path)
+}
 
-	// drain and report
-	for range progress {
-	}
-	return <-result
+// No progress reporting
+func (c *Client) ReplaceFileInCollection(id, filename string, path string) error {
+	return c.ReplaceFileInContainer(&ContainerRef{Type: "collection", Id: id}, filename, path)
 }
 
 // No progress reporting
 func (c *Client) DownloadFileFromCollection(id, name string, path string) error {
-	src := CreateDownloadSourceFromFilename(path)
-	progress, result := c.DownloadFromCollection(id, name, src)
-
-	// drain and report
-	for range progress {
-	}
-	return <-result
+	return c.DownloadFileFromContainer(&ContainerRef{Type: "collection", Id: id}, name, path)
 }
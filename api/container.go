@@ -0,0 +1,390 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ContainerRef identifies a container by type and Id, such as "session" and the session's Id.
+// It is the same type as ContainerReference, so either name may be used.
+type ContainerRef = ContainerReference
+
+// Container holds the fields that every container type may have.
+// It is returned by GetContainer, and may be passed to ModifyContainer, whichever the container's type.
+type Container struct {
+	Id   string `json:"_id,omitempty"`
+	Name string `json:"label,omitempty"`
+
+	// The container's parents, which are set according to its type.
+	// Sessions have both a group and a project.
+	GroupId   string `json:"group,omitempty"`
+	ProjectId string `json:"project,omitempty"`
+	SessionId string `json:"session,omitempty"`
+
+	Notes []*Note                `json:"notes,omitempty"`
+	Tags  []string               `json:"tags,omitempty"`
+	Info  map[string]interface{} `json:"info,omitempty"`
+
+	Created  *time.Time `json:"created,omitempty"`
+	Modified *time.Time `json:"modified,omitempty"`
+	Files    []*File    `json:"files,omitempty"`
+
+	Permissions []*Permission `json:"permissions,omitempty"`
+}
+
+// URL prefix of each container type.
+var containerPrefixes = map[string]string{
+	"group":       "groups",
+	"project":     "projects",
+	"session":     "sessions",
+	"acquisition": "acquisitions",
+	"collection":  "collections",
+}
+
+// containerURL returns the URL of a container, such as "sessions/<id>".
+func containerURL(ref *ContainerRef) (string, error) {
+	prefix, ok := containerPrefixes[ref.Type]
+	if !ok {
+		return "", errors.New("Unknown container type " + strconv.Quote(ref.Type))
+	}
+	return prefix + "/" + ref.Id, nil
+}
+
+// containerFileURL returns the URL of a container's file, such as "sessions/<id>/files/<name>".
+func containerFileURL(ref *ContainerRef, filename string) (string, error) {
+	url, err := containerURL(ref)
+	return url + "/files/" + filename, err
+}
+
+// failedTransfer returns the channels of an upload or download that could not start.
+func failedTransfer(err error) (chan int64, chan error) {
+	progress := make(chan int64)
+	close(progress)
+
+	result := make(chan error, 1)
+	result <- err
+	return progress, result
+}
+
+func (c *Client) GetContainer(ref *ContainerRef) (*Container, *http.Response, error) {
+	url, err := containerURL(ref)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var aerr *Error
+	var container *Container
+	resp, err := c.New().Get(url).Receive(&container, &aerr)
+	return container, resp, Coalesce(err, aerr)
+}
+
+// ModifyContainer changes the fields of a container that are set on container.
+// Typed values such as *Session may also be given, to change fields that Container does not have.
+func (c *Client) ModifyContainer(ref *ContainerRef, container interface{}) (*http.Response, error) {
+	url, err := containerURL(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	var aerr *Error
+	var response *ModifiedResponse
+
+	resp, err := c.New().Put(url).BodyJSON(container).Receive(&response, &aerr)
+
+	// Should not have to check this count
+	// https://github.com/scitran/core/issues/680
+	if err == nil && aerr == nil && response.ModifiedCount != 1 {
+		return resp, errors.New("Modifying " + ref.Type + " " + ref.Id + " returned " + strconv.Itoa(response.ModifiedCount) + " instead of 1")
+	}
+
+	return resp, Coalesce(err, aerr)
+}
+
+func (c *Client) DeleteContainer(ref *ContainerRef) (*http.Response, error) {
+	url, err := containerURL(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	var aerr *Error
+	var response *DeletedResponse
+
+	resp, err := c.New().Delete(url).Receive(&response, &aerr)
+
+	// Should not have to check this count
+	// https://github.com/scitran/core/issues/680
+	if err == nil && aerr == nil && response.DeletedCount != 1 {
+		return resp, errors.New("Deleting " + ref.Type + " " + ref.Id + " returned " + strconv.Itoa(response.DeletedCount) + " instead of 1")
+	}
+
+	return resp, Coalesce(err, aerr)
+}
+
+// SetContainerInfo sets fields of a container's info, leaving others alone.
+// Keys may be dotted paths, such as "a.b", to set a nested field without changing its siblings.
+func (c *Client) SetContainerInfo(ref *ContainerRef, set map[string]interface{}) (*http.Response, error) {
+	url, err := containerURL(ref)
+	if err != nil {
+		return nil, err
+	}
+	return c.setInfo(url+"/info", set)
+}
+
+// ReplaceContainerInfo replaces a container's info.
+func (c *Client) ReplaceContainerInfo(ref *ContainerRef, replace map[string]interface{}) (*http.Response, error) {
+	url, err := containerURL(ref)
+	if err != nil {
+		return nil, err
+	}
+	return c.replaceInfo(url+"/info", replace)
+}
+
+// DeleteContainerInfoFields removes fields from a container's info.
+// Keys may be dotted paths, such as "a.b", to delete a nested field without changing its siblings.
+func (c *Client) DeleteContainerInfoFields(ref *ContainerRef, keys []string) (*http.Response, error) {
+	url, err := containerURL(ref)
+	if err != nil {
+		return nil, err
+	}
+	return c.deleteInfoFields(url+"/info", keys)
+}
+
+func (c *Client) AddContainerNote(ref *ContainerRef, text string) (*http.Response, error) {
+	url, err := containerURL(ref)
+	if err != nil {
+		return nil, err
+	}
+	return c.addNote(url, text)
+}
+
+func (c *Client) GetContainerNote(ref *ContainerRef, noteId string) (*Note, *http.Response, error) {
+	url, err := containerURL(ref)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c.getNote(url, noteId)
+}
+
+func (c *Client) ModifyContainerNote(ref *ContainerRef, noteId, text string) (*http.Response, error) {
+	url, err := containerURL(ref)
+	if err != nil {
+		return nil, err
+	}
+	return c.modifyNote(url, noteId, text)
+}
+
+func (c *Client) DeleteContainerNote(ref *ContainerRef, noteId string) (*http.Response, error) {
+	url, err := containerURL(ref)
+	if err != nil {
+		return nil, err
+	}
+	return c.deleteNote(url, noteId)
+}
+
+func (c *Client) AddContainerTag(ref *ContainerRef, tag string) (*http.Response, error) {
+	url, err := containerURL(ref)
+	if err != nil {
+		return nil, err
+	}
+	return c.addTag(url, tag)
+}
+
+func (c *Client) RenameContainerTag(ref *ContainerRef, tag, newTag string) (*http.Response, error) {
+	url, err := containerURL(ref)
+	if err != nil {
+		return nil, err
+	}
+	return c.renameTag(url, tag, newTag)
+}
+
+func (c *Client) DeleteContainerTag(ref *ContainerRef, tag string) (*http.Response, error) {
+	url, err := containerURL(ref)
+	if err != nil {
+		return nil, err
+	}
+	return c.deleteTag(url, tag)
+}
+
+func (c *Client) UploadToContainer(ref *ContainerRef, files ...*UploadSource) (chan int64, chan error) {
+	url, err := containerURL(ref)
+	if err != nil {
+		return failedTransfer(err)
+	}
+	return c.UploadSimple(url+"/files", nil, files...)
+}
+
+func (c *Client) DownloadFromContainer(ref *ContainerRef, filename string, destination *DownloadSource) (chan int64, chan error) {
+	url, err := containerFileURL(ref, filename)
+	if err != nil {
+		return failedTransfer(err)
+	}
+	return c.DownloadSimple(url, destination)
+}
+
+// DownloadFromContainerResumable downloads a file to path, keeping any bytes left there by an earlier attempt,
+// and checks the download against the file's size and hash. See DownloadResumable for details.
+func (c *Client) DownloadFromContainerResumable(ref *ContainerRef, filename string, path string) (chan int64, chan error) {
+	url, err := containerFileURL(ref, filename)
+	if err != nil {
+		return failedTransfer(err)
+	}
+
+	progress := make(chan int64, 10)
+	result := make(chan error, 1)
+
+	go func() {
+		container, _, err := c.GetContainer(ref)
+		if err != nil {
+			close(progress)
+			result <- err
+			return
+		}
+
+		for _, file := range container.Files {
+			if file.Name == filename {
+				result <- <-c.DownloadResumable(url, progress, path, file)
+				return
+			}
+		}
+
+		close(progress)
+		result <- &Error{StatusCode: 404, Message: "File " + filename + " not found in " + ref.Type + " " + ref.Id}
+	}()
+
+	return progress, result
+}
+
+// DownloadContainerFileRange writes part of a file to w. See DownloadRange for details.
+func (c *Client) DownloadContainerFileRange(ref *ContainerRef, filename string, offset, length int64, w io.Writer) (int64, error) {
+	url, err := containerFileURL(ref, filename)
+	if err != nil {
+		return 0, err
+	}
+	return c.DownloadRange(url, offset, length, w)
+}
+
+func (c *Client) ModifyContainerFile(ref *ContainerRef, filename string, attributes *FileFields) (*http.Response, *ModifiedAndJobsResponse, error) {
+	url, err := containerFileURL(ref, filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c.modifyFileAttrs(url, attributes)
+}
+
+func (c *Client) SetContainerFileInfo(ref *ContainerRef, filename string, set map[string]interface{}) (*http.Response, error) {
+	url, err := containerFileURL(ref, filename)
+	if err != nil {
+		return nil, err
+	}
+	return c.setInfo(url+"/info", set)
+}
+
+func (c *Client) ReplaceContainerFileInfo(ref *ContainerRef, filename string, replace map[string]interface{}) (*http.Response, error) {
+	url, err := containerFileURL(ref, filename)
+	if err != nil {
+		return nil, err
+	}
+	return c.replaceInfo(url+"/info", replace)
+}
+
+func (c *Client) DeleteContainerFileInfoFields(ref *ContainerRef, filename string, keys []string) (*http.Response, error) {
+	url, err := containerFileURL(ref, filename)
+	if err != nil {
+		return nil, err
+	}
+	return c.deleteInfoFields(url+"/info", keys)
+}
+
+func (c *Client) RenameContainerFile(ref *ContainerRef, filename, newName string) (*http.Response, error) {
+	url, err := containerURL(ref)
+	if err != nil {
+		return nil, err
+	}
+	return c.renameFile(url, filename, newName)
+}
+
+func (c *Client) ReplaceContainerFile(ref *ContainerRef, filename string, source *UploadSource) (chan int64, chan error) {
+	url, err := containerURL(ref)
+	if err != nil {
+		return failedTransfer(err)
+	}
+	return c.replaceFile(url, filename, source)
+}
+
+func (c *Client) DeleteContainerFile(ref *ContainerRef, filename string) (*http.Response, error) {
+	url, err := containerFileURL(ref, filename)
+	if err != nil {
+		return nil, err
+	}
+	return c.deleteFile(url)
+}
+
+func (c *Client) AddContainerFileTag(ref *ContainerRef, filename, tag string) (*http.Response, error) {
+	url, err := containerFileURL(ref, filename)
+	if err != nil {
+		return nil, err
+	}
+	return c.addTag(url, tag)
+}
+
+func (c *Client) RenameContainerFileTag(ref *ContainerRef, filename, tag, newTag string) (*http.Response, error) {
+	url, err := containerFileURL(ref, filename)
+	if err != nil {
+		return nil, err
+	}
+	return c.renameTag(url, tag, newTag)
+}
+
+func (c *Client) DeleteContainerFileTag(ref *ContainerRef, filename, tag string) (*http.Response, error) {
+	url, err := containerFileURL(ref, filename)
+	if err != nil {
+		return nil, err
+	}
+	return c.deleteTag(url, tag)
+}
+
+// No progress reporting
+func (c *Client) UploadFileToContainer(ref *ContainerRef, path string) error {
+	src := CreateUploadSourceFromFilenames(path)
+	progress, result := c.UploadToContainer(ref, src...)
+
+	// drain and report
+	for range progress {
+	}
+	return <-result
+}
+
+// No progress reporting
+func (c *Client) DownloadFileFromContainer(ref *ContainerRef, name string, path string) error {
+	src := CreateDownloadSourceFromFilename(path)
+	progress, result := c.DownloadFromContainer(ref, name, src)
+
+	// drain and report
+	for range progress {
+	}
+	return <-result
+}
+
+// No progress reporting
+func (c *Client) DownloadFileFromContainerResumable(ref *ContainerRef, name string, path string) error {
+	progress, result := c.DownloadFromContainerResumable(ref, name, path)
+
+	// drain and report
+	for range progress {
+	}
+	return <-result
+}
+
+// No progress reporting
+func (c *Client) ReplaceFileInContainer(ref *ContainerRef, filename string, path string) error {
+	src := &UploadSource{Path: path}
+	progress, result := c.ReplaceContainerFile(ref, filename, src)
+
+	// drain and report
+	for range progress {
+	}
+	return <-result
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"context"
+	"net/http"
+)
+
+// contextDoer attaches a context to each request before handing it to an http client.
+// It is used as the sling Doer for clients created with WithContext.
+type contextDoer struct {
+	ctx    context.Context
+	client *http.Client
+}
+
+// Do implements sling.Doer.
+func (d *contextDoer) Do(req *http.Request) (*http.Response, error) {
+	return d.client.Do(req.WithContext(d.ctx))
+}
+
+// WithContext returns a shallow copy of c whose requests are bound to ctx.
+//
+// Every method on the returned client, including the goroutines spawned by Upload and Download,
+// will abort when ctx is cancelled or its deadline passes. The original client is not modified.
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+//	defer cancel()
+//	sessions, _, err := client.WithContext(ctx).GetAllSessions()
+//
+// Passing a nil context will panic.
+func (c *Client) WithContext(ctx context.Context) *Client {
+	if ctx == nil {
+		panic("nil context")
+	}
+
+	clone := *c
+	clone.Sling = c.Sling.New().Doer(failureDoer{&contextDoer{ctx, c.Client}})
+	clone.ctx = ctx
+	return &clone
+}
+
+// Context returns the client's context.
+// Clients that were not created with WithContext use context.Background.
+func (c *Client) Context() context.Context {
+	if c.ctx != nil {
+		return c.ctx
+	}
+	return context.Background()
+}
+
+// do sends a raw request with the client's context attached.
+// Used by request paths that do not go through sling, such as uploads and downloads.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	return c.Client.Do(req.WithContext(c.Context()))
+}
@@ -4,17 +4,10 @@ import (
 	"time"
 )
 
-// Error is an API error. All failed server responses should be of this form.
-// TODO: implement error interface, change coalesce
-type Error struct {
-	Message    string `json:"message"`
-	StatusCode int    `json:"status_code"`
-}
-
 // Permission represents the capability of a single user on a given container. Many containers have an array of these permissions, and they are frequently casscaded down the container hierarchy.
 type Permission struct {
-	Id    string `json:"_id"`
-	Level string `json:"access"`
+	Id    string      `json:"_id"`
+	Level AccessLevel `json:"access"`
 }
 
 type Origin struct {
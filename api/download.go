@@ -1,10 +1,14 @@
 package api
 
 import (
+	"crypto/sha512"
+	"encoding/hex"
 	"errors"
 	"io"
 	"io/ioutil"
 	"os"
+	"strconv"
+	"strings"
 )
 
 // DownloadSource represents one file to upload.
@@ -42,15 +46,14 @@ func (c *Client) Download(url string, progress chan<- int64, destination *Downlo
 			return err
 		}
 
-		resp, err := c.Client.Do(req)
+		resp, err := c.do(req)
 		if err != nil {
 			return err
 		}
+		defer resp.Body.Close()
 
 		if resp.StatusCode != 200 {
-			// Needs robust handling for body & raw nils
-			raw, _ := ioutil.ReadAll(resp.Body)
-			return errors.New(string(raw))
+			return errorFromResponse(resp)
 		}
 
 		if resp.Body == nil {
@@ -83,3 +86,243 @@ func (c *Client) DownloadSimple(url string, destination *DownloadSource) (chan i
 
 	return progress, c.Download(url, progress, destination)
 }
+
+// DownloadResumable downloads the file at url to path, reporting downloaded bytes to progress.
+// Any bytes already at path, as left by an interrupted attempt, are kept and only the rest is requested.
+// DownloadResumable will not block sending to progress, and closes it once done.
+//
+// If file is given, such as from a container's Files, the download is checked against its size and hash once complete.
+// If they do not match, the file at path is removed so that the next attempt starts over.
+// Otherwise, only the length reported by the server is checked.
+func (c *Client) DownloadResumable(url string, progress chan<- int64, path string, file *File) chan error {
+	resultChan := make(chan error, 1)
+
+	go func() {
+		resultChan <- c.downloadResumable(url, progress, path, file)
+	}()
+
+	return resultChan
+}
+
+// DownloadResumableSimple is a convenience wrapper around DownloadResumable.
+// It creates the progress channel for you.
+func (c *Client) DownloadResumableSimple(url string, path string, file *File) (chan int64, chan error) {
+
+	progress := make(chan int64, 10)
+
+	return progress, c.DownloadResumable(url, progress, path, file)
+}
+
+// downloadResumable makes or resumes a download. See DownloadResumable.
+func (c *Client) downloadResumable(url string, progress chan<- int64, path string, file *File) error {
+	reader := NewProgressReader(nil, progress)
+	defer reader.Close()
+
+	out, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0666)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	offset, err := out.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+
+	// Without an expected size, the server reports whether anything is left
+	total := int64(-1)
+	if file != nil {
+		total = int64(file.Size)
+		if offset > total {
+			offset = 0
+		}
+	}
+	if file == nil || offset < total {
+		total, err = c.downloadRest(url, out, offset, reader)
+		if err != nil {
+			return err
+		}
+	}
+
+	size, err := out.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	reader.setCount(size)
+
+	if total >= 0 && size != total {
+		return errors.New("Downloaded " + strconv.FormatInt(size, 10) + " bytes to " + path + " instead of " + strconv.FormatInt(total, 10))
+	}
+	if file == nil {
+		return nil
+	}
+
+	if _, err := out.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	match, err := matchesHash(out, file.Hash)
+	if err != nil {
+		return err
+	}
+	if !match {
+		out.Close()
+		os.Remove(path)
+		return errors.New("Downloaded file " + path + " does not match hash " + file.Hash)
+	}
+	return nil
+}
+
+// downloadRest requests the file at url from offset onwards, writing it to out at that offset.
+// It returns the size of the whole file, or -1 if the server did not say.
+func (c *Client) downloadRest(url string, out *os.File, offset int64, reader *ProgressReader) (int64, error) {
+	req, err := c.New().Get(url).Request()
+	if err != nil {
+		return -1, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", "bytes="+strconv.FormatInt(offset, 10)+"-")
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return -1, err
+	}
+	defer resp.Body.Close()
+
+	total := int64(-1)
+	switch resp.StatusCode {
+	case 206:
+		var start int64
+		start, total = parseContentRange(resp.Header.Get("Content-Range"))
+		if start != offset {
+			return -1, errors.New("Server sent range " + strconv.Quote(resp.Header.Get("Content-Range")) + " instead of one from byte " + strconv.FormatInt(offset, 10))
+		}
+
+	case 200:
+		// The server sent the whole file
+		offset = 0
+		total = resp.ContentLength
+
+	case 416:
+		// Nothing is left after offset; either the file is complete, or what was downloaded is longer than the file
+		_, total = parseContentRange(resp.Header.Get("Content-Range"))
+		if total == offset {
+			return total, nil
+		}
+		if err := out.Truncate(0); err != nil {
+			return -1, err
+		}
+		return c.downloadRest(url, out, 0, reader)
+
+	default:
+		return -1, errorFromResponse(resp)
+	}
+
+	if err := out.Truncate(offset); err != nil {
+		return -1, err
+	}
+	if _, err := out.Seek(offset, io.SeekStart); err != nil {
+		return -1, err
+	}
+
+	reader.setCount(offset)
+	reader.SetReader(resp.Body)
+	_, err = io.Copy(out, reader)
+	return total, err
+}
+
+// parseContentRange parses a Content-Range header such as "bytes 10-99/100" or "bytes */100".
+// It returns the first byte of the range and the size of the whole file, either of which is -1 if unknown.
+func parseContentRange(header string) (int64, int64) {
+	start, total := int64(-1), int64(-1)
+
+	spec := strings.TrimPrefix(header, "bytes ")
+	slash := strings.LastIndex(spec, "/")
+	if spec == header || slash < 0 {
+		return start, total
+	}
+
+	if x, err := strconv.ParseInt(spec[slash+1:], 10, 64); err == nil {
+		total = x
+	}
+	if dash := strings.Index(spec[:slash], "-"); dash > 0 {
+		if x, err := strconv.ParseInt(spec[:dash], 10, 64); err == nil {
+			start = x
+		}
+	}
+	return start, total
+}
+
+// matchesHash reports whether the contents of r match a file hash such as "v0-sha384-<hex>".
+// Hashes in a form that is not understood are assumed to match.
+func matchesHash(r io.Reader, hash string) (bool, error) {
+	if !strings.HasPrefix(hash, "v0-sha384-") {
+		return true, nil
+	}
+
+	sum := sha512.New384()
+	if _, err := io.Copy(sum, r); err != nil {
+		return false, err
+	}
+	return hash == "v0-sha384-"+hex.EncodeToString(sum.Sum(nil)), nil
+}
+
+// DownloadRange writes length bytes of the file at url, starting at offset, to w, such as to read only a file's header.
+// A negative length reads to the end of the file. Fewer bytes are written if the file ends first.
+// Returns the number of bytes written.
+func (c *Client) DownloadRange(url string, offset, length int64, w io.Writer) (int64, error) {
+	if offset < 0 {
+		return 0, errors.New("Cannot download from a negative offset")
+	}
+	if length == 0 {
+		return 0, nil
+	}
+
+	req, err := c.New().Get(url).Request()
+	if err != nil {
+		return 0, err
+	}
+	byteRange := "bytes=" + strconv.FormatInt(offset, 10) + "-"
+	if length > 0 {
+		byteRange += strconv.FormatInt(offset+length-1, 10)
+	}
+	req.Header.Set("Range", byteRange)
+
+	resp, err := c.do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case 206:
+		if start, _ := parseContentRange(resp.Header.Get("Content-Range")); start != offset {
+			return 0, errors.New("Server sent range " + strconv.Quote(resp.Header.Get("Content-Range")) + " instead of one from byte " + strconv.FormatInt(offset, 10))
+		}
+
+	case 200:
+		// The server ignored the range, so skip to it
+		if _, err := io.CopyN(ioutil.Discard, resp.Body, offset); err == io.EOF {
+			return 0, nil
+		} else if err != nil {
+			return 0, err
+		}
+
+	case 416:
+		// The file ends before offset
+		return 0, nil
+
+	default:
+		return 0, errorFromResponse(resp)
+	}
+
+	if length < 0 {
+		return io.Copy(w, resp.Body)
+	}
+
+	n, err := io.CopyN(w, resp.Body, length)
+	if err == io.EOF {
+		err = nil
+	}
+	return n, err
+}
@@ -0,0 +1,129 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/dghubble/sling"
+)
+
+// RequestIdHeader is the response header that may carry the server's id for a request.
+const RequestIdHeader = "X-Request-Id"
+
+// Error is an API error. All failed server responses should be of this form.
+//
+// Methods on Client return an *Error for any non-2xx response; use errors.As or the Is* helpers to inspect it.
+type Error struct {
+	Message    string `json:"message"`
+	StatusCode int    `json:"status_code"`
+	RequestId  string `json:"request_id,omitempty"`
+
+	// The request that caused this error, if known.
+	Method string `json:"-"`
+	URL    string `json:"-"`
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	message := e.Message
+	if message == "" {
+		message = "Unknown server error"
+	}
+	return "(" + strconv.Itoa(e.StatusCode) + ") " + message
+}
+
+// errorFromResponse consumes a failed response body and returns the corresponding Error.
+// Bodies that are not an API error document, such as a proxy's HTML error page, are used as the message.
+func errorFromResponse(resp *http.Response) *Error {
+	aerr := &Error{}
+
+	if resp.Body != nil {
+		raw, _ := ioutil.ReadAll(resp.Body)
+		if json.Unmarshal(raw, aerr) != nil {
+			aerr.Message = strings.TrimSpace(string(raw))
+		}
+	}
+
+	fillErrorFromResponse(aerr, resp)
+	return aerr
+}
+
+// fillErrorFromResponse annotates an Error with details from the response that produced it.
+func fillErrorFromResponse(aerr *Error, resp *http.Response) {
+	if aerr.StatusCode == 0 {
+		aerr.StatusCode = resp.StatusCode
+	}
+	if aerr.RequestId == "" {
+		aerr.RequestId = resp.Header.Get(RequestIdHeader)
+	}
+	if resp.Request != nil {
+		aerr.Method = resp.Request.Method
+		if resp.Request.URL != nil {
+			aerr.URL = resp.Request.URL.String()
+		}
+	}
+}
+
+// errorDecoder is a sling.ResponseDecoder that decodes JSON, and ensures failed responses produce a complete Error.
+type errorDecoder struct{}
+
+// Decode implements sling.ResponseDecoder.
+func (errorDecoder) Decode(resp *http.Response, v interface{}) error {
+	aerr, ok := v.(**Error)
+	if !ok || (resp.StatusCode >= 200 && resp.StatusCode <= 299) {
+		return json.NewDecoder(resp.Body).Decode(v)
+	}
+
+	*aerr = errorFromResponse(resp)
+	return nil
+}
+
+// failureDoer is a sling.Doer that ensures every non-2xx response reaches errorDecoder.
+// Versions of sling that skip decoding responses with an empty body would otherwise leave such a failure with no Error.
+type failureDoer struct {
+	doer sling.Doer
+}
+
+// Do implements sling.Doer.
+func (d failureDoer) Do(req *http.Request) (*http.Response, error) {
+	resp, err := d.doer.Do(req)
+	if err == nil && (resp.StatusCode < 200 || resp.StatusCode > 299) && resp.ContentLength == 0 {
+		resp.ContentLength = -1
+	}
+	return resp, err
+}
+
+// IsStatus reports whether err is an API error with the given HTTP status code.
+func IsStatus(err error, statusCode int) bool {
+	var aerr *Error
+	return errors.As(err, &aerr) && aerr.StatusCode == statusCode
+}
+
+// IsBadRequest reports whether err is an API error with status 400.
+func IsBadRequest(err error) bool {
+	return IsStatus(err, http.StatusBadRequest)
+}
+
+// IsUnauthorized reports whether err is an API error with status 401.
+func IsUnauthorized(err error) bool {
+	return IsStatus(err, http.StatusUnauthorized)
+}
+
+// IsForbidden reports whether err is an API error with status 403.
+func IsForbidden(err error) bool {
+	return IsStatus(err, http.StatusForbidden)
+}
+
+// IsNotFound reports whether err is an API error with status 404.
+func IsNotFound(err error) bool {
+	return IsStatus(err, http.StatusNotFound)
+}
+
+// IsConflict reports whether err is an API error with status 409.
+func IsConflict(err error) bool {
+	return IsStatus(err, http.StatusConflict)
+}
@@ -0,0 +1,456 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ExportSidecarName is the name of the metadata file that Export writes in each container's directory, if asked to.
+const ExportSidecarName = "flywheel.json"
+
+// ExportOptions configure Export. The zero value exports every file, without sidecars.
+type ExportOptions struct {
+	// Workers is the number of containers and files handled at once. Defaults to the Walk default.
+	Workers int
+
+	// Include lists patterns, as accepted by path.Match, of which a file's name must match one. Nil includes every name.
+	Include []string
+
+	// Exclude lists patterns, as accepted by path.Match, of file names to leave out. Exclusions win over inclusions.
+	Exclude []string
+
+	// Types and Modalities list the file types, such as "dicom", and modalities, such as "MR", to export.
+	// Nil exports files of any type or modality.
+	Types      []string
+	Modalities []string
+
+	// Sidecars writes each container's metadata, as JSON, to ExportSidecarName in its directory.
+	Sidecars bool
+
+	// Incremental skips files whose local copy has the same size and modification time as the remote file.
+	// Export gives each file it downloads the modification time of the remote file, so that later runs can tell.
+	Incremental bool
+}
+
+// ExportManifest records what Export did.
+type ExportManifest struct {
+	// Files that passed the filters, sorted by local path, with what became of each.
+	Files []*ManifestFile
+
+	// Sidecars written, sorted by path.
+	Sidecars []string
+}
+
+// exporter is the state of one call to Export.
+type exporter struct {
+	c           *Client
+	options     *ExportOptions
+	destination string
+	rootDepth   int
+
+	mu       sync.Mutex
+	manifest *ExportManifest
+
+	// Directory names of the children of each container, by container type and Id, listed once per export.
+	siblings map[string]*siblingNames
+}
+
+// siblingNames holds the directory names of the children of one container, by Id.
+type siblingNames struct {
+	once  sync.Once
+	names map[string]string
+	err   error
+}
+
+// Export downloads the files of root and every container below it to a directory tree under destination.
+// Root is a path as accepted by Resolve; an empty root exports every group.
+// Only the group hierarchy can be walked, so collections and analyses cannot be exported.
+//
+// The contents of root are written directly to destination, and each container below it gets a directory named after it:
+// groups by Id, and other containers by label, or by Id if they have none. Slashes in labels are replaced with underscores.
+// Sibling containers whose names would collide are told apart by appending an underscore and their Id to each name,
+// and files of the same name in one container by numbering them, as in "scan_1.nii" and "scan_2.nii".
+// Files are numbered the same way if their name is that of a directory in their container's directory,
+// and numbers that would give the name of another file or directory are skipped.
+// With Sidecars, a file named ExportSidecarName cannot be exported, and fails.
+//
+// Files are downloaded beside their final path and renamed into place once their size and hash are checked,
+// so a file that is interrupted is resumed on the next run, and a file in place is always complete.
+//
+// If any files failed, an error saying how many is returned along with the manifest.
+func (c *Client) Export(root string, destination string, options *ExportOptions) (*ExportManifest, error) {
+	if options == nil {
+		options = &ExportOptions{}
+	}
+
+	e := &exporter{
+		c:           c,
+		options:     options,
+		destination: destination,
+		manifest:    &ExportManifest{Files: []*ManifestFile{}, Sidecars: []string{}},
+		siblings:    map[string]*siblingNames{},
+	}
+	if root = strings.Trim(root, "/"); root != "" {
+		e.rootDepth = len(strings.Split(root, "/"))
+	}
+
+	if err := os.MkdirAll(destination, 0755); err != nil {
+		return nil, err
+	}
+
+	walkOptions := []WalkOption{}
+	if options.Workers > 0 {
+		walkOptions = append(walkOptions, WalkWorkers(options.Workers))
+	}
+
+	err := c.Walk(root, func(node *ResolvedPath, err error) error {
+		if err != nil {
+			return err
+		}
+		if node.File != nil {
+			e.exportFile(node)
+			return nil
+		}
+		return e.exportContainer(node)
+	}, walkOptions...)
+
+	sort.Slice(e.manifest.Files, func(i, j int) bool {
+		return e.manifest.Files[i].Path < e.manifest.Files[j].Path
+	})
+	sort.Strings(e.manifest.Sidecars)
+
+	if err != nil {
+		return e.manifest, err
+	}
+
+	failed := 0
+	for _, entry := range e.manifest.Files {
+		if entry.Status == ManifestFailed {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return e.manifest, errors.New("Exporting failed for " + strconv.Itoa(failed) + " of " + strconv.Itoa(len(e.manifest.Files)) + " files")
+	}
+	return e.manifest, nil
+}
+
+// dir returns the local directory of a node's deepest container.
+func (e *exporter) dir(node *ResolvedPath) (string, error) {
+	var names []string
+	add := func(parentType, parentId, label, id string) error {
+		name := exportName(label, id)
+
+		// Root and its ancestors are not given directories, so their names cannot collide
+		if len(names) >= e.rootDepth {
+			siblings, err := e.children(parentType, parentId)
+			if err != nil {
+				return err
+			}
+			if unique, ok := siblings.names[id]; ok {
+				name = unique
+			}
+		}
+		names = append(names, name)
+		return nil
+	}
+
+	if node.Group != nil {
+		names = append(names, exportName(node.Group.Id, node.Group.Id))
+	}
+	if node.Project != nil {
+		if err := add("group", node.Group.Id, node.Project.Name, node.Project.Id); err != nil {
+			return "", err
+		}
+	}
+	if node.Session != nil {
+		if err := add("project", node.Project.Id, node.Session.Name, node.Session.Id); err != nil {
+			return "", err
+		}
+	}
+	if node.Acquisition != nil {
+		if err := add("session", node.Session.Id, node.Acquisition.Name, node.Acquisition.Id); err != nil {
+			return "", err
+		}
+	}
+
+	// Root and its ancestors are written to destination itself
+	if len(names) <= e.rootDepth {
+		return e.destination, nil
+	}
+	return filepath.Join(append([]string{e.destination}, names[e.rootDepth:]...)...), nil
+}
+
+// children returns the directory names of a container's children, listed once per container.
+// A name shared by siblings has each sibling's Id appended.
+func (e *exporter) children(parentType, parentId string) (*siblingNames, error) {
+	key := parentType + "/" + parentId
+	e.mu.Lock()
+	siblings := e.siblings[key]
+	if siblings == nil {
+		siblings = &siblingNames{}
+		e.siblings[key] = siblings
+	}
+	e.mu.Unlock()
+
+	siblings.once.Do(func() {
+		var labels, ids []string
+		switch parentType {
+		case "group":
+			var projects []*Project
+			projects, _, siblings.err = e.c.GetGroupProjects(parentId)
+			for _, x := range projects {
+				labels, ids = append(labels, x.Name), append(ids, x.Id)
+			}
+		case "project":
+			var sessions []*Session
+			sessions, _, siblings.err = e.c.GetProjectSessions(parentId)
+			for _, x := range sessions {
+				labels, ids = append(labels, x.Name), append(ids, x.Id)
+			}
+		case "session":
+			var acquisitions []*Acquisition
+			acquisitions, _, siblings.err = e.c.GetSessionAcquisitions(parentId)
+			for _, x := range acquisitions {
+				labels, ids = append(labels, x.Name), append(ids, x.Id)
+			}
+		}
+
+		counts := map[string]int{}
+		for i := range ids {
+			counts[exportName(labels[i], ids[i])]++
+		}
+		siblings.names = map[string]string{}
+		for i, id := range ids {
+			name := exportName(labels[i], id)
+			if counts[name] > 1 {
+				name += "_" + id
+			}
+			siblings.names[id] = name
+		}
+	})
+	return siblings, siblings.err
+}
+
+// fileName returns the local name of a node's file.
+// A file whose name is shared with another file, or with a directory, of its container is numbered,
+// skipping numbers that would give the name of another file or directory.
+func (e *exporter) fileName(node *ResolvedPath) (string, error) {
+	var files []*File
+	parentType, parentId := "", ""
+	switch {
+	case node.Acquisition != nil:
+		files = node.Acquisition.Files
+	case node.Session != nil:
+		files, parentType, parentId = node.Session.Files, "session", node.Session.Id
+	case node.Project != nil:
+		files, parentType, parentId = node.Project.Files, "project", node.Project.Id
+	}
+
+	// Every name in the container's directory, before numbering
+	counts := map[string]int{}
+	for _, x := range files {
+		counts[exportName(x.Name, x.Name)]++
+	}
+	if parentType != "" {
+		siblings, err := e.children(parentType, parentId)
+		if err != nil {
+			return "", err
+		}
+		for _, name := range siblings.names {
+			counts[name]++
+		}
+	}
+
+	// Number the files that collide in order, so that each gets the same name whichever is asked for
+	taken := map[string]bool{}
+	for name := range counts {
+		taken[name] = true
+	}
+	for _, x := range files {
+		name := exportName(x.Name, x.Name)
+		if counts[name] > 1 {
+			ext := path.Ext(name)
+			for i := 1; ; i++ {
+				numbered := strings.TrimSuffix(name, ext) + "_" + strconv.Itoa(i) + ext
+				if !taken[numbered] {
+					taken[numbered] = true
+					name = numbered
+					break
+				}
+			}
+		}
+		if x == node.File {
+			return name, nil
+		}
+	}
+	return exportName(node.File.Name, node.File.Name), nil
+}
+
+// exportName returns the name of a container's directory.
+func exportName(label, id string) string {
+	if label == "" || label == "." || label == ".." {
+		return id
+	}
+	return strings.NewReplacer("/", "_", "\\", "_").Replace(label)
+}
+
+// exportContainer creates a container's directory, and writes its sidecar if asked to.
+func (e *exporter) exportContainer(node *ResolvedPath) error {
+	dir, err := e.dir(node)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	if !e.options.Sidecars {
+		return nil
+	}
+
+	raw, err := json.MarshalIndent(node.Leaf(), "", "\t")
+	if err != nil {
+		return err
+	}
+	sidecar := filepath.Join(dir, ExportSidecarName)
+	if err := ioutil.WriteFile(sidecar, raw, 0644); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	e.manifest.Sidecars = append(e.manifest.Sidecars, sidecar)
+	e.mu.Unlock()
+	return nil
+}
+
+// exportFile downloads a file, unless it is filtered out or already up to date.
+func (e *exporter) exportFile(node *ResolvedPath) {
+	file := node.File
+	if !e.wants(file) {
+		return
+	}
+
+	parent := fileContainer(node)
+	entry := &ManifestFile{
+		Size:   int64(file.Size),
+		Target: parent,
+		Name:   file.Name,
+	}
+	dir, err := e.dir(node)
+	name, nameErr := e.fileName(node)
+	if err == nil {
+		err = nameErr
+	}
+	if err != nil {
+		dir, name = e.destination, exportName(file.Name, file.Name)
+	}
+	entry.Path = filepath.Join(dir, name)
+
+	e.mu.Lock()
+	e.manifest.Files = append(e.manifest.Files, entry)
+	e.mu.Unlock()
+
+	if err == nil && e.options.Sidecars && filepath.Base(entry.Path) == ExportSidecarName {
+		err = errors.New("File " + file.Name + " would be overwritten by the metadata sidecar of its container")
+	}
+	if err != nil {
+		entry.Status, entry.Error = ManifestFailed, err
+		return
+	}
+
+	if e.options.Incremental && upToDate(entry.Path, file) {
+		entry.Status = ManifestSkipped
+		return
+	}
+
+	if err := e.download(parent, file, entry.Path); err != nil {
+		entry.Status, entry.Error = ManifestFailed, err
+		return
+	}
+	entry.Status = ManifestDownloaded
+}
+
+// wants reports whether a file passes the filters.
+func (e *exporter) wants(file *File) bool {
+	o := e.options
+
+	if o.Include != nil && !matchesAny(o.Include, file.Name) {
+		return false
+	}
+	if matchesAny(o.Exclude, file.Name) {
+		return false
+	}
+	if o.Types != nil && indexOf(o.Types, file.Type) < 0 {
+		return false
+	}
+	if o.Modalities != nil && indexOf(o.Modalities, file.Modality) < 0 {
+		return false
+	}
+	return true
+}
+
+// matchesAny reports whether a name matches any of the patterns.
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if match, _ := path.Match(pattern, name); match {
+			return true
+		}
+	}
+	return false
+}
+
+// upToDate reports whether a local file has the size and modification time of a remote one.
+// Times are compared to the second, as some filesystems keep no more.
+func upToDate(localPath string, file *File) bool {
+	stat, err := os.Stat(localPath)
+	if err != nil || file.Modified == nil {
+		return false
+	}
+	return stat.Size() == int64(file.Size) && stat.ModTime().Truncate(time.Second).Equal(file.Modified.Truncate(time.Second))
+}
+
+// download fetches a file to a partial path, then moves it into place with the remote file's modification time.
+func (e *exporter) download(parent *ContainerRef, file *File, localPath string) error {
+	url, err := containerFileURL(parent, file.Name)
+	if err != nil {
+		return err
+	}
+
+	partial := localPath + ".part"
+	progress, result := e.c.DownloadResumableSimple(url, partial, file)
+	for range progress {
+	}
+	if err := <-result; err != nil {
+		return err
+	}
+
+	if err := os.Rename(partial, localPath); err != nil {
+		return err
+	}
+	if file.Modified != nil {
+		return os.Chtimes(localPath, *file.Modified, *file.Modified)
+	}
+	return nil
+}
+
+// fileContainer returns the container that a node's file is in.
+func fileContainer(node *ResolvedPath) *ContainerRef {
+	switch {
+	case node.Acquisition != nil:
+		return &ContainerRef{Type: "acquisition", Id: node.Acquisition.Id}
+	case node.Session != nil:
+		return &ContainerRef{Type: "session", Id: node.Session.Id}
+	case node.Project != nil:
+		return &ContainerRef{Type: "project", Id: node.Project.Id}
+	}
+	return &ContainerRef{Type: "group", Id: node.Group.Id}
+}
@@ -1,6 +1,11 @@
 package api
 
 import (
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
 	"time"
 )
 
@@ -8,6 +13,7 @@ type File struct {
 	Name   string  `json:"name,omitempty"`
 	Origin *Origin `json:"origin,omitempty"`
 	Size   int     `json:"size,omitempty"`
+	Hash   string  `json:"hash,omitempty"`
 
 	Modality     string   `json:"modality,omitempty"`
 	Mimetype     string   `json:"mimetype,omitempty"`
@@ -26,3 +32,162 @@ type FileFields struct {
 	Measurements []string `json:"measurements,omitempty"`
 	Type         string   `json:"type,omitempty"`
 }
+
+// The following functions manage the file at url, such as "sessions/<id>/files/<name>".
+// Each container type that has files has wrappers for them alongside its other functions.
+
+func (c *Client) deleteFile(url string) (*http.Response, error) {
+	var aerr *Error
+	var response *DeletedResponse
+
+	resp, err := c.New().Delete(url).Receive(&response, &aerr)
+
+	// Should not have to check this count
+	// https://github.com/scitran/core/issues/680
+	if err == nil && aerr == nil && response.DeletedCount != 1 {
+		return resp, errors.New("Deleting file " + url + " returned " + strconv.Itoa(response.DeletedCount) + " instead of 1")
+	}
+
+	return resp, Coalesce(err, aerr)
+}
+
+// containerFile returns the named file of the container at url, such as "sessions/<id>", and the container's other files.
+// A file that is not found is a not-found Error.
+func (c *Client) containerFile(url, filename string) (*File, []*File, *http.Response, error) {
+	var aerr *Error
+	var container *Container
+
+	resp, err := c.New().Get(url).Receive(&container, &aerr)
+	if err = Coalesce(err, aerr); err != nil {
+		return nil, nil, resp, err
+	}
+	for _, file := range container.Files {
+		if file.Name == filename {
+			return file, container.Files, resp, nil
+		}
+	}
+	return nil, container.Files, resp, &Error{StatusCode: 404, Message: "File " + filename + " not found in " + url, Method: "GET", URL: url}
+}
+
+// restoreFile gives a file of the container at url any modality, measurements, type, info and tags of original
+// that it does not already have. Only attributes that original sets are restored.
+func (c *Client) restoreFile(url, filename string, original *File) error {
+	current, _, _, err := c.containerFile(url, filename)
+	if err != nil {
+		return err
+	}
+	fileURL := url + "/files/" + filename
+
+	fields := &FileFields{}
+	changed := false
+	if original.Modality != "" && original.Modality != current.Modality {
+		fields.Modality, changed = original.Modality, true
+	}
+	if len(original.Measurements) > 0 && !reflect.DeepEqual(original.Measurements, current.Measurements) {
+		fields.Measurements, changed = original.Measurements, true
+	}
+	if original.Type != "" && original.Type != current.Type {
+		fields.Type, changed = original.Type, true
+	}
+	if changed {
+		if _, _, err := c.modifyFileAttrs(fileURL, fields); err != nil {
+			return err
+		}
+	}
+
+	if len(original.Info) > 0 && !reflect.DeepEqual(original.Info, current.Info) {
+		if _, err := c.replaceInfo(fileURL+"/info", original.Info); err != nil {
+			return err
+		}
+	}
+
+	has := map[string]bool{}
+	for _, tag := range current.Tags {
+		has[tag] = true
+	}
+	for _, tag := range original.Tags {
+		if !has[tag] {
+			if _, err := c.addTag(fileURL, tag); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// replaceFile uploads new contents for an existing file of the container at url, such as "sessions/<id>".
+// The API replaces a file when one of the same name is uploaded. The file's modality, measurements, type, info
+// and tags are then restored, should the server not have kept them.
+//
+// Replacing is not atomic. The file is looked up, uploaded and restored in separate requests, so a change made
+// to the file by someone else in between may be lost, and if restoring fails the new contents are left without
+// their metadata.
+func (c *Client) replaceFile(url, filename string, source *UploadSource) (chan int64, chan error) {
+	// Without this check, the upload would add a new file instead
+	original, _, _, err := c.containerFile(url, filename)
+	if err != nil {
+		return failedTransfer(err)
+	}
+
+	// The upload is named for the file it replaces, whatever the source is called
+	named := *source
+	named.Name = filename
+	progress, uploaded := c.UploadSimple(url+"/files", nil, &named)
+
+	result := make(chan error, 1)
+	go func() {
+		err := <-uploaded
+		if err == nil {
+			err = c.restoreFile(url, filename, original)
+		}
+		result <- err
+	}()
+	return progress, result
+}
+
+// renameFile gives a file of the container at url, such as "sessions/<id>", a new name.
+// The API cannot rename files, so the contents are copied to a file of the new name, which is given the
+// modality, measurements, type, info and tags of the old one, and the old file is then deleted.
+//
+// Renaming is not atomic. Until it completes both files exist, and if a step fails the new file may be left
+// alongside the old one. A file of the new name that appears after the check for one is replaced.
+func (c *Client) renameFile(url, filename, newName string) (*http.Response, error) {
+	original, files, resp, err := c.containerFile(url, filename)
+	if err != nil {
+		return resp, err
+	}
+	for _, file := range files {
+		if file.Name == newName {
+			return resp, &Error{StatusCode: 409, Message: "File " + newName + " already exists in " + url, Method: "GET", URL: url}
+		}
+	}
+
+	// Stream the download into the upload; the pipe is closed with the download's error, so a failed
+	// download cannot be uploaded as a complete file
+	reader, writer := io.Pipe()
+	_, downloaded := c.DownloadSimple(url+"/files/"+filename, &DownloadSource{Writer: openWriter{writer}})
+	go func() {
+		writer.CloseWithError(<-downloaded)
+	}()
+
+	_, uploaded := c.UploadSimple(url+"/files", nil, &UploadSource{Name: newName, Reader: reader})
+	if err := <-uploaded; err != nil {
+		reader.CloseWithError(err)
+		return nil, err
+	}
+
+	if err := c.restoreFile(url, newName, original); err != nil {
+		return nil, err
+	}
+	return c.deleteFile(url + "/files/" + filename)
+}
+
+// openWriter is an io.WriteCloser whose Close does nothing, so that its writer can be closed by its owner instead.
+type openWriter struct {
+	io.Writer
+}
+
+// Close implements io.Closer.
+func (openWriter) Close() error {
+	return nil
+}
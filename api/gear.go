@@ -37,8 +37,10 @@ type Gear struct {
 type GearCategory string
 
 const (
-	Utility  GearCategory = "utility"
-	Analysis GearCategory = "analysis"
+	Utility GearCategory = "utility"
+
+	// Named AnalysisCategory, as Analysis is the analysis container type
+	AnalysisCategory GearCategory = "analysis"
 
 	// Legacy category; equivalent to Utility
 	Converter GearCategory = "converter"
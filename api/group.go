@@ -1,9 +1,7 @@
 package api
 
 import (
-	"errors"
 	"net/http"
-	"strconv"
 	"time"
 )
 
@@ -35,6 +33,13 @@ func (c *Client) GetGroup(id string) (*Group, *http.Response, error) {
 	return group, resp, Coalesce(err, aerr)
 }
 
+func (c *Client) GetGroupProjects(id string) ([]*Project, *http.Response, error) {
+	var aerr *Error
+	var projects []*Project
+	resp, err := c.New().Get("groups/"+id+"/projects").Receive(&projects, &aerr)
+	return projects, resp, Coalesce(err, aerr)
+}
+
 func (c *Client) AddGroup(group *Group) (string, *http.Response, error) {
 	var aerr *Error
 	var response *IdResponse
@@ -50,51 +55,33 @@ func (c *Client) AddGroup(group *Group) (string, *http.Response, error) {
 }
 
 func (c *Client) AddGroupTag(id, tag string) (*http.Response, error) {
-	var aerr *Error
-	var response *ModifiedResponse
-
-	var tagDoc interface{}
-	tagDoc = map[string]interface{}{
-		"value": tag,
-	}
-
-	resp, err := c.New().Post("groups/"+id+"/tags").BodyJSON(tagDoc).Receive(&response, &aerr)
+	return c.AddContainerTag(&ContainerRef{Type: "group", Id: id}, tag)
+}
 
-	// Should not have to check this count
-	// https://github.com/scitran/core/issues/680
-	if err == nil && aerr == nil && response.ModifiedCount != 1 {
-		return resp, errors.New("Modifying group " + id + " returned " + strconv.Itoa(response.ModifiedCount) + " instead of 1")
-	}
+func (c *Client) RenameGroupTag(id, tag, newTag string) (*http.Response, error) {
+	return c.RenameContainerTag(&ContainerRef{Type: "group", Id: id}, tag, newTag)
+}
 
-	return resp, Coalesce(err, aerr)
+func (c *Client) DeleteGroupTag(id, tag string) (*http.Response, error) {
+	return c.DeleteContainerTag(&ContainerRef{Type: "group", Id: id}, tag)
 }
 
 func (c *Client) ModifyGroup(id string, group *Group) (*http.Response, error) {
-	var aerr *Error
-	var response *ModifiedResponse
-
-	resp, err := c.New().Put("groups/"+id).BodyJSON(group).Receive(&response, &aerr)
-
-	// Should not have to check this count
-	// https://github.com/scitran/core/issues/680
-	if err == nil && aerr == nil && response.ModifiedCount != 1 {
-		return resp, errors.New("Modifying group " + id + " returned " + strconv.Itoa(response.ModifiedCount) + " instead of 1")
-	}
-
-	return resp, Coalesce(err, aerr)
+	return c.ModifyContainer(&ContainerRef{Type: "group", Id: id}, group)
 }
 
 func (c *Client) DeleteGroup(id string) (*http.Response, error) {
-	var aerr *Error
-	var response *DeletedResponse
+	return c.DeleteContainer(&ContainerRef{Type: "group", Id: id})
+}
 
-	resp, err := c.New().Delete("groups/"+id).Receive(&response, &aerr)
+func (c *Client) SetGroupInfo(id string, set map[string]interface{}) (*http.Response, error) {
+	return c.SetContainerInfo(&ContainerRef{Type: "group", Id: id}, set)
+}
 
-	// Should not have to check this count
-	// https://github.com/scitran/core/issues/680
-	if err == nil && aerr == nil && response.DeletedCount != 1 {
-		return resp, errors.New("Deleting group " + id + " returned " + strconv.Itoa(response.DeletedCount) + " instead of 1")
-	}
+func (c *Client) ReplaceGroupInfo(id string, replace map[string]interface{}) (*http.Response, error) {
+	return c.ReplaceContainerInfo(&ContainerRef{Type: "group", Id: id}, replace)
+}
 
-	return resp, Coalesce(err, aerr)
+func (c *Client) DeleteGroupInfoFields(id string, keys []string) (*http.Response, error) {
+	return c.DeleteContainerInfoFields(&ContainerRef{Type: "group", Id: id}, keys)
 }
@@ -0,0 +1,264 @@
+package api
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Limiter caps the request rate and the number of in-flight requests of the clients that share it.
+// A Limiter must be created by NewLimiter, and is safe for concurrent use. See LimitRequests.
+//
+// A request is in flight from the moment it is sent until its response body is closed,
+// so long-running uploads and downloads hold their slot for their entire transfer.
+type Limiter struct {
+	mu sync.Mutex
+
+	// Token bucket; a rate of zero is unlimited
+	rate   float64
+	burst  int
+	tokens float64
+	last   time.Time
+
+	// In-flight cap; zero is unlimited
+	maxInFlight int
+	inFlight    int
+
+	// changed is closed and replaced whenever a slot is released or a limit is adjusted, waking any waiters
+	changed chan struct{}
+
+	stats LimiterStats
+}
+
+// LimiterStats reports how much a Limiter has delayed requests.
+type LimiterStats struct {
+	// Requests is the number of requests admitted by the limiter.
+	Requests int64
+
+	// Delayed is the number of requests that had to wait before being admitted.
+	Delayed int64
+
+	// TotalWait and MaxWait are the cumulative and longest time spent waiting by a request.
+	TotalWait time.Duration
+	MaxWait   time.Duration
+
+	// InFlight is the number of requests currently in flight.
+	InFlight int
+}
+
+// NewLimiter returns a Limiter that admits rate requests per second, with bursts of up to burst requests,
+// and at most maxInFlight concurrent requests. A rate or maxInFlight of zero disables that limit.
+func NewLimiter(rate float64, burst int, maxInFlight int) *Limiter {
+	l := &Limiter{
+		changed: make(chan struct{}),
+	}
+	l.SetRate(rate, burst)
+	l.SetMaxInFlight(maxInFlight)
+	return l
+}
+
+// SetRate changes the request rate and burst size. Takes effect immediately, including for waiting requests.
+// Tokens already in the bucket are kept, up to the new burst size; a new Limiter starts with a full bucket.
+func (l *Limiter) SetRate(rate float64, burst int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if burst < 1 {
+		burst = 1
+	}
+
+	now := time.Now()
+	if l.last.IsZero() {
+		l.tokens = float64(burst)
+	} else {
+		l.refill(now)
+	}
+
+	l.rate = rate
+	l.burst = burst
+	if l.tokens > float64(burst) {
+		l.tokens = float64(burst)
+	}
+	l.last = now
+	l.broadcast()
+}
+
+// SetMaxInFlight changes the in-flight request cap. Takes effect immediately, including for waiting requests.
+func (l *Limiter) SetMaxInFlight(maxInFlight int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.maxInFlight = maxInFlight
+	l.broadcast()
+}
+
+// Stats returns a snapshot of the limiter's statistics.
+func (l *Limiter) Stats() LimiterStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	stats := l.stats
+	stats.InFlight = l.inFlight
+	return stats
+}
+
+// broadcast wakes all waiters. Must be called with mu held.
+func (l *Limiter) broadcast() {
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+// acquire blocks until a request may be sent, or ctx is done.
+// Each successful call must be paired with a call to release.
+func (l *Limiter) acquire(ctx context.Context) error {
+	begin := time.Now()
+
+	err := l.acquireSlot(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = l.takeToken(ctx)
+	if err != nil {
+		l.release()
+		return err
+	}
+
+	wait := time.Since(begin)
+
+	l.mu.Lock()
+	l.stats.Requests++
+	if wait > time.Millisecond {
+		l.stats.Delayed++
+		l.stats.TotalWait += wait
+		if wait > l.stats.MaxWait {
+			l.stats.MaxWait = wait
+		}
+	}
+	l.mu.Unlock()
+
+	return nil
+}
+
+// acquireSlot blocks until the number of in-flight requests is under the cap.
+func (l *Limiter) acquireSlot(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		if l.maxInFlight <= 0 || l.inFlight < l.maxInFlight {
+			l.inFlight++
+			l.mu.Unlock()
+			return nil
+		}
+		changed := l.changed
+		l.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// takeToken blocks until a token is available in the bucket.
+func (l *Limiter) takeToken(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		if l.rate <= 0 {
+			l.mu.Unlock()
+			return nil
+		}
+
+		l.refill(time.Now())
+
+		if l.tokens >= 1 {
+			l.tokens--
+			l.mu.Unlock()
+			return nil
+		}
+
+		wait := time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+		changed := l.changed
+		l.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		case <-time.After(wait):
+		}
+	}
+}
+
+// refill adds the tokens accrued since the bucket was last updated, up to the burst size. Must be called with mu held.
+func (l *Limiter) refill(now time.Time) {
+	if l.rate > 0 {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > float64(l.burst) {
+			l.tokens = float64(l.burst)
+		}
+	}
+	l.last = now
+}
+
+// release frees an in-flight slot.
+func (l *Limiter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.inFlight--
+	l.broadcast()
+}
+
+// LimitTransport delays requests as required by a Limiter.
+type LimitTransport struct {
+	Limiter *Limiter
+
+	// Transport is the underlying HTTP transport to use when making requests.
+	// It will default to http.DefaultTransport if nil.
+	Transport http.RoundTripper
+}
+
+// RoundTrip implements the RoundTripper interface.
+func (t *LimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	transport := t.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	err := t.Limiter.acquire(req.Context())
+	if err != nil {
+		// RoundTrippers must always close the request body
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Limiter.release()
+		return resp, err
+	}
+
+	// Hold the slot until the response has been consumed
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: t.Limiter.release}
+	return resp, nil
+}
+
+// releasingBody releases a Limiter slot when closed.
+type releasingBody struct {
+	io.ReadCloser
+
+	release func()
+	once    sync.Once
+}
+
+// Close implements io.Closer.
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
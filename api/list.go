@@ -0,0 +1,206 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// ListOptions narrow, order and page a listing of containers. The zero value lists everything.
+//
+// Options are sent as the limit, after_id, filter, sort and fields query parameters of the listing routes.
+// The in-memory server of the apitest package implements them as described here; a server that does not
+// support a parameter ignores it, so check your server's version before relying on them.
+type ListOptions struct {
+	// Limit is the most results returned. Zero means no limit.
+	Limit int `url:"limit,omitempty"`
+
+	// AfterId returns only results whose Id sorts after it, so that a listing can be paged through in Id order.
+	// Paged listings are in Id order unless Sort is set.
+	AfterId string `url:"after_id,omitempty"`
+
+	// Filter is a comma-separated list of conditions that results must all meet, such as "label=~^sub,created>2017-01-01".
+	// Each compares a field, which may be a dotted path such as "info.site", with a value using one of
+	// =, !=, <, <=, >, >=, or =~ for a regular expression. Values are compared as numbers when both are numeric.
+	Filter string `url:"filter,omitempty"`
+
+	// Sort is a comma-separated list of fields to order results by, each optionally followed by ":asc" or ":desc",
+	// such as "label,created:desc".
+	Sort string `url:"sort,omitempty"`
+
+	// Fields limits each result to the given fields, such as "label" and "info.site". Ids are always included.
+	Fields []string `url:"fields,comma,omitempty"`
+}
+
+// DefaultPageSize is the number of results fetched at once by a ContainerIterator whose options set no Limit.
+const DefaultPageSize = 1000
+
+// listContainers decodes a listing of containers of a type, such as "session", into result.
+func (c *Client) listContainers(containerType string, options *ListOptions, result interface{}) (*http.Response, error) {
+	prefix, ok := containerPrefixes[containerType]
+	if !ok {
+		return nil, errors.New("Unknown container type " + strconv.Quote(containerType))
+	}
+
+	var aerr *Error
+	req := c.New().Get(prefix)
+	if options != nil {
+		req = req.QueryStruct(options)
+	}
+
+	resp, err := req.Receive(result, &aerr)
+	return resp, Coalesce(err, aerr)
+}
+
+// ListContainers returns the containers of a type, such as "session", that match the options.
+func (c *Client) ListContainers(containerType string, options *ListOptions) ([]*Container, *http.Response, error) {
+	var containers []*Container
+	resp, err := c.listContainers(containerType, options, &containers)
+	return containers, resp, err
+}
+
+func (c *Client) ListGroups(options *ListOptions) ([]*Group, *http.Response, error) {
+	var groups []*Group
+	resp, err := c.listContainers("group", options, &groups)
+	return groups, resp, err
+}
+
+func (c *Client) ListProjects(options *ListOptions) ([]*Project, *http.Response, error) {
+	var projects []*Project
+	resp, err := c.listContainers("project", options, &projects)
+	return projects, resp, err
+}
+
+func (c *Client) ListSessions(options *ListOptions) ([]*Session, *http.Response, error) {
+	var sessions []*Session
+	resp, err := c.listContainers("session", options, &sessions)
+	return sessions, resp, err
+}
+
+func (c *Client) ListAcquisitions(options *ListOptions) ([]*Acquisition, *http.Response, error) {
+	var acquisitions []*Acquisition
+	resp, err := c.listContainers("acquisition", options, &acquisitions)
+	return acquisitions, resp, err
+}
+
+func (c *Client) ListCollections(options *ListOptions) ([]*Collection, *http.Response, error) {
+	var collections []*Collection
+	resp, err := c.listContainers("collection", options, &collections)
+	return collections, resp, err
+}
+
+// ContainerIterator steps through a listing of containers a page at a time, so that only one page is held in memory.
+//
+//	it := client.IterateContainers("acquisition", &api.ListOptions{Filter: "label=~^T1"})
+//	for it.Next() {
+//		var acquisition api.Acquisition
+//		err := it.Decode(&acquisition)
+//		...
+//	}
+//	if it.Err() != nil {
+//		...
+//	}
+type ContainerIterator struct {
+	c             *Client
+	containerType string
+	options       ListOptions
+
+	page  []json.RawMessage
+	index int
+	last  bool
+	err   error
+}
+
+// IterateContainers returns an iterator over the containers of a type, such as "session", that match the options.
+// Pages are fetched in Id order, starting after options.AfterId if set; options.Limit sets the page size.
+// Options must not set Sort, which would break paging by Id.
+func (c *Client) IterateContainers(containerType string, options *ListOptions) *ContainerIterator {
+	it := &ContainerIterator{
+		c:             c,
+		containerType: containerType,
+		index:         -1,
+	}
+	if options != nil {
+		it.options = *options
+	}
+	if it.options.Limit < 1 {
+		it.options.Limit = DefaultPageSize
+	}
+	if it.options.Sort != "" {
+		it.err = errors.New("Cannot iterate over containers in an order other than by Id")
+	}
+	return it
+}
+
+// Next advances to the next container, fetching another page if needed.
+// It returns false at the end of the listing, or if fetching a page failed, in which case Err returns the error.
+func (it *ContainerIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+
+	if it.index+1 < len(it.page) {
+		it.index++
+		return true
+	}
+	if it.last {
+		return false
+	}
+
+	// Continue after the last container of the previous page
+	if len(it.page) > 0 {
+		if it.options.AfterId, it.err = pageId(it.page[len(it.page)-1]); it.err != nil {
+			return false
+		}
+	}
+
+	it.page, it.index = nil, -1
+	if _, it.err = it.c.listContainers(it.containerType, &it.options, &it.page); it.err != nil {
+		return false
+	}
+	it.last = len(it.page) < it.options.Limit
+
+	// A server that ignores after_id sends the same page again, which would never end
+	if it.options.AfterId != "" && len(it.page) > 0 {
+		var first string
+		if first, it.err = pageId(it.page[0]); it.err != nil {
+			return false
+		}
+		if first <= it.options.AfterId {
+			it.err = errors.New("Listing " + it.containerType + "s did not continue after " + it.options.AfterId + "; the server may not support paging with after_id")
+			return false
+		}
+	}
+
+	return it.Next()
+}
+
+// pageId returns the Id of a container in a page.
+func pageId(raw json.RawMessage) (string, error) {
+	var container struct {
+		Id string `json:"_id"`
+	}
+	err := json.Unmarshal(raw, &container)
+	return container.Id, err
+}
+
+// Container returns the current container, with the fields that every type may have.
+func (it *ContainerIterator) Container() *Container {
+	container := &Container{}
+	it.Decode(container)
+	return container
+}
+
+// Decode decodes the current container into a value such as *Session.
+func (it *ContainerIterator) Decode(v interface{}) error {
+	if it.index < 0 || it.index >= len(it.page) {
+		return errors.New("No current container; call Next first")
+	}
+	return json.Unmarshal(it.page[it.index], v)
+}
+
+// Err returns the error that ended iteration, if any.
+func (it *ContainerIterator) Err() error {
+	return it.err
+}
@@ -0,0 +1,356 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LogFormat selects how requests are logged. See DebugLogFormat.
+type LogFormat string
+
+const (
+	// LogText writes one human-readable line per request.
+	LogText LogFormat = "text"
+
+	// LogJSON writes one JSON object per request, separated by newlines.
+	LogJSON LogFormat = "json"
+
+	// LogRaw writes an HTTP/1.1 representation of each request and response. See DebugTransport.
+	LogRaw LogFormat = "raw"
+)
+
+// DefaultLogBodyBytes is the default size above which logged bodies are truncated.
+const DefaultLogBodyBytes = 64 * 1024
+
+// redacted replaces the value of credentials in logs.
+const redacted = "[REDACTED]"
+
+// Headers and query parameters whose values are credentials.
+var sensitiveHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+var sensitiveParams = []string{"key", "api_key", "token", "ticket"}
+
+// redactHeader returns a copy of header with the values of credentials replaced.
+// The authorization scheme, such as "scitran-user", is kept.
+func redactHeader(header http.Header) http.Header {
+	clean := make(http.Header, len(header))
+	for k, v := range header {
+		clean[k] = v
+	}
+
+	for _, name := range sensitiveHeaders {
+		values := clean[http.CanonicalHeaderKey(name)]
+		if len(values) == 0 {
+			continue
+		}
+
+		replaced := make([]string, len(values))
+		for i, value := range values {
+			replaced[i] = redacted
+			if name == "Authorization" {
+				if space := strings.Index(value, " "); space > 0 {
+					replaced[i] = value[:space+1] + redacted
+				}
+			}
+		}
+		clean[http.CanonicalHeaderKey(name)] = replaced
+	}
+
+	return clean
+}
+
+// redactURL returns a copy of u with the values of credential query parameters replaced.
+func redactURL(u *url.URL) *url.URL {
+	clean := *u
+	query := clean.Query()
+
+	changed := false
+	for _, name := range sensitiveParams {
+		if _, ok := query[name]; ok {
+			query.Set(name, redacted)
+			changed = true
+		}
+	}
+	if changed {
+		clean.RawQuery = query.Encode()
+	}
+
+	return &clean
+}
+
+// isTextContent reports whether a content type is safe and useful to log.
+func isTextContent(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	return strings.HasPrefix(mediaType, "text/") ||
+		mediaType == "application/json" ||
+		strings.HasSuffix(mediaType, "+json") ||
+		mediaType == "application/x-www-form-urlencoded"
+}
+
+// RequestLogEntry is a structured record of one request, as written by LogTransport.
+type RequestLogEntry struct {
+	Time     time.Time     `json:"time"`
+	Method   string        `json:"method"`
+	URL      string        `json:"url"`
+	Status   int           `json:"status,omitempty"`
+	Duration time.Duration `json:"duration_ns"`
+
+	RequestBytes  int64 `json:"request_bytes"`
+	ResponseBytes int64 `json:"response_bytes"`
+
+	Headers      http.Header `json:"headers,omitempty"`
+	RequestBody  string      `json:"request_body,omitempty"`
+	ResponseBody string      `json:"response_body,omitempty"`
+
+	Error string `json:"error,omitempty"`
+}
+
+// String formats the entry as a single line of text.
+func (e *RequestLogEntry) String() string {
+	var b strings.Builder
+
+	b.WriteString(e.Time.Format(time.RFC3339Nano))
+	b.WriteString(" " + e.Method + " " + e.URL)
+	if e.Status != 0 {
+		b.WriteString(" " + strconv.Itoa(e.Status))
+	}
+	b.WriteString(" " + e.Duration.String())
+	b.WriteString(" sent=" + strconv.FormatInt(e.RequestBytes, 10))
+	b.WriteString(" received=" + strconv.FormatInt(e.ResponseBytes, 10))
+	if e.Error != "" {
+		b.WriteString(" error=" + strconv.Quote(e.Error))
+	}
+	names := make([]string, 0, len(e.Headers))
+	for name := range e.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range e.Headers[name] {
+			b.WriteString("\n\t" + name + ": " + value)
+		}
+	}
+	if e.RequestBody != "" {
+		b.WriteString("\n\trequest: " + e.RequestBody)
+	}
+	if e.ResponseBody != "" {
+		b.WriteString("\n\tresponse: " + e.ResponseBody)
+	}
+
+	return b.String()
+}
+
+// LogTransport writes a structured entry for each request to a writer, redacting credentials.
+//
+// An entry is written once the response body is closed, so that the full transfer size and duration are known.
+type LogTransport struct {
+
+	// All requests made with this transport will be written to Writer.
+	// It will default to os.Stderr if nil.
+	Writer io.Writer
+
+	// Format of each entry: LogText or LogJSON. Defaults to LogText.
+	Format LogFormat
+
+	// Headers enables logging of request headers.
+	Headers bool
+
+	// Bodies enables logging of request and response bodies.
+	// Only textual bodies, such as JSON, are logged; binary and multipart bodies never are.
+	Bodies bool
+
+	// MaxBodyBytes truncates logged bodies. Defaults to DefaultLogBodyBytes if zero.
+	MaxBodyBytes int
+
+	// ShowCredentials disables redaction. Should only be used for development.
+	ShowCredentials bool
+
+	// Transport is the underlying HTTP transport to use when making requests.
+	// It will default to http.DefaultTransport if nil.
+	Transport http.RoundTripper
+
+	// Serializes writes, so that concurrent entries do not interleave.
+	mu sync.Mutex
+}
+
+// RoundTrip implements the RoundTripper interface.
+func (t *LogTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	transport := t.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	logURL, header := req.URL, req.Header
+	if !t.ShowCredentials {
+		logURL, header = redactURL(req.URL), redactHeader(req.Header)
+	}
+
+	entry := &RequestLogEntry{
+		Time:   time.Now(),
+		Method: req.Method,
+		URL:    logURL.String(),
+	}
+	if t.Headers {
+		entry.Headers = header
+	}
+
+	// Count, and possibly capture, the request body as the transport reads it
+	var sent *loggingBody
+	if req.Body != nil && req.Body != http.NoBody {
+		sent = t.newLoggingBody(req.Body, req.Header.Get("Content-Type"), nil)
+		req = req.Clone(req.Context())
+		req.Body = sent
+	}
+
+	resp, err := transport.RoundTrip(req)
+
+	finish := func(received *loggingBody) {
+		entry.Duration = time.Since(entry.Time)
+		if sent != nil {
+			entry.RequestBytes = sent.bytesRead()
+			entry.RequestBody = sent.captured()
+		}
+		if received != nil {
+			entry.ResponseBytes = received.bytesRead()
+			entry.ResponseBody = received.captured()
+		}
+		t.write(entry)
+	}
+
+	if err != nil {
+		entry.Error = err.Error()
+		finish(nil)
+		return resp, err
+	}
+
+	entry.Status = resp.StatusCode
+	resp.Body = t.newLoggingBody(resp.Body, resp.Header.Get("Content-Type"), finish)
+	return resp, nil
+}
+
+// newLoggingBody wraps a body, capturing it if it should be logged.
+func (t *LogTransport) newLoggingBody(body io.ReadCloser, contentType string, onClose func(*loggingBody)) *loggingBody {
+	b := &loggingBody{ReadCloser: body, onClose: onClose}
+
+	if t.Bodies {
+		if isTextContent(contentType) {
+			b.limit = t.MaxBodyBytes
+			if b.limit <= 0 {
+				b.limit = DefaultLogBodyBytes
+			}
+			b.capture = &bytes.Buffer{}
+		} else if contentType != "" {
+			b.omitted, _, _ = mime.ParseMediaType(contentType)
+			if b.omitted == "" {
+				b.omitted = "binary"
+			}
+		}
+	}
+
+	return b
+}
+
+// write serializes an entry to the writer.
+func (t *LogTransport) write(entry *RequestLogEntry) {
+	var line []byte
+	if t.Format == LogJSON {
+		line, _ = json.Marshal(entry)
+	} else {
+		line = []byte(entry.String())
+	}
+	line = append(line, '\n')
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.Writer == nil {
+		os.Stderr.Write(line)
+	} else {
+		t.Writer.Write(line)
+	}
+}
+
+// loggingBody counts, and optionally captures, the bytes read through it.
+// A request body may still be read by the transport after the response arrives, so access is guarded by mu.
+type loggingBody struct {
+	io.ReadCloser
+
+	mu    sync.Mutex
+	count int64
+
+	capture *bytes.Buffer
+	limit   int
+	omitted string
+
+	onClose func(*loggingBody)
+	once    sync.Once
+}
+
+// Read implements io.Reader.
+func (b *loggingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.count += int64(n)
+
+	if b.capture != nil && b.capture.Len() < b.limit {
+		remaining := b.limit - b.capture.Len()
+		if n < remaining {
+			remaining = n
+		}
+		b.capture.Write(p[:remaining])
+	}
+
+	return n, err
+}
+
+// Close implements io.Closer.
+func (b *loggingBody) Close() error {
+	err := b.ReadCloser.Close()
+	if b.onClose != nil {
+		b.once.Do(func() { b.onClose(b) })
+	}
+	return err
+}
+
+// bytesRead returns the number of bytes read so far.
+func (b *loggingBody) bytesRead() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.count
+}
+
+// captured returns the logged form of the body.
+func (b *loggingBody) captured() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.omitted != "" {
+		return "[" + strconv.FormatInt(b.count, 10) + " bytes of " + b.omitted + " omitted]"
+	}
+	if b.capture == nil {
+		return ""
+	}
+
+	result := b.capture.String()
+	if b.count > int64(b.capture.Len()) {
+		result += "... [truncated " + strconv.FormatInt(b.count-int64(b.capture.Len()), 10) + " bytes]"
+	}
+	return result
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// The following functions manage the notes of the container at url, such as "sessions/<id>".
+// Each container type that has notes has wrappers for them alongside its other functions.
+//
+// Only a note's author, or an admin of its container, may modify or delete it; the server enforces this.
+// Other users receive an error for which IsForbidden is true.
+
+func (c *Client) addNote(url, text string) (*http.Response, error) {
+	var aerr *Error
+	var response *ModifiedResponse
+
+	note := &Note{
+		Text: text,
+	}
+
+	resp, err := c.New().Post(url+"/notes").BodyJSON(note).Receive(&response, &aerr)
+
+	// Should not have to check this count
+	// https://github.com/scitran/core/issues/680
+	if err == nil && aerr == nil && response.ModifiedCount != 1 {
+		return resp, errors.New("Adding note to " + url + " returned " + strconv.Itoa(response.ModifiedCount) + " instead of 1")
+	}
+
+	return resp, Coalesce(err, aerr)
+}
+
+func (c *Client) getNote(url, noteId string) (*Note, *http.Response, error) {
+	var aerr *Error
+	var note *Note
+	resp, err := c.New().Get(url+"/notes/"+noteId).Receive(&note, &aerr)
+	return note, resp, Coalesce(err, aerr)
+}
+
+func (c *Client) modifyNote(url, noteId, text string) (*http.Response, error) {
+	var aerr *Error
+	var response *ModifiedResponse
+
+	note := &Note{
+		Text: text,
+	}
+
+	resp, err := c.New().Put(url+"/notes/"+noteId).BodyJSON(note).Receive(&response, &aerr)
+
+	// Should not have to check this count
+	// https://github.com/scitran/core/issues/680
+	if err == nil && aerr == nil && response.ModifiedCount != 1 {
+		return resp, errors.New("Modifying note " + noteId + " on " + url + " returned " + strconv.Itoa(response.ModifiedCount) + " instead of 1")
+	}
+
+	return resp, Coalesce(err, aerr)
+}
+
+func (c *Client) deleteNote(url, noteId string) (*http.Response, error) {
+	var aerr *Error
+	var response *DeletedResponse
+
+	resp, err := c.New().Delete(url+"/notes/"+noteId).Receive(&response, &aerr)
+
+	// Should not have to check this count
+	// https://github.com/scitran/core/issues/680
+	if err == nil && aerr == nil && response.DeletedCount != 1 {
+		return resp, errors.New("Deleting note " + noteId + " from " + url + " returned " + strconv.Itoa(response.DeletedCount) + " instead of 1")
+	}
+
+	return resp, Coalesce(err, aerr)
+}
@@ -25,6 +25,7 @@ func (c *Client) modifyFileAttrs(url string, attributes *FileFields) (*http.Resp
 }
 
 // Helper func
+// Keys may be dotted paths, such as "a.b", to set a nested field without changing its siblings.
 func (c *Client) setInfo(url string, set map[string]interface{}) (*http.Response, error) {
 	var aerr *Error
 	var response *ModifiedResponse
@@ -38,7 +39,7 @@ func (c *Client) setInfo(url string, set map[string]interface{}) (*http.Response
 	// Should not have to check this count
 	// https://github.com/scitran/core/issues/680
 	if err == nil && aerr == nil && response.ModifiedCount != 1 {
-		return resp, errors.New("Modifying file returned " + strconv.Itoa(response.ModifiedCount) + " instead of 1")
+		return resp, errors.New("Modifying " + url + " returned " + strconv.Itoa(response.ModifiedCount) + " instead of 1")
 	}
 
 	return resp, Coalesce(err, aerr)
@@ -58,13 +59,14 @@ func (c *Client) replaceInfo(url string, replace map[string]interface{}) (*http.
 	// Should not have to check this count
 	// https://github.com/scitran/core/issues/680
 	if err == nil && aerr == nil && response.ModifiedCount != 1 {
-		return resp, errors.New("Modifying file returned " + strconv.Itoa(response.ModifiedCount) + " instead of 1")
+		return resp, errors.New("Modifying " + url + " returned " + strconv.Itoa(response.ModifiedCount) + " instead of 1")
 	}
 
 	return resp, Coalesce(err, aerr)
 }
 
 // Helper func
+// Keys may be dotted paths, such as "a.b", to delete a nested field without changing its siblings.
 func (c *Client) deleteInfoFields(url string, keys []string) (*http.Response, error) {
 	var aerr *Error
 	var response *ModifiedResponse
@@ -78,7 +80,7 @@ func (c *Client) deleteInfoFields(url string, keys []string) (*http.Response, er
 	// Should not have to check this count
 	// https://github.com/scitran/core/issues/680
 	if err == nil && aerr == nil && response.ModifiedCount != 1 {
-		return resp, errors.New("Modifying file returned " + strconv.Itoa(response.ModifiedCount) + " instead of 1")
+		return resp, errors.New("Modifying " + url + " returned " + strconv.Itoa(response.ModifiedCount) + " instead of 1")
 	}
 
 	return resp, Coalesce(err, aerr)
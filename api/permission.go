@@ -0,0 +1,180 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// AccessLevel is the capability a Permission grants.
+type AccessLevel string
+
+const (
+	// NoAccess is not a level the API grants; it is returned when a user has no permission on a container.
+	NoAccess AccessLevel = ""
+
+	ReadOnly  AccessLevel = "ro"
+	ReadWrite AccessLevel = "rw"
+	Admin     AccessLevel = "admin"
+)
+
+// Rank of each access level, from least to most capable.
+var accessRanks = map[AccessLevel]int{
+	NoAccess:  0,
+	ReadOnly:  1,
+	ReadWrite: 2,
+	Admin:     3,
+}
+
+// Allows reports whether a level grants at least the capability of another.
+// Unknown levels allow nothing.
+func (a AccessLevel) Allows(required AccessLevel) bool {
+	rank, ok := accessRanks[a]
+	return ok && rank >= accessRanks[required]
+}
+
+// The following functions manage the permissions of the container at url, such as "projects/<id>".
+// Each container type that has its own permissions has wrappers for them below.
+
+func (c *Client) addPermission(url string, permission *Permission) (*http.Response, error) {
+	var aerr *Error
+	var response *ModifiedResponse
+
+	resp, err := c.New().Post(url+"/permissions").BodyJSON(permission).Receive(&response, &aerr)
+
+	// Should not have to check this count
+	// https://github.com/scitran/core/issues/680
+	if err == nil && aerr == nil && response.ModifiedCount != 1 {
+		return resp, errors.New("Adding permission to " + url + " returned " + strconv.Itoa(response.ModifiedCount) + " instead of 1")
+	}
+
+	return resp, Coalesce(err, aerr)
+}
+
+func (c *Client) modifyPermission(url string, permission *Permission) (*http.Response, error) {
+	var aerr *Error
+	var response *ModifiedResponse
+
+	resp, err := c.New().Put(url+"/permissions/"+permission.Id).BodyJSON(permission).Receive(&response, &aerr)
+
+	// Should not have to check this count
+	// https://github.com/scitran/core/issues/680
+	if err == nil && aerr == nil && response.ModifiedCount != 1 {
+		return resp, errors.New("Modifying permission on " + url + " returned " + strconv.Itoa(response.ModifiedCount) + " instead of 1")
+	}
+
+	return resp, Coalesce(err, aerr)
+}
+
+func (c *Client) deletePermission(url, userId string) (*http.Response, error) {
+	var aerr *Error
+	var response *ModifiedResponse
+
+	resp, err := c.New().Delete(url+"/permissions/"+userId).Receive(&response, &aerr)
+
+	// Should not have to check this count
+	// https://github.com/scitran/core/issues/680
+	if err == nil && aerr == nil && response.ModifiedCount != 1 {
+		return resp, errors.New("Deleting permission from " + url + " returned " + strconv.Itoa(response.ModifiedCount) + " instead of 1")
+	}
+
+	return resp, Coalesce(err, aerr)
+}
+
+func (c *Client) AddGroupPermission(id string, permission *Permission) (*http.Response, error) {
+	return c.addPermission("groups/"+id, permission)
+}
+
+func (c *Client) ModifyGroupPermission(id string, permission *Permission) (*http.Response, error) {
+	return c.modifyPermission("groups/"+id, permission)
+}
+
+func (c *Client) DeleteGroupPermission(id, userId string) (*http.Response, error) {
+	return c.deletePermission("groups/"+id, userId)
+}
+
+func (c *Client) AddProjectPermission(id string, permission *Permission) (*http.Response, error) {
+	return c.addPermission("projects/"+id, permission)
+}
+
+func (c *Client) ModifyProjectPermission(id string, permission *Permission) (*http.Response, error) {
+	return c.modifyPermission("projects/"+id, permission)
+}
+
+func (c *Client) DeleteProjectPermission(id, userId string) (*http.Response, error) {
+	return c.deletePermission("projects/"+id, userId)
+}
+
+func (c *Client) AddCollectionPermission(id string, permission *Permission) (*http.Response, error) {
+	return c.addPermission("collections/"+id, permission)
+}
+
+func (c *Client) ModifyCollectionPermission(id string, permission *Permission) (*http.Response, error) {
+	return c.modifyPermission("collections/"+id, permission)
+}
+
+func (c *Client) DeleteCollectionPermission(id, userId string) (*http.Response, error) {
+	return c.deletePermission("collections/"+id, userId)
+}
+
+// GetEffectiveAccess returns a user's access to a container, by type and Id, such as "session" and its Id.
+//
+// This is the access granted on the container itself, or failing that, on its nearest ancestor that grants any.
+// Site administrators may have more access than their permissions show.
+func (c *Client) GetEffectiveAccess(containerType, id, userId string) (AccessLevel, *http.Response, error) {
+	var resp *http.Response
+
+	for containerType != "" {
+		var permissions []*Permission
+		var parentType, parentId string
+		var err error
+
+		switch containerType {
+		case "acquisition":
+			var acquisition *Acquisition
+			acquisition, resp, err = c.GetAcquisition(id)
+			if err == nil {
+				permissions, parentType, parentId = acquisition.Permissions, "session", acquisition.SessionId
+			}
+		case "session":
+			var session *Session
+			session, resp, err = c.GetSession(id)
+			if err == nil {
+				permissions, parentType, parentId = session.Permissions, "project", session.ProjectId
+			}
+		case "project":
+			var project *Project
+			project, resp, err = c.GetProject(id)
+			if err == nil {
+				permissions, parentType, parentId = project.Permissions, "group", project.GroupId
+			}
+		case "group":
+			var group *Group
+			group, resp, err = c.GetGroup(id)
+			if err == nil {
+				permissions = group.Permissions
+			}
+		case "collection":
+			var collection *Collection
+			collection, resp, err = c.GetCollection(id)
+			if err == nil {
+				permissions = collection.Permissions
+			}
+		default:
+			return NoAccess, nil, errors.New("Cannot check access to unknown container type " + containerType)
+		}
+		if err != nil {
+			return NoAccess, resp, err
+		}
+
+		for _, permission := range permissions {
+			if permission.Id == userId {
+				return permission.Level, resp, nil
+			}
+		}
+
+		containerType, id = parentType, parentId
+	}
+
+	return NoAccess, resp, nil
+}
@@ -0,0 +1,339 @@
+package api
+
+import (
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Environment variables read by NewClientFromEnvironment and NewClientFromProfile.
+// Where set, these override the contents of the config file.
+const (
+	// SdkConfigKey sets the path of the config file. Defaults to DefaultConfigPath.
+	SdkConfigKey = "SdkConfig"
+
+	// SdkProfileKey selects a profile from the config file, when none is named explicitly.
+	SdkProfileKey = "SdkProfile"
+
+	// SdkApiKeyKey sets the API key, in the form "host:port:key" or "host:key".
+	SdkApiKeyKey = "SdkApiKey"
+
+	// SdkInsecureSkipVerifyKey disables SSL verification when set to a true value, such as "true" or "1".
+	SdkInsecureSkipVerifyKey = "SdkInsecureSkipVerify"
+
+	// SdkInsecureUsePlaintextKey uses plaintext HTTP when set to a true value, such as "true" or "1".
+	SdkInsecureUsePlaintextKey = "SdkInsecureUsePlaintext"
+)
+
+// plaintextKeySuffix is appended to API keys given to the bridge to request plaintext HTTP.
+const plaintextKeySuffix = ",InsecureUsePlaintext"
+
+// Profile holds the settings needed to connect to one Flywheel instance.
+//
+// The instance can be given either as a complete ApiKey, or as separate Host, Port and Key fields.
+// Separate fields take precedence over the corresponding part of ApiKey.
+type Profile struct {
+	// ApiKey is a complete API key, in the form "host:port:key" or "host:key".
+	ApiKey string `json:"api_key,omitempty" yaml:"api_key,omitempty"`
+
+	Host string `json:"host,omitempty" yaml:"host,omitempty"`
+	Port int    `json:"port,omitempty" yaml:"port,omitempty"`
+	Key  string `json:"key,omitempty" yaml:"key,omitempty"`
+
+	// Skip SSL verification. Should only be used for development.
+	InsecureSkipVerify bool `json:"insecure_skip_verify,omitempty" yaml:"insecure_skip_verify,omitempty"`
+
+	// Use plaintext (HTTP) transport. Should only be used for development.
+	InsecureUsePlaintext bool `json:"insecure_use_plaintext,omitempty" yaml:"insecure_use_plaintext,omitempty"`
+
+	// Path to a PEM file of certificate authorities to verify the server against, instead of the system's.
+	CAFile string `json:"ca_file,omitempty" yaml:"ca_file,omitempty"`
+
+	// URL of a proxy to send all requests through.
+	Proxy string `json:"proxy,omitempty" yaml:"proxy,omitempty"`
+}
+
+// ConfigFile is a set of named profiles, as stored in the user's config file.
+// The file is YAML; as JSON is also YAML, a JSON file of the same shape works too.
+//
+//	default_profile: prod
+//	profiles:
+//	  prod:
+//	    api_key: flywheel.example.com:my-key
+//	  local:
+//	    host: localhost
+//	    port: 8443
+//	    key: change-me
+//	    insecure_skip_verify: true
+type ConfigFile struct {
+	// The profile to use when none is named.
+	DefaultProfile string `json:"default_profile,omitempty" yaml:"default_profile,omitempty"`
+
+	Profiles map[string]*Profile `json:"profiles,omitempty" yaml:"profiles,omitempty"`
+}
+
+// DefaultConfigPath returns the default location of the config file: flywheel/config.yaml, under $XDG_CONFIG_HOME or ~/.config.
+func DefaultConfigPath() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".config")
+	}
+
+	return filepath.Join(dir, "flywheel", "config.yaml"), nil
+}
+
+// LoadConfigFile reads a config file from path.
+func LoadConfigFile(path string) (*ConfigFile, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var config *ConfigFile
+	err = yaml.Unmarshal(raw, &config)
+	if err != nil {
+		return nil, errors.New("Invalid config file " + path + ": " + err.Error())
+	}
+	if config == nil {
+		config = &ConfigFile{}
+	}
+
+	return config, nil
+}
+
+// Profile returns a copy of the named profile, or the default profile if name is empty.
+// A profile whose key fields cannot form a valid API key is reported here, rather than when a client is created from it.
+func (c *ConfigFile) Profile(name string) (*Profile, error) {
+	if name == "" {
+		name = c.DefaultProfile
+	}
+	if name == "" {
+		return nil, errors.New("No profile was named, and the config file has no default profile")
+	}
+
+	profile, ok := c.Profiles[name]
+	if !ok || profile == nil {
+		return nil, errors.New("Profile " + strconv.Quote(name) + " not found in config file")
+	}
+
+	result := *profile
+	if err := result.validate(); err != nil {
+		return nil, errors.New("Profile " + strconv.Quote(name) + " is invalid: " + err.Error())
+	}
+	return &result, nil
+}
+
+// ProfileFromApiKey returns a profile for an API key, as given to the language bridges.
+//
+// Keys for a port other than 443 are assumed to be development instances, and skip SSL verification.
+// Keys suffixed with ",InsecureUsePlaintext" use plaintext HTTP.
+func ProfileFromApiKey(apiKey string) (*Profile, error) {
+	profile := &Profile{}
+
+	if strings.HasSuffix(apiKey, plaintextKeySuffix) {
+		profile.InsecureUsePlaintext = true
+		apiKey = strings.TrimSuffix(apiKey, plaintextKeySuffix)
+	}
+
+	_, port, _, err := ParseApiKey(apiKey)
+	if err != nil {
+		return nil, err
+	}
+
+	profile.ApiKey = apiKey
+	profile.InsecureSkipVerify = port != 443
+	return profile, nil
+}
+
+// applyEnvironment overrides the profile with any environment variables that are set.
+func (p *Profile) applyEnvironment() error {
+	if apiKey, ok := os.LookupEnv(SdkApiKeyKey); ok {
+		p.ApiKey = apiKey
+		p.Host, p.Port, p.Key = "", 0, ""
+	}
+
+	for key, field := range map[string]*bool{
+		SdkInsecureSkipVerifyKey:   &p.InsecureSkipVerify,
+		SdkInsecureUsePlaintextKey: &p.InsecureUsePlaintext,
+	} {
+		if value, ok := os.LookupEnv(key); ok {
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				return errors.New("Invalid value for " + key + ": " + strconv.Quote(value))
+			}
+			*field = parsed
+		}
+	}
+
+	return nil
+}
+
+// validate checks the format of the profile's key fields.
+// The fields may be left unset, such as when the API key is given by the environment.
+func (p *Profile) validate() error {
+	if p.ApiKey != "" {
+		if _, _, _, err := ParseApiKey(p.ApiKey); err != nil {
+			return errors.New("Invalid api_key: " + err.Error())
+		}
+	}
+	if strings.Contains(p.Host, ":") {
+		return errors.New("Invalid host " + strconv.Quote(p.Host) + ": must not contain a colon")
+	}
+	if p.Port < 0 || p.Port > 65535 {
+		return errors.New("Invalid port " + strconv.Itoa(p.Port))
+	}
+	if strings.Contains(p.Key, ":") {
+		return errors.New("Invalid key: must not contain a colon")
+	}
+	return nil
+}
+
+// apiKey combines the profile's key fields into an API key, and validates it.
+func (p *Profile) apiKey() (string, error) {
+	host, port, key := "", 443, ""
+
+	if err := p.validate(); err != nil {
+		return "", err
+	}
+	if p.ApiKey != "" {
+		host, port, key, _ = ParseApiKey(p.ApiKey)
+	}
+
+	if p.Host != "" {
+		host = p.Host
+	}
+	if p.Port != 0 {
+		port = p.Port
+	}
+	if p.Key != "" {
+		key = p.Key
+	}
+
+	if host == "" || key == "" {
+		return "", errors.New("Profile must set an API key, or both a host and a key")
+	}
+
+	apiKey := host + ":" + strconv.Itoa(port) + ":" + key
+	if _, _, _, err := ParseApiKey(apiKey); err != nil {
+		return "", err
+	}
+	return apiKey, nil
+}
+
+// Options returns the client options that the profile specifies, not including its API key.
+func (p *Profile) Options() ([]ApiKeyClientOption, error) {
+	options := []ApiKeyClientOption{}
+
+	if p.InsecureSkipVerify {
+		options = append(options, InsecureNoSSLVerification)
+	}
+	if p.InsecureUsePlaintext {
+		options = append(options, InsecureUsePlaintext)
+	}
+
+	if p.CAFile != "" {
+		pem, err := ioutil.ReadFile(p.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("No certificates found in CA file " + p.CAFile)
+		}
+		options = append(options, RootCAs(pool))
+	}
+
+	if p.Proxy != "" {
+		proxyURL, err := url.Parse(p.Proxy)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, ProxyURL(proxyURL))
+	}
+
+	return options, nil
+}
+
+// NewClient creates a Client from the profile. Options given here are applied after the profile's own.
+// Unlike NewApiKeyClient, an invalid profile returns an error instead of panicking.
+func (p *Profile) NewClient(options ...ApiKeyClientOption) (*Client, error) {
+	apiKey, err := p.apiKey()
+	if err != nil {
+		return nil, err
+	}
+
+	profileOptions, err := p.Options()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewApiKeyClient(apiKey, append(profileOptions, options...)...), nil
+}
+
+// NewClientFromProfile creates a Client from the named profile in the user's config file, then applies environment overrides.
+// If name is empty, the profile named by SdkProfileKey is used, falling back to the file's default profile.
+//
+// The config file is read from the path in SdkConfigKey, or DefaultConfigPath if not set.
+// See ConfigFile for its format.
+func NewClientFromProfile(name string, options ...ApiKeyClientOption) (*Client, error) {
+	path, set := os.LookupEnv(SdkConfigKey)
+	if !set {
+		var err error
+		path, err = DefaultConfigPath()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	config, err := LoadConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if name == "" {
+		name = os.Getenv(SdkProfileKey)
+	}
+
+	profile, err := config.Profile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	err = profile.applyEnvironment()
+	if err != nil {
+		return nil, err
+	}
+
+	return profile.NewClient(options...)
+}
+
+// NewClientFromEnvironment creates a Client from the environment.
+//
+// If SdkApiKeyKey is set and no profile is selected by SdkProfileKey, the client is configured from environment variables alone.
+// Otherwise, it behaves as NewClientFromProfile with the profile named by SdkProfileKey, or the default profile.
+func NewClientFromEnvironment(options ...ApiKeyClientOption) (*Client, error) {
+	_, keySet := os.LookupEnv(SdkApiKeyKey)
+	_, profileSet := os.LookupEnv(SdkProfileKey)
+
+	if keySet && !profileSet {
+		profile := &Profile{}
+		err := profile.applyEnvironment()
+		if err != nil {
+			return nil, err
+		}
+		return profile.NewClient(options...)
+	}
+
+	return NewClientFromProfile("", options...)
+}
@@ -86,6 +86,11 @@ func (r *ProgressReader) Update() {
 	r.update(atomic.LoadInt64(&r.count))
 }
 
+// setCount changes the bytes reported as read, such as to count work done by an earlier attempt.
+func (r *ProgressReader) setCount(n int64) {
+	atomic.StoreInt64(&r.count, n)
+}
+
 // Read implements io.Reader.
 func (r *ProgressReader) SetReader(newReader io.Reader) {
 	r.Reader = newReader
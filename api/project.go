@@ -1,9 +1,7 @@
 package api
 
 import (
-	"errors"
 	"net/http"
-	"strconv"
 	"time"
 )
 
@@ -62,122 +60,112 @@ func (c *Client) AddProject(project *Project) (string, *http.Response, error) {
 }
 
 func (c *Client) AddProjectNote(id, text string) (*http.Response, error) {
-	var aerr *Error
-	var response *ModifiedResponse
-
-	note := &Note{
-		Text: text,
-	}
+	return c.AddContainerNote(&ContainerRef{Type: "project", Id: id}, text)
+}
 
-	resp, err := c.New().Post("projects/"+id+"/notes").BodyJSON(note).Receive(&response, &aerr)
+func (c *Client) GetProjectNote(id, noteId string) (*Note, *http.Response, error) {
+	return c.GetContainerNote(&ContainerRef{Type: "project", Id: id}, noteId)
+}
 
-	// Should not have to check this count
-	// https://github.com/scitran/core/issues/680
-	if err == nil && aerr == nil && response.ModifiedCount != 1 {
-		return resp, errors.New("Modifying project " + id + " returned " + strconv.Itoa(response.ModifiedCount) + " instead of 1")
-	}
+func (c *Client) ModifyProjectNote(id, noteId, text string) (*http.Response, error) {
+	return c.ModifyContainerNote(&ContainerRef{Type: "project", Id: id}, noteId, text)
+}
 
-	return resp, Coalesce(err, aerr)
+func (c *Client) DeleteProjectNote(id, noteId string) (*http.Response, error) {
+	return c.DeleteContainerNote(&ContainerRef{Type: "project", Id: id}, noteId)
 }
 
 func (c *Client) AddProjectTag(id, tag string) (*http.Response, error) {
-	var aerr *Error
-	var response *ModifiedResponse
-
-	var tagDoc interface{}
-	tagDoc = map[string]interface{}{
-		"value": tag,
-	}
-
-	resp, err := c.New().Post("projects/"+id+"/tags").BodyJSON(tagDoc).Receive(&response, &aerr)
+	return c.AddContainerTag(&ContainerRef{Type: "project", Id: id}, tag)
+}
 
-	// Should not have to check this count
-	// https://github.com/scitran/core/issues/680
-	if err == nil && aerr == nil && response.ModifiedCount != 1 {
-		return resp, errors.New("Modifying project " + id + " returned " + strconv.Itoa(response.ModifiedCount) + " instead of 1")
-	}
+func (c *Client) RenameProjectTag(id, tag, newTag string) (*http.Response, error) {
+	return c.RenameContainerTag(&ContainerRef{Type: "project", Id: id}, tag, newTag)
+}
 
-	return resp, Coalesce(err, aerr)
+func (c *Client) DeleteProjectTag(id, tag string) (*http.Response, error) {
+	return c.DeleteContainerTag(&ContainerRef{Type: "project", Id: id}, tag)
 }
 
 func (c *Client) ModifyProject(id string, project *Project) (*http.Response, error) {
-	var aerr *Error
-	var response *ModifiedResponse
-
-	resp, err := c.New().Put("projects/"+id).BodyJSON(project).Receive(&response, &aerr)
-
-	// Should not have to check this count
-	// https://github.com/scitran/core/issues/680
-	if err == nil && aerr == nil && response.ModifiedCount != 1 {
-		return resp, errors.New("Modifying project " + id + " returned " + strconv.Itoa(response.ModifiedCount) + " instead of 1")
-	}
-
-	return resp, Coalesce(err, aerr)
+	return c.ModifyContainer(&ContainerRef{Type: "project", Id: id}, project)
 }
 
 func (c *Client) DeleteProject(id string) (*http.Response, error) {
-	var aerr *Error
-	var response *DeletedResponse
+	return c.DeleteContainer(&ContainerRef{Type: "project", Id: id})
+}
 
-	resp, err := c.New().Delete("projects/"+id).Receive(&response, &aerr)
+func (c *Client) SetProjectInfo(id string, set map[string]interface{}) (*http.Response, error) {
+	return c.SetContainerInfo(&ContainerRef{Type: "project", Id: id}, set)
+}
 
-	// Should not have to check this count
-	// https://github.com/scitran/core/issues/680
-	if err == nil && aerr == nil && response.DeletedCount != 1 {
-		return resp, errors.New("Deleting project " + id + " returned " + strconv.Itoa(response.DeletedCount) + " instead of 1")
-	}
+func (c *Client) ReplaceProjectInfo(id string, replace map[string]interface{}) (*http.Response, error) {
+	return c.ReplaceContainerInfo(&ContainerRef{Type: "project", Id: id}, replace)
+}
 
-	return resp, Coalesce(err, aerr)
+func (c *Client) DeleteProjectInfoFields(id string, keys []string) (*http.Response, error) {
+	return c.DeleteContainerInfoFields(&ContainerRef{Type: "project", Id: id}, keys)
 }
 
 func (c *Client) UploadToProject(id string, files ...*UploadSource) (chan int64, chan error) {
-	url := "projects/" + id + "/files"
-	return c.UploadSimple(url, nil, files...)
+	return c.UploadToContainer(&ContainerRef{Type: "project", Id: id}, files...)
 }
 
 func (c *Client) ModifyProjectFile(id string, filename string, attributes *FileFields) (*http.Response, *ModifiedAndJobsResponse, error) {
-	url := "projects/" + id + "/files/" + filename
-	return c.modifyFileAttrs(url, attributes)
+	return c.ModifyContainerFile(&ContainerRef{Type: "project", Id: id}, filename, attributes)
 }
 
 func (c *Client) SetProjectFileInfo(id string, filename string, set map[string]interface{}) (*http.Response, error) {
-	url := "projects/" + id + "/files/" + filename + "/info"
-	return c.setInfo(url, set)
+	return c.SetContainerFileInfo(&ContainerRef{Type: "project", Id: id}, filename, set)
 }
 
 func (c *Client) ReplaceProjectFileInfo(id string, filename string, replace map[string]interface{}) (*http.Response, error) {
-	url := "projects/" + id + "/files/" + filename + "/info"
-	return c.replaceInfo(url, replace)
+	return c.ReplaceContainerFileInfo(&ContainerRef{Type: "project", Id: id}, filename, replace)
 }
 
 func (c *Client) DeleteProjectFileInfoFields(id string, filename string, keys []string) (*http.Response, error) {
-	url := "projects/" + id + "/files/" + filename + "/info"
-	return c.deleteInfoFields(url, keys)
+	return c.DeleteContainerFileInfoFields(&ContainerRef{Type: "project", Id: id}, filename, keys)
+}
+
+func (c *Client) RenameProjectFile(id, filename, newName string) (*http.Response, error) {
+	return c.RenameContainerFile(&ContainerRef{Type: "project", Id: id}, filename, newName)
+}
+
+func (c *Client) ReplaceProjectFile(id, filename string, source *UploadSource) (chan int64, chan error) {
+	return c.ReplaceContainerFile(&ContainerRef{Type: "project", Id: id}, filename, source)
+}
+
+func (c *Client) DeleteProjectFile(id, filename string) (*http.Response, error) {
+	return c.DeleteContainerFile(&ContainerRef{Type: "project", Id: id}, filename)
+}
+
+func (c *Client) AddProjectFileTag(id, filename, tag string) (*http.Response, error) {
+	return c.AddContainerFileTag(&ContainerRef{Type: "project", Id: id}, filename, tag)
+}
+
+func (c *Client) RenameProjectFileTag(id, filename, tag, newTag string) (*http.Response, error) {
+	return c.RenameContainerFileTag(&ContainerRef{Type: "project", Id: id}, filename, tag, newTag)
+}
+
+func (c *Client) DeleteProjectFileTag(id, filename, tag string) (*http.Response, error) {
+	return c.DeleteContainerFileTag(&ContainerRef{Type: "project", Id: id}, filename, tag)
 }
 
 func (c *Client) DownloadFromProject(id string, filename string, destination *DownloadSource) (chan int64, chan error) {
-	url := "projects/" + id + "/files/" + filename
-	return c.DownloadSimple(url, destination)
+	return c.DownloadFromContainer(&ContainerRef{Type: "project", Id: id}, filename, destination)
 }
 
 // No progress reporting
 func (c *Client) UploadFileToProject(id string, path string) error {
-	src := CreateUploadSourceFromFilenames(path)
-	progress, result := c.UploadToProject(id, src...)
+	return c.UploadFileToContainer(&ContainerRef{Type: "project", Id: id}, path)
+}
 
-	// drain and report
-	for range progress {
-	}
-	return <-result
+// No progress reporting
+func (c *Client) ReplaceFileInProject(id, filename string, path string) error {
+	return c.ReplaceFileInContainer(&ContainerRef{Type: "project", Id: id}, filename, path)
 }
 
 // No progress reporting
 func (c *Client) DownloadFileFromProject(id, name string, path string) error {
-	src := CreateDownloadSourceFromFilename(path)
-	progress, result := c.DownloadFromProject(id, name, src)
-
-	// drain and report
-	for range progress {
-	}
-	return <-result
+	return c.DownloadFileFromContainer(&ContainerRef{Type: "project", Id: id}, name, path)
 }
@@ -0,0 +1,285 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ResolvedPath is a container or file found by Resolve, along with its ancestors.
+// Levels below the one resolved are nil.
+type ResolvedPath struct {
+	Path []string
+
+	Group       *Group
+	Project     *Project
+	Session     *Session
+	Acquisition *Acquisition
+	File        *File
+}
+
+// Type returns the level the path resolved to: "group", "project", "session", "acquisition" or "file".
+func (r *ResolvedPath) Type() string {
+	switch {
+	case r.File != nil:
+		return "file"
+	case r.Acquisition != nil:
+		return "acquisition"
+	case r.Session != nil:
+		return "session"
+	case r.Project != nil:
+		return "project"
+	case r.Group != nil:
+		return "group"
+	}
+	return ""
+}
+
+// Leaf returns the container or file the path resolved to.
+// Its type is one of *Group, *Project, *Session, *Acquisition or *File.
+func (r *ResolvedPath) Leaf() interface{} {
+	switch r.Type() {
+	case "file":
+		return r.File
+	case "acquisition":
+		return r.Acquisition
+	case "session":
+		return r.Session
+	case "project":
+		return r.Project
+	case "group":
+		return r.Group
+	}
+	return nil
+}
+
+// AmbiguousPathError is returned by Resolve when a path segment names more than one container or file, or both a container and a file.
+// Using one of the Ids as the segment instead will resolve the ambiguity; files that share a name cannot be told apart by path.
+type AmbiguousPathError struct {
+	Path    string
+	Segment string
+
+	// Ids of the containers that matched, and the name of each file that did.
+	Ids []string
+}
+
+// Error implements the error interface.
+func (e *AmbiguousPathError) Error() string {
+	return "Path " + e.Path + " is ambiguous: " + strconv.Itoa(len(e.Ids)) + " matches for " + strconv.Quote(e.Segment) +
+		" (" + strings.Join(e.Ids, ", ") + ")"
+}
+
+// Resolve finds a container or file by its path, such as "group/project/session/acquisition/file.txt".
+//
+// Each segment may be an Id or a label; Ids take precedence. Files may be named after any project, session or acquisition.
+// A path that does not exist returns a not-found *Error, and a label or file name shared by several children returns an *AmbiguousPathError.
+//
+// Labels that contain slashes can be resolved with ResolveSegments.
+func (c *Client) Resolve(path string) (*ResolvedPath, *http.Response, error) {
+	return c.ResolveSegments(strings.Split(strings.Trim(path, "/"), "/"))
+}
+
+// ResolveSegments is Resolve for a path that has already been split into segments.
+func (c *Client) ResolveSegments(segments []string) (*ResolvedPath, *http.Response, error) {
+	path := strings.Join(segments, "/")
+	if path == "" {
+		return nil, nil, errors.New("Cannot resolve an empty path")
+	}
+	for _, segment := range segments {
+		if segment == "" {
+			return nil, nil, errors.New("Path " + strconv.Quote(path) + " has an empty segment")
+		}
+	}
+
+	result := &ResolvedPath{Path: segments}
+
+	resp, err := c.resolveGroup(result, path, segments[0])
+	if err != nil {
+		return nil, resp, err
+	}
+
+	for _, segment := range segments[1:] {
+		resp, err = c.resolveChild(result, path, segment)
+		if err != nil {
+			return nil, resp, err
+		}
+	}
+
+	return result, resp, nil
+}
+
+// resolveGroup finds the group a path starts with.
+func (c *Client) resolveGroup(result *ResolvedPath, path, segment string) (*http.Response, error) {
+	group, resp, err := c.GetGroup(segment)
+	if err == nil {
+		result.Group = group
+		return resp, nil
+	}
+	if !IsNotFound(err) {
+		return resp, err
+	}
+
+	// Not an Id; look for a group with that label
+	groups, resp, err := c.GetAllGroups()
+	if err != nil {
+		return resp, err
+	}
+
+	ids := make([]string, len(groups))
+	labels := make([]string, len(groups))
+	for i, x := range groups {
+		ids[i], labels[i] = x.Id, x.Name
+	}
+
+	i, err := matchSegment(path, segment, ids, labels)
+	if err != nil {
+		return resp, err
+	}
+	if i < 0 {
+		return resp, notFoundInPath(path, "No group named "+strconv.Quote(segment))
+	}
+
+	result.Group = groups[i]
+	return resp, nil
+}
+
+// resolveChild descends one level from the deepest container resolved so far.
+func (c *Client) resolveChild(result *ResolvedPath, path, segment string) (*http.Response, error) {
+	var ids, labels []string
+	var files []*File
+	var resp *http.Response
+	var err error
+
+	// List the children of the current level
+	switch result.Type() {
+	case "group":
+		var projects []*Project
+		projects, resp, err = c.GetGroupProjects(result.Group.Id)
+		for _, x := range projects {
+			ids, labels = append(ids, x.Id), append(labels, x.Name)
+		}
+
+	case "project":
+		var sessions []*Session
+		sessions, resp, err = c.GetProjectSessions(result.Project.Id)
+		for _, x := range sessions {
+			ids, labels = append(ids, x.Id), append(labels, x.Name)
+		}
+		files = result.Project.Files
+
+	case "session":
+		var acquisitions []*Acquisition
+		acquisitions, resp, err = c.GetSessionAcquisitions(result.Session.Id)
+		for _, x := range acquisitions {
+			ids, labels = append(ids, x.Id), append(labels, x.Name)
+		}
+		files = result.Session.Files
+
+	case "acquisition":
+		files = result.Acquisition.Files
+
+	case "file":
+		return nil, notFoundInPath(path, "File "+result.File.Name+" has no children")
+	}
+	if err != nil {
+		return resp, err
+	}
+
+	i, err := matchSegment(path, segment, ids, labels)
+	if err != nil {
+		return resp, err
+	}
+
+	var file *File
+	var matches []string
+	for _, x := range files {
+		if x.Name == segment {
+			if file == nil {
+				file = x
+			}
+			matches = append(matches, "file "+x.Name)
+		}
+	}
+	if i >= 0 && ids[i] != segment {
+		matches = append([]string{ids[i]}, matches...)
+	}
+
+	switch {
+	case i >= 0 && ids[i] == segment:
+		return c.fetchChild(result, ids[i])
+
+	case len(matches) > 1:
+		return resp, &AmbiguousPathError{Path: path, Segment: segment, Ids: matches}
+
+	case i >= 0:
+		return c.fetchChild(result, ids[i])
+
+	case file != nil:
+		result.File = file
+		return resp, nil
+	}
+
+	return resp, notFoundInPath(path, "Nothing named "+strconv.Quote(segment)+" in "+result.Type()+" "+leafId(result))
+}
+
+// fetchChild retrieves a child of the deepest container resolved so far, in full.
+// Listings omit fields such as files, so the child is fetched rather than taken from its parent's listing.
+func (c *Client) fetchChild(result *ResolvedPath, id string) (*http.Response, error) {
+	var resp *http.Response
+	var err error
+
+	switch result.Type() {
+	case "group":
+		result.Project, resp, err = c.GetProject(id)
+	case "project":
+		result.Session, resp, err = c.GetSession(id)
+	case "session":
+		result.Acquisition, resp, err = c.GetAcquisition(id)
+	}
+	return resp, err
+}
+
+// leafId returns the Id of the deepest container resolved so far.
+func leafId(result *ResolvedPath) string {
+	switch result.Type() {
+	case "acquisition":
+		return result.Acquisition.Id
+	case "session":
+		return result.Session.Id
+	case "project":
+		return result.Project.Id
+	case "group":
+		return result.Group.Id
+	}
+	return ""
+}
+
+// matchSegment returns the index of the candidate a segment names, or -1 if there is none.
+// An Id match wins over label matches; several label matches are ambiguous.
+func matchSegment(path, segment string, ids, labels []string) (int, error) {
+	for i, id := range ids {
+		if id == segment {
+			return i, nil
+		}
+	}
+
+	match := -1
+	var matches []string
+	for i, label := range labels {
+		if label == segment {
+			match = i
+			matches = append(matches, ids[i])
+		}
+	}
+
+	if len(matches) > 1 {
+		return -1, &AmbiguousPathError{Path: path, Segment: segment, Ids: matches}
+	}
+	return match, nil
+}
+
+// notFoundInPath returns a not-found error for a path, so that it can be checked with IsNotFound.
+func notFoundInPath(path, message string) error {
+	return &Error{StatusCode: http.StatusNotFound, Message: "Could not resolve " + path + ": " + message}
+}
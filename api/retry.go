@@ -0,0 +1,262 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy controls how failed requests are retried. See RetryRequests.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts made for a request, including the first.
+	MaxAttempts int
+
+	// BaseDelay is the backoff before the first retry; it doubles on each further attempt.
+	// The actual delay is chosen at random between zero and the backoff ("full jitter").
+	BaseDelay time.Duration
+
+	// MaxDelay caps the backoff, including any delay requested by a Retry-After header.
+	MaxDelay time.Duration
+
+	// Methods lists the HTTP methods that may be retried.
+	// Defaults to the idempotent methods GET, HEAD, OPTIONS, PUT and DELETE if nil.
+	Methods []string
+
+	// StatusCodes lists the response codes that trigger a retry.
+	// Defaults to 429, 502, 503 and 504 if nil.
+	StatusCodes []int
+
+	// RetryUploads enables retrying Upload and its wrappers.
+	// Only uploads whose UploadSources all set Path are retried, as those can be reopened from disk.
+	// Progress restarts from zero on each attempt, and the final total is always sent, after the result;
+	// so that it is not dropped, that send blocks until the progress channel is read.
+	RetryUploads bool
+}
+
+// DefaultRetryPolicy retries idempotent requests up to three times, backing off from half a second.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 4,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    30 * time.Second,
+}
+
+var defaultRetryMethods = []string{"GET", "HEAD", "OPTIONS", "PUT", "DELETE"}
+var defaultRetryStatusCodes = []int{429, 502, 503, 504}
+
+// retriesMethod reports whether requests with this method may be retried.
+func (p *RetryPolicy) retriesMethod(method string) bool {
+	methods := p.Methods
+	if methods == nil {
+		methods = defaultRetryMethods
+	}
+	for _, x := range methods {
+		if x == method {
+			return true
+		}
+	}
+	return false
+}
+
+// retriesStatus reports whether a response with this status code should be retried.
+func (p *RetryPolicy) retriesStatus(statusCode int) bool {
+	codes := p.StatusCodes
+	if codes == nil {
+		codes = defaultRetryStatusCodes
+	}
+	for _, x := range codes {
+		if x == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+// retriesError reports whether an error from a request or an upload should be retried.
+func (p *RetryPolicy) retriesError(err error) bool {
+	var aerr *Error
+	if errors.As(err, &aerr) {
+		return p.retriesStatus(aerr.StatusCode)
+	}
+
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// delay returns how long to wait before the next attempt, given the number of attempts made so far.
+// A Retry-After header on resp takes precedence over the computed backoff.
+func (p *RetryPolicy) delay(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if after, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			if p.MaxDelay > 0 && after > p.MaxDelay {
+				return p.MaxDelay
+			}
+			return after
+		}
+	}
+
+	backoff := p.BaseDelay << uint(attempt-1)
+	if backoff <= 0 || (p.MaxDelay > 0 && backoff > p.MaxDelay) {
+		backoff = p.MaxDelay
+	}
+	if backoff <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(backoff) + 1))
+}
+
+// parseRetryAfter parses a Retry-After header, which is either a number of seconds or an HTTP date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		after := time.Until(date)
+		if after < 0 {
+			after = 0
+		}
+		return after, true
+	}
+	return 0, false
+}
+
+// RetryTransport retries failed requests according to a RetryPolicy.
+//
+// Only requests whose method is allowed by the policy, and whose body can be replayed, are retried.
+// Waiting between attempts is aborted if the request's context is done.
+type RetryTransport struct {
+	Policy RetryPolicy
+
+	// Transport is the underlying HTTP transport to use when making requests.
+	// It will default to http.DefaultTransport if nil.
+	Transport http.RoundTripper
+}
+
+// RoundTrip implements the RoundTripper interface.
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	transport := t.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+	if !t.Policy.retriesMethod(req.Method) || !replayable {
+		return transport.RoundTrip(req)
+	}
+
+	ctx := req.Context()
+	attemptReq := req
+
+	for attempt := 1; ; attempt++ {
+		resp, err := transport.RoundTrip(attemptReq)
+
+		retry := (err != nil && t.Policy.retriesError(err)) ||
+			(err == nil && t.Policy.retriesStatus(resp.StatusCode))
+
+		if !retry || attempt >= t.Policy.MaxAttempts || ctx.Err() != nil {
+			return resp, err
+		}
+
+		delay := t.Policy.delay(attempt, resp)
+
+		// Discard the failed response so the connection can be reused
+		if resp != nil {
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+
+		// Requests must not be modified by a RoundTripper, so each retry sends a copy with a fresh body
+		attemptReq = req.Clone(ctx)
+		if req.GetBody != nil {
+			attemptReq.Body, err = req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// uploadWithRetry repeats an upload according to the client's retry policy, reopening each source from its Path.
+// Progress from each attempt is forwarded to progress, which is closed once the final attempt completes.
+func (c *Client) uploadWithRetry(url string, metadata []byte, progress chan<- int64, files []*UploadSource) chan error {
+	policy := c.retry
+	ctx := c.Context()
+	resultChan := make(chan error, 1)
+
+	go func() {
+		for attempt := 1; ; attempt++ {
+			// Each attempt works on copies, as uploading opens and closes the sources; the caller's are left alone.
+			// The first attempt reads any readers the caller gave; later attempts reopen every source from disk.
+			sources := make([]*UploadSource, len(files))
+			for i, file := range files {
+				source := *file
+				if attempt > 1 {
+					source.Reader = nil
+				}
+				sources[i] = &source
+			}
+
+			attemptProgress := make(chan int64, 10)
+			result := c.uploadOnce(url, metadata, attemptProgress, sources)
+
+			last := int64(-1)
+			for x := range attemptProgress {
+				last = x
+				if progress != nil {
+					select {
+					case progress <- x:
+					default:
+					}
+				}
+			}
+			err := <-result
+
+			if err == nil || attempt >= policy.MaxAttempts || !policy.retriesError(err) || ctx.Err() != nil {
+				resultChan <- err
+				if progress != nil {
+					if last >= 0 {
+						progress <- last
+					}
+					close(progress)
+				}
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+			case <-time.After(policy.delay(attempt, nil)):
+			}
+		}
+	}()
+
+	return resultChan
+}
+
+// canRetryUpload reports whether every source of an upload can be reopened from disk.
+func canRetryUpload(files []*UploadSource) bool {
+	for _, file := range files {
+		if file.Path == "" {
+			return false
+		}
+	}
+	return len(files) > 0
+}
@@ -62,7 +62,7 @@ type AnalysisSearchResponse struct {
 	Id      string `json:"_id,omitempty"`
 	Name    string `json:"label,omitempty"`
 	User    string `json:"user,omitempty"`
-	Created string `json:created,omitempty"`
+	Created string `json:"created,omitempty"`
 }
 type ParentSearchResponse struct {
 	Type string `json:"type,omitempty"`
@@ -1,9 +1,7 @@
 package api
 
 import (
-	"errors"
 	"net/http"
-	"strconv"
 	"time"
 )
 
@@ -78,122 +76,112 @@ func (c *Client) AddSession(session *Session) (string, *http.Response, error) {
 }
 
 func (c *Client) AddSessionNote(id, text string) (*http.Response, error) {
-	var aerr *Error
-	var response *ModifiedResponse
-
-	note := &Note{
-		Text: text,
-	}
+	return c.AddContainerNote(&ContainerRef{Type: "session", Id: id}, text)
+}
 
-	resp, err := c.New().Post("sessions/"+id+"/notes").BodyJSON(note).Receive(&response, &aerr)
+func (c *Client) GetSessionNote(id, noteId string) (*Note, *http.Response, error) {
+	return c.GetContainerNote(&ContainerRef{Type: "session", Id: id}, noteId)
+}
 
-	// Should not have to check this count
-	// https://github.com/scitran/core/issues/680
-	if err == nil && aerr == nil && response.ModifiedCount != 1 {
-		return resp, errors.New("Modifying session " + id + " returned " + strconv.Itoa(response.ModifiedCount) + " instead of 1")
-	}
+func (c *Client) ModifySessionNote(id, noteId, text string) (*http.Response, error) {
+	return c.ModifyContainerNote(&ContainerRef{Type: "session", Id: id}, noteId, text)
+}
 
-	return resp, Coalesce(err, aerr)
+func (c *Client) DeleteSessionNote(id, noteId string) (*http.Response, error) {
+	return c.DeleteContainerNote(&ContainerRef{Type: "session", Id: id}, noteId)
 }
 
 func (c *Client) AddSessionTag(id, tag string) (*http.Response, error) {
-	var aerr *Error
-	var response *ModifiedResponse
-
-	var tagDoc interface{}
-	tagDoc = map[string]interface{}{
-		"value": tag,
-	}
-
-	resp, err := c.New().Post("sessions/"+id+"/tags").BodyJSON(tagDoc).Receive(&response, &aerr)
+	return c.AddContainerTag(&ContainerRef{Type: "session", Id: id}, tag)
+}
 
-	// Should not have to check this count
-	// https://github.com/scitran/core/issues/680
-	if err == nil && aerr == nil && response.ModifiedCount != 1 {
-		return resp, errors.New("Modifying session " + id + " returned " + strconv.Itoa(response.ModifiedCount) + " instead of 1")
-	}
+func (c *Client) RenameSessionTag(id, tag, newTag string) (*http.Response, error) {
+	return c.RenameContainerTag(&ContainerRef{Type: "session", Id: id}, tag, newTag)
+}
 
-	return resp, Coalesce(err, aerr)
+func (c *Client) DeleteSessionTag(id, tag string) (*http.Response, error) {
+	return c.DeleteContainerTag(&ContainerRef{Type: "session", Id: id}, tag)
 }
 
 func (c *Client) ModifySession(id string, session *Session) (*http.Response, error) {
-	var aerr *Error
-	var response *ModifiedResponse
-
-	resp, err := c.New().Put("sessions/"+id).BodyJSON(session).Receive(&response, &aerr)
-
-	// Should not have to check this count
-	// https://github.com/scitran/core/issues/680
-	if err == nil && aerr == nil && response.ModifiedCount != 1 {
-		return resp, errors.New("Modifying session " + id + " returned " + strconv.Itoa(response.ModifiedCount) + " instead of 1")
-	}
-
-	return resp, Coalesce(err, aerr)
+	return c.ModifyContainer(&ContainerRef{Type: "session", Id: id}, session)
 }
 
 func (c *Client) DeleteSession(id string) (*http.Response, error) {
-	var aerr *Error
-	var response *DeletedResponse
+	return c.DeleteContainer(&ContainerRef{Type: "session", Id: id})
+}
 
-	resp, err := c.New().Delete("sessions/"+id).Receive(&response, &aerr)
+func (c *Client) SetSessionInfo(id string, set map[string]interface{}) (*http.Response, error) {
+	return c.SetContainerInfo(&ContainerRef{Type: "session", Id: id}, set)
+}
 
-	// Should not have to check this count
-	// https://github.com/scitran/core/issues/680
-	if err == nil && aerr == nil && response.DeletedCount != 1 {
-		return resp, errors.New("Deleting session " + id + " returned " + strconv.Itoa(response.DeletedCount) + " instead of 1")
-	}
+func (c *Client) ReplaceSessionInfo(id string, replace map[string]interface{}) (*http.Response, error) {
+	return c.ReplaceContainerInfo(&ContainerRef{Type: "session", Id: id}, replace)
+}
 
-	return resp, Coalesce(err, aerr)
+func (c *Client) DeleteSessionInfoFields(id string, keys []string) (*http.Response, error) {
+	return c.DeleteContainerInfoFields(&ContainerRef{Type: "session", Id: id}, keys)
 }
 
 func (c *Client) UploadToSession(id string, files ...*UploadSource) (chan int64, chan error) {
-	url := "sessions/" + id + "/files"
-	return c.UploadSimple(url, nil, files...)
+	return c.UploadToContainer(&ContainerRef{Type: "session", Id: id}, files...)
 }
 
 func (c *Client) ModifySessionFile(id string, filename string, attributes *FileFields) (*http.Response, *ModifiedAndJobsResponse, error) {
-	url := "sessions/" + id + "/files/" + filename
-	return c.modifyFileAttrs(url, attributes)
+	return c.ModifyContainerFile(&ContainerRef{Type: "session", Id: id}, filename, attributes)
 }
 
 func (c *Client) SetSessionFileInfo(id string, filename string, set map[string]interface{}) (*http.Response, error) {
-	url := "sessions/" + id + "/files/" + filename + "/info"
-	return c.setInfo(url, set)
+	return c.SetContainerFileInfo(&ContainerRef{Type: "session", Id: id}, filename, set)
 }
 
 func (c *Client) ReplaceSessionFileInfo(id string, filename string, replace map[string]interface{}) (*http.Response, error) {
-	url := "sessions/" + id + "/files/" + filename + "/info"
-	return c.replaceInfo(url, replace)
+	return c.ReplaceContainerFileInfo(&ContainerRef{Type: "session", Id: id}, filename, replace)
 }
 
 func (c *Client) DeleteSessionFileInfoFields(id string, filename string, keys []string) (*http.Response, error) {
-	url := "sessions/" + id + "/files/" + filename + "/info"
-	return c.deleteInfoFields(url, keys)
+	return c.DeleteContainerFileInfoFields(&ContainerRef{Type: "session", Id: id}, filename, keys)
+}
+
+func (c *Client) RenameSessionFile(id, filename, newName string) (*http.Response, error) {
+	return c.RenameContainerFile(&ContainerRef{Type: "session", Id: id}, filename, newName)
+}
+
+func (c *Client) ReplaceSessionFile(id, filename string, source *UploadSource) (chan int64, chan error) {
+	return c.ReplaceContainerFile(&ContainerRef{Type: "session", Id: id}, filename, source)
+}
+
+func (c *Client) DeleteSessionFile(id, filename string) (*http.Response, error) {
+	return c.DeleteContainerFile(&ContainerRef{Type: "session", Id: id}, filename)
+}
+
+func (c *Client) AddSessionFileTag(id, filename, tag string) (*http.Response, error) {
+	return c.AddContainerFileTag(&ContainerRef{Type: "session", Id: id}, filename, tag)
+}
+
+func (c *Client) RenameSessionFileTag(id, filename, tag, newTag string) (*http.Response, error) {
+	return c.RenameContainerFileTag(&ContainerRef{Type: "session", Id: id}, filename, tag, newTag)
+}
+
+func (c *Client) DeleteSessionFileTag(id, filename, tag string) (*http.Response, error) {
+	return c.DeleteContainerFileTag(&ContainerRef{Type: "session", Id: id}, filename, tag)
 }
 
 func (c *Client) DownloadFromSession(id string, filename string, destination *DownloadSource) (chan int64, chan error) {
-	url := "sessions/" + id + "/files/" + filename
-	return c.DownloadSimple(url, destination)
+	return c.DownloadFromContainer(&ContainerRef{Type: "session", Id: id}, filename, destination)
 }
 
 // No progress reporting
 func (c *Client) UploadFileToSession(id string, path string) error {
-	src := CreateUploadSourceFromFilenames(path)
-	progress, result := c.UploadToSession(id, src...)
+	return c.UploadFileToContainer(&ContainerRef{Type: "session", Id: id}, path)
+}
 
-	// drain and report
-	for range progress {
-	}
-	return <-result
+// No progress reporting
+func (c *Client) ReplaceFileInSession(id, filename string, path string) error {
+	return c.ReplaceFileInContainer(&ContainerRef{Type: "session", Id: id}, filename, path)
 }
 
 // No progress reporting
 func (c *Client) DownloadFileFromSession(id, name string, path string) error {
-	src := CreateDownloadSourceFromFilename(path)
-	progress, result := c.DownloadFromSession(id, name, src)
-
-	// drain and report
-	for range progress {
-	}
-	return <-result
+	return c.DownloadFileFromContainer(&ContainerRef{Type: "session", Id: id}, name, path)
 }
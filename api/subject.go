@@ -0,0 +1,196 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// Subjects are not containers of their own: each session holds a copy of its subject, and a subject's code is unique within a project.
+// The functions in this file change a subject by modifying each of its sessions in turn.
+// Each session is changed with a single ModifySession that sends its whole subject, including the info,
+// so a session is never left with only part of a change.
+
+// SubjectSessionResult is the outcome of changing a subject on one of its sessions.
+type SubjectSessionResult struct {
+	SessionId string
+	Response  *http.Response
+	Error     error
+}
+
+// GetProjectSubjects returns the subjects of a project's sessions, each as recorded on the first of its sessions.
+func (c *Client) GetProjectSubjects(projectId string) ([]*Subject, *http.Response, error) {
+	sessions, resp, err := c.GetProjectSessions(projectId)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	subjects := []*Subject{}
+	seen := map[string]bool{}
+
+	for _, session := range sessions {
+		if session.Subject == nil || session.Subject.Code == "" || seen[session.Subject.Code] {
+			continue
+		}
+		seen[session.Subject.Code] = true
+
+		// Listings omit most of the subject, so fetch the session in full
+		full, resp, err := c.GetSession(session.Id)
+		if err != nil {
+			return nil, resp, err
+		}
+		if full.Subject != nil {
+			subjects = append(subjects, full.Subject)
+		}
+	}
+
+	return subjects, resp, nil
+}
+
+// GetSubjectSessions returns the sessions of a project whose subject has the given code.
+func (c *Client) GetSubjectSessions(projectId, code string) ([]*Session, *http.Response, error) {
+	sessions, resp, err := c.GetProjectSessions(projectId)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	result := []*Session{}
+	for _, session := range sessions {
+		if session.Subject != nil && session.Subject.Code == code {
+			result = append(result, session)
+		}
+	}
+
+	return result, resp, nil
+}
+
+// RenameSubject changes the code of a subject on each of its sessions.
+// Renaming to a code that another subject of the project already has is a conflict; use MergeSubjects instead.
+func (c *Client) RenameSubject(projectId, code, newCode string) ([]*SubjectSessionResult, error) {
+	existing, _, err := c.GetSubjectSessions(projectId, newCode)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) > 0 && code != newCode {
+		return nil, &Error{StatusCode: http.StatusConflict, Message: "Subject " + newCode + " already exists in project " + projectId}
+	}
+
+	return c.modifySubject(projectId, code, func(subject *Subject) {
+		subject.Code = newCode
+	})
+}
+
+// MergeSubjects moves every session of one subject to another, replacing the sessions' copy of the subject with that of the target.
+// Each session keeps its own subject Id.
+func (c *Client) MergeSubjects(projectId, code, intoCode string) ([]*SubjectSessionResult, error) {
+	if code == intoCode {
+		return nil, errors.New("Cannot merge subject " + code + " into itself")
+	}
+
+	targets, _, err := c.GetSubjectSessions(projectId, intoCode)
+	if err != nil {
+		return nil, err
+	}
+	if len(targets) == 0 {
+		return nil, subjectNotFound(projectId, intoCode)
+	}
+
+	target, _, err := c.GetSession(targets[0].Id)
+	if err != nil {
+		return nil, err
+	}
+
+	from := target.Subject
+	return c.modifySubject(projectId, code, func(subject *Subject) {
+		subject.Code = from.Code
+		subject.Firstname, subject.Lastname = from.Firstname, from.Lastname
+		subject.Sex, subject.Age = from.Sex, from.Age
+		subject.Info = from.Info
+	})
+}
+
+// SetSubjectInfo sets keys of a subject's info on each of its sessions, leaving other keys alone.
+func (c *Client) SetSubjectInfo(projectId, code string, set map[string]interface{}) ([]*SubjectSessionResult, error) {
+	return c.modifySubject(projectId, code, func(subject *Subject) {
+		info := map[string]interface{}{}
+		for k, v := range subject.Info {
+			info[k] = v
+		}
+		for k, v := range set {
+			info[k] = v
+		}
+		subject.Info = info
+	})
+}
+
+// ReplaceSubjectInfo replaces a subject's info on each of its sessions.
+func (c *Client) ReplaceSubjectInfo(projectId, code string, replace map[string]interface{}) ([]*SubjectSessionResult, error) {
+	return c.modifySubject(projectId, code, func(subject *Subject) {
+		subject.Info = replace
+	})
+}
+
+// DeleteSubjectInfoFields removes keys from a subject's info on each of its sessions.
+func (c *Client) DeleteSubjectInfoFields(projectId, code string, keys []string) ([]*SubjectSessionResult, error) {
+	return c.modifySubject(projectId, code, func(subject *Subject) {
+		info := map[string]interface{}{}
+		for k, v := range subject.Info {
+			info[k] = v
+		}
+		for _, k := range keys {
+			delete(info, k)
+		}
+		subject.Info = info
+	})
+}
+
+// modifySubject applies a change to the subject of each session that has the given subject code.
+func (c *Client) modifySubject(projectId, code string, change func(*Subject)) ([]*SubjectSessionResult, error) {
+	return c.eachSubjectSession(projectId, code, func(sessionId string) (*http.Response, error) {
+		// The whole subject is sent, so start from the session's full copy of it
+		full, resp, err := c.GetSession(sessionId)
+		if err != nil {
+			return resp, err
+		}
+		subject := &Subject{}
+		if full.Subject != nil {
+			*subject = *full.Subject
+		}
+		change(subject)
+
+		return c.ModifySession(sessionId, &Session{Subject: subject})
+	})
+}
+
+// eachSubjectSession applies a change to each session that has the given subject code.
+// Every session is attempted; if any fail, an error is returned along with the result for each session.
+func (c *Client) eachSubjectSession(projectId, code string, apply func(sessionId string) (*http.Response, error)) ([]*SubjectSessionResult, error) {
+	sessions, _, err := c.GetSubjectSessions(projectId, code)
+	if err != nil {
+		return nil, err
+	}
+	if len(sessions) == 0 {
+		return nil, subjectNotFound(projectId, code)
+	}
+
+	results := make([]*SubjectSessionResult, len(sessions))
+	failed := 0
+
+	for i, session := range sessions {
+		resp, err := apply(session.Id)
+		results[i] = &SubjectSessionResult{SessionId: session.Id, Response: resp, Error: err}
+		if err != nil {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return results, errors.New("Modifying subject " + code + " failed on " + strconv.Itoa(failed) + " of " + strconv.Itoa(len(sessions)) + " sessions")
+	}
+	return results, nil
+}
+
+// subjectNotFound returns a not-found error for a subject, so that it can be checked with IsNotFound.
+func subjectNotFound(projectId, code string) error {
+	return &Error{StatusCode: http.StatusNotFound, Message: "No sessions of project " + projectId + " have subject " + code}
+}
@@ -0,0 +1,236 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// The following functions manage the tags of the container or file at url, such as "sessions/<id>" or "sessions/<id>/files/<name>".
+// Each container type has wrappers for them alongside its other functions.
+
+func (c *Client) addTag(url, tag string) (*http.Response, error) {
+	var aerr *Error
+	var response *ModifiedResponse
+
+	tagDoc := map[string]interface{}{
+		"value": tag,
+	}
+
+	resp, err := c.New().Post(url+"/tags").BodyJSON(tagDoc).Receive(&response, &aerr)
+
+	// Should not have to check this count
+	// https://github.com/scitran/core/issues/680
+	if err == nil && aerr == nil && response.ModifiedCount != 1 {
+		return resp, errors.New("Adding tag to " + url + " returned " + strconv.Itoa(response.ModifiedCount) + " instead of 1")
+	}
+
+	return resp, Coalesce(err, aerr)
+}
+
+func (c *Client) renameTag(url, tag, newTag string) (*http.Response, error) {
+	var aerr *Error
+	var response *ModifiedResponse
+
+	tagDoc := map[string]interface{}{
+		"value": newTag,
+	}
+
+	resp, err := c.New().Put(url+"/tags/"+tag).BodyJSON(tagDoc).Receive(&response, &aerr)
+
+	// Should not have to check this count
+	// https://github.com/scitran/core/issues/680
+	if err == nil && aerr == nil && response.ModifiedCount != 1 {
+		return resp, errors.New("Renaming tag on " + url + " returned " + strconv.Itoa(response.ModifiedCount) + " instead of 1")
+	}
+
+	return resp, Coalesce(err, aerr)
+}
+
+func (c *Client) deleteTag(url, tag string) (*http.Response, error) {
+	var aerr *Error
+	var response *ModifiedResponse
+
+	resp, err := c.New().Delete(url+"/tags/"+tag).Receive(&response, &aerr)
+
+	// Should not have to check this count
+	// https://github.com/scitran/core/issues/680
+	if err == nil && aerr == nil && response.ModifiedCount != 1 {
+		return resp, errors.New("Deleting tag from " + url + " returned " + strconv.Itoa(response.ModifiedCount) + " instead of 1")
+	}
+
+	return resp, Coalesce(err, aerr)
+}
+
+// TagChange describes a change to the tags of a container, as applied by BulkTag.
+// Renames are applied first, then removals, then additions.
+type TagChange struct {
+	// Add lists tags to add. Tags a container already has are left alone.
+	Add []string
+
+	// Remove lists tags to remove. Tags a container does not have are ignored.
+	Remove []string
+
+	// Rename maps tags to their new values. Tags a container does not have are ignored.
+	// If a container already has the new value, the old tag is removed instead.
+	Rename map[string]string
+}
+
+// BulkTagResult is the outcome of changing the tags of one target of BulkTag.
+type BulkTagResult struct {
+	Target *ContainerReference
+
+	// Tags holds the target's tags after the requests that succeeded, or nil if it could not be fetched.
+	Tags []string
+
+	// Modified is the number of requests that changed the target's tags; zero if it already matched.
+	Modified int
+
+	Error error
+}
+
+// BulkTag applies a tag change to each of a list of containers, changing up to workers containers at once.
+// Each container is fetched first, so that only the requests it needs are made.
+//
+// Every target is attempted; if any fail, an error is returned along with the result for each target, in the order given.
+// Targets not yet started when the client's context is cancelled fail with the context's error.
+func (c *Client) BulkTag(targets []*ContainerReference, change *TagChange, workers int) ([]*BulkTagResult, error) {
+	if workers < 1 {
+		workers = 1
+	}
+
+	results := make([]*BulkTagResult, len(targets))
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i] = c.tagContainer(targets[i], change)
+			}
+		}()
+	}
+
+	for i := range targets {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	failed := 0
+	for _, result := range results {
+		if result.Error != nil {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return results, errors.New("Changing tags failed on " + strconv.Itoa(failed) + " of " + strconv.Itoa(len(targets)) + " containers")
+	}
+	return results, nil
+}
+
+// tagContainer applies a tag change to a single container.
+func (c *Client) tagContainer(target *ContainerReference, change *TagChange) *BulkTagResult {
+	result := &BulkTagResult{Target: target}
+
+	if err := c.Context().Err(); err != nil {
+		result.Error = err
+		return result
+	}
+
+	url, err := containerURL(target)
+	if err != nil {
+		result.Error = err
+		return result
+	}
+
+	var aerr *Error
+	var container struct {
+		Tags []string `json:"tags"`
+	}
+	_, err = c.New().Get(url).Receive(&container, &aerr)
+	if result.Error = Coalesce(err, aerr); result.Error != nil {
+		return result
+	}
+
+	tags := container.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	has := func(tag string) bool {
+		return indexOf(tags, tag) >= 0
+	}
+	without := func(tag string) []string {
+		i := indexOf(tags, tag)
+		return append(tags[:i:i], tags[i+1:]...)
+	}
+
+	// Apply renames in a stable order, so that overlapping renames behave the same every time
+	var renames []string
+	for tag := range change.Rename {
+		renames = append(renames, tag)
+	}
+	sort.Strings(renames)
+
+	for _, tag := range renames {
+		newTag := change.Rename[tag]
+		if !has(tag) || tag == newTag {
+			continue
+		}
+
+		if has(newTag) {
+			_, err = c.deleteTag(url, tag)
+			if err == nil {
+				tags = without(tag)
+			}
+		} else {
+			_, err = c.renameTag(url, tag, newTag)
+			if err == nil {
+				tags[indexOf(tags, tag)] = newTag
+			}
+		}
+
+		if err != nil {
+			break
+		}
+		result.Modified++
+	}
+
+	for _, tag := range change.Remove {
+		if err != nil || !has(tag) {
+			continue
+		}
+		if _, err = c.deleteTag(url, tag); err == nil {
+			tags = without(tag)
+			result.Modified++
+		}
+	}
+
+	for _, tag := range change.Add {
+		if err != nil || has(tag) {
+			continue
+		}
+		if _, err = c.addTag(url, tag); err == nil {
+			tags = append(tags, tag)
+			result.Modified++
+		}
+	}
+
+	result.Tags, result.Error = tags, err
+	return result
+}
+
+// indexOf returns the position of a string in a list, or -1 if it is not present.
+func indexOf(list []string, x string) int {
+	for i, y := range list {
+		if y == x {
+			return i
+		}
+	}
+	return -1
+}
@@ -1,20 +1,33 @@
 package api
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"io"
 	"net/http"
+	"net/url"
+	"strings"
+	"time"
 
 	"github.com/dghubble/sling"
 )
 
 // SdkDebugKey is the environment variable used to control request debug logging.
-// If enabled, the SDK will log an HTTP/1.1 representation of all requests made to stdout.
+// If set, the SDK will log all requests made to stderr, in the LogFormat named by its value ("text", "json" or "raw").
+// Other values log as text.
 const SdkDebugKey = "SdkDebug"
 
 // Client is an http and sling client capable of making flywheel requests.
 type Client struct {
 	*http.Client
 	*sling.Sling
+
+	// ctx is attached to every request the client makes. See WithContext.
+	ctx context.Context
+
+	// retry is the client's retry policy, if any. See RetryRequests.
+	retry *RetryPolicy
 }
 
 type ApiKeyClientOption func(*ApiKeyClientOptions)
@@ -25,13 +38,49 @@ type ApiKeyClientOptions struct {
 	// Use plaintext (HTTP) transport
 	InsecureUsePlaintext bool
 
-	// A writer to send debug request bodies to, if any
+	// A writer to send debug request logs to, if any
 	DebugWriter io.Writer
+
+	// The format of debug request logs
+	DebugFormat LogFormat
+
+	// Policy for retrying failed requests, if any
+	Retry *RetryPolicy
+
+	// Limiter for request rate and concurrency, if any
+	Limiter *Limiter
+
+	// Base transport to use instead of the default, if any.
+	// TLS, proxy and response header timeout options do not apply to a custom base transport.
+	BaseTransport http.RoundTripper
+
+	// Middleware to wrap the base transport with, outermost first
+	Middleware []Middleware
+
+	// Certificate authorities to verify the server against, if not the system's
+	RootCAs *x509.CertPool
+
+	// Client certificates to present to the server, for mutual TLS
+	Certificates []tls.Certificate
+
+	// Proxy selection function. Defaults to http.ProxyFromEnvironment if nil.
+	Proxy func(*http.Request) (*url.URL, error)
+
+	// Time limit for an entire request, including reading the response body. Zero means no limit.
+	Timeout time.Duration
+
+	// Time limit for the server's response headers, after the request is sent. Zero means no limit.
+	ResponseHeaderTimeout time.Duration
 }
 
+// Middleware wraps a RoundTripper, returning a RoundTripper that adds some behavior.
+// See UseMiddleware.
+type Middleware func(http.RoundTripper) http.RoundTripper
+
 var DefaultApiKeyClientOptions = ApiKeyClientOptions{
 	InsecureSkipVerify:   false,
 	InsecureUsePlaintext: false,
+	DebugFormat:          LogText,
 }
 
 // Specify that the ApiKeyClient should not verify SSL connections.
@@ -42,14 +91,110 @@ var InsecureNoSSLVerification ApiKeyClientOption
 // Should only be used for development.
 var InsecureUsePlaintext ApiKeyClientOption
 
-// Specify that the ApiKeyClient should log all request bodies to the specified Writer.
-// See DebugTransport for details.
+// Specify that the ApiKeyClient should log all requests to the specified Writer.
+// Credentials are redacted. See DebugLogFormat, LogTransport and DebugTransport for details.
 func DebugLogRequests(w io.Writer) ApiKeyClientOption {
 	return func(o *ApiKeyClientOptions) {
 		o.DebugWriter = w
 	}
 }
 
+// Specify the format used by DebugLogRequests. Defaults to LogText.
+func DebugLogFormat(format LogFormat) ApiKeyClientOption {
+	return func(o *ApiKeyClientOptions) {
+		o.DebugFormat = format
+	}
+}
+
+// Specify a base transport for the ApiKeyClient to send requests with, such as an instrumented or mocked transport.
+// The options for TLS, proxies and ResponseHeaderTimeout configure the default base transport, and are ignored if one is given.
+func BaseTransport(rt http.RoundTripper) ApiKeyClientOption {
+	return func(o *ApiKeyClientOptions) {
+		o.BaseTransport = rt
+	}
+}
+
+// Specify middleware for the ApiKeyClient to wrap its base transport with. The first middleware given is outermost.
+// Middleware is in turn wrapped by the SDK's own logging, limiting and retrying transports, so it sees every attempt.
+// May be given more than once; middleware accumulates.
+func UseMiddleware(middleware ...Middleware) ApiKeyClientOption {
+	return func(o *ApiKeyClientOptions) {
+		o.Middleware = append(o.Middleware, middleware...)
+	}
+}
+
+// Specify the certificate authorities the ApiKeyClient should verify the server against, instead of the system's.
+func RootCAs(pool *x509.CertPool) ApiKeyClientOption {
+	return func(o *ApiKeyClientOptions) {
+		o.RootCAs = pool
+	}
+}
+
+// Specify client certificates the ApiKeyClient should present to the server, for mutual TLS.
+// Certificates can be loaded with tls.LoadX509KeyPair.
+func ClientCertificates(certs ...tls.Certificate) ApiKeyClientOption {
+	return func(o *ApiKeyClientOptions) {
+		o.Certificates = append(o.Certificates, certs...)
+	}
+}
+
+// Specify how the ApiKeyClient should choose a proxy for each request, as with http.Transport.
+// By default, proxies are taken from the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.
+func Proxy(proxy func(*http.Request) (*url.URL, error)) ApiKeyClientOption {
+	return func(o *ApiKeyClientOptions) {
+		o.Proxy = proxy
+	}
+}
+
+// Specify that the ApiKeyClient should send all requests through the proxy at proxyURL.
+func ProxyURL(proxyURL *url.URL) ApiKeyClientOption {
+	return Proxy(http.ProxyURL(proxyURL))
+}
+
+// Specify a time limit for each request made by the ApiKeyClient, including reading the response body.
+// This limits uploads and downloads as well; consider ResponseHeaderTimeout or Client.WithContext for those.
+func Timeout(timeout time.Duration) ApiKeyClientOption {
+	return func(o *ApiKeyClientOptions) {
+		o.Timeout = timeout
+	}
+}
+
+// Specify a time limit for the server's response headers, once a request has been sent.
+// Unlike Timeout, this does not limit how long a response body takes to read.
+func ResponseHeaderTimeout(timeout time.Duration) ApiKeyClientOption {
+	return func(o *ApiKeyClientOptions) {
+		o.ResponseHeaderTimeout = timeout
+	}
+}
+
+// parseLogFormat interprets the value of SdkDebugKey.
+func parseLogFormat(value string) LogFormat {
+	switch LogFormat(strings.ToLower(value)) {
+	case LogJSON:
+		return LogJSON
+	case LogRaw:
+		return LogRaw
+	default:
+		return LogText
+	}
+}
+
+// Specify that the ApiKeyClient should retry failed idempotent requests according to policy.
+// See RetryPolicy and DefaultRetryPolicy for details.
+func RetryRequests(policy RetryPolicy) ApiKeyClientOption {
+	return func(o *ApiKeyClientOptions) {
+		o.Retry = &policy
+	}
+}
+
+// Specify that the ApiKeyClient should delay requests as required by limiter.
+// The same Limiter may be shared by several clients; see NewLimiter for details.
+func LimitRequests(limiter *Limiter) ApiKeyClientOption {
+	return func(o *ApiKeyClientOptions) {
+		o.Limiter = limiter
+	}
+}
+
 func init() {
 	InsecureNoSSLVerification = func(o *ApiKeyClientOptions) {
 		o.InsecureSkipVerify = true
@@ -3,10 +3,12 @@ package api
 import (
 	"crypto/tls"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httputil"
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/dghubble/sling"
 )
@@ -15,11 +17,11 @@ import (
 // Passing a key with an invalid format will panic.
 func NewApiKeyClient(apiKey string, options ...ApiKeyClientOption) *Client {
 
-	// If the debug environment variable is set, add the debug transport.
+	// If the debug environment variable is set, add request logging in the format it names.
 	// This is added to the beginning of the options array, in case it is overridden later.
-	_, debug := os.LookupEnv(SdkDebugKey)
+	format, debug := os.LookupEnv(SdkDebugKey)
 	if debug {
-		options = append([]ApiKeyClientOption{DebugLogRequests(os.Stderr)}, options...)
+		options = append([]ApiKeyClientOption{DebugLogRequests(os.Stderr), DebugLogFormat(parseLogFormat(format))}, options...)
 	}
 
 	// Load all configuration options into a config struct
@@ -33,26 +35,64 @@ func NewApiKeyClient(apiKey string, options ...ApiKeyClientOption) *Client {
 		panic(err)
 	}
 
-	// Load TLS configuration into a transport
-	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{
+	// Use the given base transport, or load TLS and proxy configuration into a default one
+	rt := config.BaseTransport
+	if rt == nil {
+		tr := defaultTransport()
+		tr.TLSClientConfig = &tls.Config{
 			InsecureSkipVerify: config.InsecureSkipVerify,
-		},
+			RootCAs:            config.RootCAs,
+			Certificates:       config.Certificates,
+		}
+		tr.ResponseHeaderTimeout = config.ResponseHeaderTimeout
+		if config.Proxy != nil {
+			tr.Proxy = config.Proxy
+		}
+		rt = tr
 	}
 
-	var hc *http.Client
+	// Wrap the base transport with middleware, applying the last first so that the first is outermost
+	for i := len(config.Middleware) - 1; i >= 0; i-- {
+		rt = config.Middleware[i](rt)
+	}
 
-	// Create an HTTP client, adding the debug transport if specified
-	if config.DebugWriter == nil {
-		hc = &http.Client{
-			Transport: tr,
+	// Add request logging if specified
+	if config.DebugWriter != nil {
+		if config.DebugFormat == LogRaw {
+			rt = &DebugTransport{
+				Transport: rt,
+				Writer:    config.DebugWriter,
+			}
+		} else {
+			rt = &LogTransport{
+				Transport: rt,
+				Writer:    config.DebugWriter,
+				Format:    config.DebugFormat,
+			}
 		}
-	} else {
-		debugT := &DebugTransport{
-			Transport: tr,
-			Writer:    config.DebugWriter,
+	}
+
+	// Add the limit transport if specified.
+	// This is wrapped by the retry transport, so that each attempt is limited.
+	if config.Limiter != nil {
+		rt = &LimitTransport{
+			Transport: rt,
+			Limiter:   config.Limiter,
+		}
+	}
+
+	// Add the retry transport if specified.
+	// This wraps the debug transport, so that each attempt is logged.
+	if config.Retry != nil {
+		rt = &RetryTransport{
+			Transport: rt,
+			Policy:    *config.Retry,
 		}
-		hc = debugT.Client()
+	}
+
+	hc := &http.Client{
+		Transport: rt,
+		Timeout:   config.Timeout,
 	}
 
 	protocol := "https"
@@ -66,21 +106,53 @@ func NewApiKeyClient(apiKey string, options ...ApiKeyClientOption) *Client {
 		Set("Authorization", "scitran-user "+key).
 		Set("User-Agent", "Flywheel SDK").
 		Path("api/").
-		Client(hc)
+		Doer(failureDoer{hc}).
+		ResponseDecoder(errorDecoder{})
 
 	return &Client{
-		hc,
-		sc,
+		Client: hc,
+		Sling:  sc,
+		retry:  config.Retry,
 	}
 }
 
-// DebugTransport prints its raw requests and responses to a writer, including bodies.
+// defaultTransport returns a copy of http.DefaultTransport, or if a program has replaced it with another type,
+// a transport with the same settings as the standard library's.
+func defaultTransport() *http.Transport {
+	if tr, ok := http.DefaultTransport.(*http.Transport); ok {
+		return tr.Clone()
+	}
+
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
+
+// DebugTransport prints its raw requests and responses to a writer.
+//
+// Credentials are redacted, and only textual bodies up to MaxBodyBytes are printed;
+// larger or binary bodies, such as file transfers, are summarized instead.
 type DebugTransport struct {
 
 	// All requests made with this transport will be written to Writer.
 	// It will default to os.Stderr if nil.
 	Writer io.Writer
 
+	// MaxBodyBytes is the largest body that will be printed. Defaults to DefaultLogBodyBytes if zero.
+	MaxBodyBytes int
+
+	// ShowCredentials disables redaction. Should only be used for development.
+	ShowCredentials bool
+
 	// Transport is the underlying HTTP transport to use when making requests.
 	// It will default to http.DefaultTransport if nil.
 	Transport http.RoundTripper
@@ -101,9 +173,8 @@ func (t *DebugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 	//
 	// For that reason, there's a bit of "if err is nil okay now we can use err" logic.
 
-	msg, err := httputil.DumpRequestOut(req, true)
+	msg, err := t.dumpRequest(req)
 	if err != nil {
-		Println("ergh")
 		return nil, err
 	}
 
@@ -127,7 +198,7 @@ func (t *DebugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 		// If there is a valid response, dump that too
 		if resp != nil {
 			var msg2 []byte
-			msg2, err = httputil.DumpResponse(resp, true)
+			msg2, err = t.dumpResponse(resp)
 
 			if err != nil {
 				str := []byte("Response dump failed: " + err.Error())
@@ -155,6 +226,86 @@ func (t *DebugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 	return resp, err
 }
 
+// printBody reports whether a body of this type and length should be printed.
+// Bodies of unknown length, given as -1, are not printed, as they could be of any size.
+func (t *DebugTransport) printBody(contentType string, length int64) bool {
+	limit := int64(t.MaxBodyBytes)
+	if limit <= 0 {
+		limit = DefaultLogBodyBytes
+	}
+	return isTextContent(contentType) && length >= 0 && length <= limit
+}
+
+// dumpRequest dumps a redacted copy of the request, without consuming its body.
+func (t *DebugTransport) dumpRequest(req *http.Request) ([]byte, error) {
+	clone := req.Clone(req.Context())
+	if !t.ShowCredentials {
+		clone.Header = redactHeader(req.Header)
+		clone.URL = redactURL(req.URL)
+	}
+
+	hasBody := req.Body != nil && req.Body != http.NoBody
+
+	// Only replayable bodies can be printed, as the original must still be sent
+	printBody := hasBody && req.GetBody != nil && req.ContentLength >= 0 && t.printBody(req.Header.Get("Content-Type"), req.ContentLength)
+	if printBody {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		clone.Body = body
+	}
+
+	msg, err := httputil.DumpRequestOut(clone, printBody)
+	if err != nil {
+		return nil, err
+	}
+
+	if hasBody && !printBody {
+		// A zero length with a body present means the length is unknown
+		length := req.ContentLength
+		if length == 0 {
+			length = -1
+		}
+		msg = append(msg, []byte(bodySummary(req.Header.Get("Content-Type"), length))...)
+	}
+	return msg, nil
+}
+
+// dumpResponse dumps a redacted copy of the response.
+// If the body is printed, it is buffered and replaced so that it can still be read.
+func (t *DebugTransport) dumpResponse(resp *http.Response) ([]byte, error) {
+	clone := *resp
+	if !t.ShowCredentials {
+		clone.Header = redactHeader(resp.Header)
+	}
+
+	printBody := t.printBody(resp.Header.Get("Content-Type"), resp.ContentLength)
+
+	msg, err := httputil.DumpResponse(&clone, printBody)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = clone.Body
+
+	if !printBody && resp.ContentLength != 0 {
+		msg = append(msg, []byte(bodySummary(resp.Header.Get("Content-Type"), resp.ContentLength))...)
+	}
+	return msg, nil
+}
+
+// bodySummary describes a body that was not printed.
+func bodySummary(contentType string, length int64) string {
+	if contentType == "" {
+		contentType = "unknown type"
+	}
+	size := "unknown size"
+	if length >= 0 {
+		size = strconv.FormatInt(length, 10) + " bytes"
+	}
+	return "[Body omitted: " + contentType + ", " + size + "]"
+}
+
 // Client returns an *http.Client which uses the DebugTransport.
 func (t *DebugTransport) Client() *http.Client {
 	return &http.Client{Transport: t}
@@ -3,7 +3,6 @@ package api
 import (
 	"errors"
 	"io"
-	"io/ioutil"
 	"mime/multipart"
 	"net/http"
 	"os"
@@ -119,19 +118,19 @@ func (c *Client) sendUploadRequest(url string, reader io.ReadCloser, contentType
 		Request()
 
 	if err != nil {
+		// Unblock the multipart writer, which would otherwise wait on the pipe forever
+		reader.Close()
 		return nil, err
 	}
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return resp, err
 	}
+	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
-		// Needs robust handling for body & raw nils
-		raw, _ := ioutil.ReadAll(resp.Body)
-
-		return resp, errors.New(string(raw))
+		return resp, errorFromResponse(resp)
 	}
 
 	return resp, err
@@ -142,7 +141,17 @@ func (c *Client) sendUploadRequest(url string, reader io.ReadCloser, contentType
 //
 // Depending on the URL, metadata may be required, or only one file may be allowed at a time.
 // It is generally a good idea to use a purpose-specific upload method.
+//
+// If the client's RetryPolicy enables RetryUploads, uploads whose sources all set Path are retried.
 func (c *Client) Upload(url string, metadata []byte, progress chan<- int64, files []*UploadSource) chan error {
+	if c.retry != nil && c.retry.RetryUploads && canRetryUpload(files) {
+		return c.uploadWithRetry(url, metadata, progress, files)
+	}
+	return c.uploadOnce(url, metadata, progress, files)
+}
+
+// uploadOnce makes a single upload attempt. See Upload.
+func (c *Client) uploadOnce(url string, metadata []byte, progress chan<- int64, files []*UploadSource) chan error {
 
 	// Form data is written from one goroutine to another
 	reader, writer := io.Pipe()
@@ -155,7 +164,6 @@ func (c *Client) Upload(url string, metadata []byte, progress chan<- int64, file
 	// Shared memory for results, protected by a waitgroup. Simpler (but more dangerous) than channels.
 	var writeError error
 	var uploadError error
-	var response *http.Response
 	var wg sync.WaitGroup
 	wg.Add(2)
 
@@ -171,7 +179,7 @@ func (c *Client) Upload(url string, metadata []byte, progress chan<- int64, file
 
 	// Send encoded body to server, await completion, report
 	go func() {
-		response, uploadError = c.sendUploadRequest(url, reader, contentType)
+		_, uploadError = c.sendUploadRequest(url, reader, contentType)
 		wg.Done()
 	}()
 
@@ -181,7 +189,10 @@ func (c *Client) Upload(url string, metadata []byte, progress chan<- int64, file
 
 		// Encoding & local-IO errors take precedence over network errors.
 		// Could combine the two if both are set. Eh.
-		if writeError != nil {
+		// A cancelled context causes both to fail; report the context error in that case.
+		if ctxErr := c.Context().Err(); ctxErr != nil {
+			resultChan <- ctxErr
+		} else if writeError != nil {
 			resultChan <- writeError
 		} else {
 			resultChan <- uploadError
@@ -0,0 +1,468 @@
+package api
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultUploadWorkers is the number of files uploaded at once by UploadDirectory whose options set no Workers.
+const DefaultUploadWorkers = 4
+
+// The levels of the hierarchy that a local directory tree may mirror, from the top down.
+// Subjects are not containers, but are given a level of their own so that sessions can be grouped by subject.
+var directoryLevels = []string{"group", "project", "subject", "session", "acquisition"}
+
+// UploadLocation is where UploadDirectory places a local file.
+// Containers are named by label, or by Id for an existing container; groups are named by Id.
+// Only the levels below the target container are used, and the file is uploaded to the deepest level given.
+type UploadLocation struct {
+	Group       string
+	Project     string
+	Subject     string
+	Session     string
+	Acquisition string
+
+	// Name is the name to upload the file under. Defaults to the local file's name.
+	Name string
+}
+
+// UploadDirectoryOptions configure UploadDirectory. The zero value uses the defaults.
+type UploadDirectoryOptions struct {
+	// Map returns where to upload a local file, given its slash-separated path relative to the local root.
+	// Returning nil leaves the file out. Returning an error fails that file only.
+	//
+	// By default, each directory names a container at the next level below the target, down to acquisitions,
+	// so that a project's local tree is laid out as subject/session/acquisition/file.
+	Map func(relativePath string) (*UploadLocation, error)
+
+	// Workers is the number of files uploaded at once. Defaults to DefaultUploadWorkers.
+	Workers int
+
+	// CompareHashes skips a file that already exists only if its hash matches too, rather than only its size.
+	// Hashing reads every local file that might be skipped.
+	CompareHashes bool
+}
+
+// Status of each file in an UploadManifest or ExportManifest.
+const (
+	ManifestUploaded   = "uploaded"
+	ManifestDownloaded = "downloaded"
+	ManifestSkipped    = "skipped"
+	ManifestFailed     = "failed"
+)
+
+// UploadManifest records what UploadDirectory did.
+type UploadManifest struct {
+	// Containers that were created, in the order they were created.
+	Created []*CreatedContainer
+
+	// Local files, in the order they were found, with what became of each.
+	Files []*ManifestFile
+}
+
+// CreatedContainer is a container that UploadDirectory created.
+type CreatedContainer struct {
+	Ref    *ContainerRef
+	Label  string
+	Parent *ContainerRef
+}
+
+// ManifestFile is a local file that UploadDirectory or Export considered.
+type ManifestFile struct {
+	Path string
+	Size int64
+
+	// The container the file was uploaded to or downloaded from, or would have been.
+	Target *ContainerRef
+	Name   string
+
+	// One of the Manifest statuses, and the error for a failed file.
+	Status string
+	Error  error
+}
+
+// uploadContainer is a container found or created by UploadDirectory, along with the files it already has.
+type uploadContainer struct {
+	ref   *ContainerRef
+	path  string
+	files map[string]*File
+}
+
+// directoryUpload is the state of one call to UploadDirectory.
+type directoryUpload struct {
+	c        *Client
+	options  *UploadDirectoryOptions
+	manifest *UploadManifest
+
+	// Containers found or created so far, by their path of labels.
+	containers map[string]*uploadContainer
+
+	// The local path of the first file found for each target, by container type, Id and file name.
+	targets map[string]string
+}
+
+// UploadDirectory uploads the files in a local directory tree to target and the containers below it,
+// creating any containers that do not yet exist. A nil target uploads to the top of the hierarchy, starting with groups.
+//
+// Files that already exist with the same name and size are skipped; see UploadDirectoryOptions for how to compare hashes too.
+// If several local files are mapped to the same name in one container, only the first found is uploaded, and the rest fail.
+// Containers are found or created one at a time, and files are then uploaded several at once.
+//
+// The returned manifest lists each container created and each file found.
+// If any files failed, an error saying how many is returned along with the manifest.
+func (c *Client) UploadDirectory(localRoot string, target *ContainerRef, options *UploadDirectoryOptions) (*UploadManifest, error) {
+	if options == nil {
+		options = &UploadDirectoryOptions{}
+	}
+
+	levels, err := levelsBelow(target)
+	if err != nil {
+		return nil, err
+	}
+	mapping := options.Map
+	if mapping == nil {
+		mapping = mirrorLevels(levels)
+	}
+
+	u := &directoryUpload{
+		c:          c,
+		options:    options,
+		manifest:   &UploadManifest{Created: []*CreatedContainer{}, Files: []*ManifestFile{}},
+		containers: map[string]*uploadContainer{},
+		targets:    map[string]string{},
+	}
+
+	root := &uploadContainer{ref: target, files: map[string]*File{}}
+	if target != nil {
+		container, _, err := c.GetContainer(target)
+		if err != nil {
+			return nil, err
+		}
+		root.files = filesByName(container.Files)
+	}
+
+	// Find or create containers one file at a time, so that each is only created once
+	var pending []*ManifestFile
+	err = filepath.Walk(localRoot, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		relative, err := filepath.Rel(localRoot, path)
+		if err != nil {
+			return err
+		}
+		location, err := mapping(filepath.ToSlash(relative))
+		if location == nil && err == nil {
+			return nil
+		}
+
+		entry := &ManifestFile{Path: path, Size: info.Size(), Name: info.Name()}
+		u.manifest.Files = append(u.manifest.Files, entry)
+
+		var container *uploadContainer
+		if err == nil {
+			container, err = u.locate(root, levels, location)
+		}
+		if err != nil {
+			entry.Status, entry.Error = ManifestFailed, err
+			return nil
+		}
+
+		entry.Target = container.ref
+		if location.Name != "" {
+			entry.Name = location.Name
+		}
+
+		// Files are found in lexical order, so the same one of two files with one target always wins
+		key := container.ref.Type + "/" + container.ref.Id + "/" + entry.Name
+		if first, ok := u.targets[key]; ok {
+			entry.Status, entry.Error = ManifestFailed, errors.New("File "+path+" would overwrite "+first+", which is uploaded as the same file")
+			return nil
+		}
+		u.targets[key] = path
+
+		existing := container.files[entry.Name]
+		if existing != nil {
+			same, err := u.sameFile(existing, entry)
+			if err != nil {
+				entry.Status, entry.Error = ManifestFailed, err
+				return nil
+			}
+			if same {
+				entry.Status = ManifestSkipped
+				return nil
+			}
+		}
+
+		pending = append(pending, entry)
+		return nil
+	})
+	if err != nil {
+		return u.manifest, err
+	}
+
+	u.uploadAll(pending)
+
+	failed := 0
+	for _, entry := range u.manifest.Files {
+		if entry.Status == ManifestFailed {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return u.manifest, errors.New("Uploading failed for " + strconv.Itoa(failed) + " of " + strconv.Itoa(len(u.manifest.Files)) + " files")
+	}
+	return u.manifest, nil
+}
+
+// levelsBelow returns the levels of the hierarchy below a target container, or all of them for a nil target.
+func levelsBelow(target *ContainerRef) ([]string, error) {
+	if target == nil {
+		return directoryLevels, nil
+	}
+
+	for i, level := range directoryLevels {
+		if level == target.Type && level != "subject" {
+			return directoryLevels[i+1:], nil
+		}
+	}
+	return nil, errors.New("Cannot upload a directory to a " + target.Type)
+}
+
+// mirrorLevels returns the default mapping of UploadDirectory, in which each directory names a container at the next level.
+func mirrorLevels(levels []string) func(string) (*UploadLocation, error) {
+	return func(relativePath string) (*UploadLocation, error) {
+		segments := strings.Split(relativePath, "/")
+		dirs := segments[:len(segments)-1]
+
+		if len(dirs) > len(levels) {
+			return nil, errors.New("File " + relativePath + " is nested more deeply than the hierarchy")
+		}
+
+		location := &UploadLocation{}
+		fields := map[string]*string{
+			"group":       &location.Group,
+			"project":     &location.Project,
+			"subject":     &location.Subject,
+			"session":     &location.Session,
+			"acquisition": &location.Acquisition,
+		}
+		for i, dir := range dirs {
+			*fields[levels[i]] = dir
+		}
+		return location, nil
+	}
+}
+
+// locate finds or creates the container a file is to be uploaded to, starting from the target.
+func (u *directoryUpload) locate(root *uploadContainer, levels []string, location *UploadLocation) (*uploadContainer, error) {
+	labels := map[string]string{
+		"group":       location.Group,
+		"project":     location.Project,
+		"subject":     location.Subject,
+		"session":     location.Session,
+		"acquisition": location.Acquisition,
+	}
+
+	current := root
+	missing := ""
+	for _, level := range levels {
+		label := labels[level]
+		switch {
+		case level == "subject" && label != "" && location.Session == "":
+			return nil, errors.New("Files cannot be uploaded to subject " + label + ", which is not a container")
+		case level == "subject":
+			continue
+		case label == "":
+			missing = level
+			continue
+		case missing != "":
+			return nil, errors.New("Cannot upload to " + level + " " + label + " without a " + missing)
+		}
+
+		var err error
+		current, err = u.child(current, level, label, labels["subject"])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if current.ref == nil {
+		return nil, errors.New("Files cannot be uploaded above the level of groups")
+	}
+	return current, nil
+}
+
+// child finds the container at a level below parent that has a label, or creates it.
+// Sessions are looked for among those of the subject given, if any, and are created with that subject.
+func (u *directoryUpload) child(parent *uploadContainer, level, label, subject string) (*uploadContainer, error) {
+	path := parent.path + "/" + label
+	if level == "session" && subject != "" {
+		path = parent.path + "/" + subject + "/" + label
+	}
+	if x := u.containers[path]; x != nil {
+		return x, nil
+	}
+
+	c := u.c
+	var ids, labels []string
+	var err error
+
+	switch level {
+	case "group":
+		var groups []*Group
+		groups, _, err = c.GetAllGroups()
+		for _, x := range groups {
+			ids, labels = append(ids, x.Id), append(labels, x.Name)
+		}
+
+	case "project":
+		var projects []*Project
+		projects, _, err = c.GetAllProjects()
+		for _, x := range projects {
+			if x.GroupId == parent.ref.Id {
+				ids, labels = append(ids, x.Id), append(labels, x.Name)
+			}
+		}
+
+	case "session":
+		var sessions []*Session
+		sessions, _, err = c.GetProjectSessions(parent.ref.Id)
+		for _, x := range sessions {
+			if subject == "" || (x.Subject != nil && x.Subject.Code == subject) {
+				ids, labels = append(ids, x.Id), append(labels, x.Name)
+			}
+		}
+
+	case "acquisition":
+		var acquisitions []*Acquisition
+		acquisitions, _, err = c.GetSessionAcquisitions(parent.ref.Id)
+		for _, x := range acquisitions {
+			ids, labels = append(ids, x.Id), append(labels, x.Name)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	i, err := matchSegment(strings.TrimPrefix(path, "/"), label, ids, labels)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &uploadContainer{path: path, files: map[string]*File{}}
+	if i >= 0 {
+		result.ref = &ContainerRef{Type: level, Id: ids[i]}
+		container, _, err := c.GetContainer(result.ref)
+		if err != nil {
+			return nil, err
+		}
+		result.files = filesByName(container.Files)
+	} else {
+		result.ref, err = u.create(parent.ref, level, label, subject)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	u.containers[path] = result
+	return result, nil
+}
+
+// create adds a container at a level below parent, and records it in the manifest.
+func (u *directoryUpload) create(parent *ContainerRef, level, label, subject string) (*ContainerRef, error) {
+	c := u.c
+	var id string
+	var err error
+
+	switch level {
+	case "group":
+		id, _, err = c.AddGroup(&Group{Id: label, Name: label})
+	case "project":
+		id, _, err = c.AddProject(&Project{Name: label, GroupId: parent.Id})
+	case "session":
+		session := &Session{Name: label, ProjectId: parent.Id}
+		if subject != "" {
+			session.Subject = &Subject{Code: subject}
+		}
+		id, _, err = c.AddSession(session)
+	case "acquisition":
+		id, _, err = c.AddAcquisition(&Acquisition{Name: label, SessionId: parent.Id})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ref := &ContainerRef{Type: level, Id: id}
+	u.manifest.Created = append(u.manifest.Created, &CreatedContainer{Ref: ref, Label: label, Parent: parent})
+	return ref, nil
+}
+
+// sameFile reports whether an existing file matches a local one, and so need not be uploaded.
+func (u *directoryUpload) sameFile(existing *File, entry *ManifestFile) (bool, error) {
+	if int64(existing.Size) != entry.Size {
+		return false, nil
+	}
+	if !u.options.CompareHashes {
+		return true, nil
+	}
+
+	file, err := os.Open(entry.Path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+	return matchesHash(file, existing.Hash)
+}
+
+// uploadAll uploads files with a pool of workers, recording the outcome of each.
+func (u *directoryUpload) uploadAll(pending []*ManifestFile) {
+	workers := u.options.Workers
+	if workers < 1 {
+		workers = DefaultUploadWorkers
+	}
+
+	entries := make(chan *ManifestFile)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for entry := range entries {
+				progress, result := u.c.UploadToContainer(entry.Target, &UploadSource{Path: entry.Path, Name: entry.Name})
+				for range progress {
+				}
+
+				if err := <-result; err != nil {
+					entry.Status, entry.Error = ManifestFailed, err
+				} else {
+					entry.Status = ManifestUploaded
+				}
+			}
+		}()
+	}
+
+	for _, entry := range pending {
+		entries <- entry
+	}
+	close(entries)
+	wg.Wait()
+}
+
+// filesByName indexes files by name.
+func filesByName(files []*File) map[string]*File {
+	result := map[string]*File{}
+	for _, file := range files {
+		result[file.Name] = file
+	}
+	return result
+}
@@ -2,24 +2,19 @@ package api
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
-	"strconv"
 )
 
-// Coalesce will extract an API error message into a golang error, if applicable.
+// Coalesce will return a transport error or an API error as a golang error, if applicable.
+// API errors are returned as an *Error.
 func Coalesce(err error, aerr *Error) error {
-
-	// This should eventually be replaced with something that takes the http response as well and looks at that. Expect200? Etc.
-
 	if err != nil {
 		return err
 	} else if aerr != nil {
 		if aerr.Message == "" {
 			aerr.Message = "Unknown server error"
 		}
-		aerr.Message = "(" + strconv.Itoa(aerr.StatusCode) + ") " + aerr.Message
-		return errors.New(aerr.Message)
+		return aerr
 	} else {
 		return nil
 	}
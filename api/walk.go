@@ -0,0 +1,383 @@
+package api
+
+import (
+	"errors"
+	"sync"
+)
+
+// SkipSubtree can be returned by a WalkFunc to skip the children of the node it was called for.
+var SkipSubtree = errors.New("skip this subtree")
+
+// StopWalk can be returned by a WalkFunc to end a walk early without Walk returning an error.
+var StopWalk = errors.New("stop walking")
+
+// WalkFunc is called by Walk for each node of the hierarchy.
+// The node's ancestors are filled in, and its Type reports which level it is.
+//
+// If a node's children cannot be listed, the function is called for that node with the error, in addition to any call without one.
+// Returning nil then skips the node's children and continues the walk.
+// Returning SkipSubtree skips the node's children, StopWalk ends the walk, and any other error ends the walk and is returned by Walk.
+//
+// Nodes are visited concurrently, so the function must be safe for concurrent use.
+// A node is always visited before its children, but there is no ordering between siblings.
+type WalkFunc func(node *ResolvedPath, err error) error
+
+// WalkOptions configure Walk.
+type WalkOptions struct {
+	// Workers is the number of nodes visited at once.
+	Workers int
+
+	// Types limits which levels WalkFunc is called for, such as "session" or "file".
+	// Levels below the deepest type given are not listed. Nil calls WalkFunc for every level.
+	Types []string
+}
+
+// WalkOption is a functional option for Walk.
+type WalkOption func(*WalkOptions)
+
+var DefaultWalkOptions = WalkOptions{
+	Workers: 4,
+	Types:   nil,
+}
+
+// WalkWorkers sets the number of nodes visited at once.
+func WalkWorkers(workers int) WalkOption {
+	return func(o *WalkOptions) {
+		o.Workers = workers
+	}
+}
+
+// WalkTypes limits which levels WalkFunc is called for.
+func WalkTypes(types ...string) WalkOption {
+	return func(o *WalkOptions) {
+		o.Types = types
+	}
+}
+
+// Depth of each level in the hierarchy.
+var walkDepths = map[string]int{
+	"":            0,
+	"group":       1,
+	"project":     2,
+	"session":     3,
+	"acquisition": 4,
+	"file":        5,
+}
+
+// Walk visits every node of the hierarchy under root, including root itself, calling fn for each.
+// Root is a path as accepted by Resolve; an empty root walks every group.
+//
+// Containers are as returned by listing their parent, which omits some fields.
+// When files are walked, containers are instead fetched in full, so that their files are known.
+//
+// Walk stops early if the client's context is cancelled, returning the context's error.
+func (c *Client) Walk(root string, fn WalkFunc, options ...WalkOption) error {
+	config := DefaultWalkOptions
+	for _, x := range options {
+		x(&config)
+	}
+	if config.Workers < 1 {
+		config.Workers = 1
+	}
+
+	w := &walker{
+		c:        c,
+		fn:       fn,
+		types:    map[string]bool{},
+		maxDepth: walkDepths["file"],
+	}
+	w.cond = sync.NewCond(&w.mu)
+
+	if config.Types != nil {
+		w.maxDepth = 0
+		for _, x := range config.Types {
+			depth, ok := walkDepths[x]
+			if !ok || x == "" {
+				return errors.New("Cannot walk unknown type " + x)
+			}
+			w.types[x] = true
+			if depth > w.maxDepth {
+				w.maxDepth = depth
+			}
+		}
+	}
+
+	// Resolve fetches containers in full, so the root needs no further fetching
+	start := &walkTask{node: &ResolvedPath{}, full: true}
+	if root != "" {
+		node, _, err := c.Resolve(root)
+		if err != nil {
+			return err
+		}
+		start.node = node
+	}
+	w.push(start)
+
+	var wg sync.WaitGroup
+	for i := 0; i < config.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.work()
+		}()
+	}
+	wg.Wait()
+
+	if w.err == StopWalk {
+		return nil
+	}
+	return w.err
+}
+
+// walkTask is a node waiting to be visited.
+type walkTask struct {
+	node *ResolvedPath
+
+	// Whether the node's container was fetched in full, rather than taken from a listing.
+	full bool
+}
+
+// walker holds the state of a single walk, shared between its workers.
+type walker struct {
+	c        *Client
+	fn       WalkFunc
+	types    map[string]bool
+	maxDepth int
+
+	mu   sync.Mutex
+	cond *sync.Cond
+
+	// Tasks waiting to be visited, and the number either waiting or being visited.
+	// The walk is finished when pending reaches zero.
+	queue   []*walkTask
+	pending int
+
+	// Set when the walk ends early.
+	stopped bool
+	err     error
+
+	// All projects, listed once per walk and grouped by group Id.
+	projectsOnce sync.Once
+	projects     map[string][]*Project
+	projectsErr  error
+}
+
+// push queues tasks to be visited.
+func (w *walker) push(tasks ...*walkTask) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.stopped {
+		return
+	}
+	w.queue = append(w.queue, tasks...)
+	w.pending += len(tasks)
+	w.cond.Broadcast()
+}
+
+// stop ends the walk, keeping the first reason given.
+func (w *walker) stop(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.stopped {
+		w.stopped = true
+		w.err = err
+	}
+	w.cond.Broadcast()
+}
+
+// work visits tasks until the walk is finished or stopped.
+func (w *walker) work() {
+	for {
+		w.mu.Lock()
+		for len(w.queue) == 0 && w.pending > 0 && !w.stopped {
+			w.cond.Wait()
+		}
+		if w.stopped || w.pending == 0 {
+			w.mu.Unlock()
+			return
+		}
+
+		// Visit the newest task first, so that the walk goes deep before wide and the queue stays short
+		task := w.queue[len(w.queue)-1]
+		w.queue = w.queue[:len(w.queue)-1]
+		w.mu.Unlock()
+
+		w.visit(task)
+
+		w.mu.Lock()
+		w.pending--
+		if w.pending == 0 {
+			w.cond.Broadcast()
+		}
+		w.mu.Unlock()
+	}
+}
+
+// visit calls fn for a node, then queues its children.
+func (w *walker) visit(task *walkTask) {
+	if err := w.c.Context().Err(); err != nil {
+		w.stop(err)
+		return
+	}
+
+	node := task.node
+	depth := walkDepths[node.Type()]
+
+	// Files are listed with their container, so fetch it in full if needed
+	if !task.full && w.wantFiles() && node.File == nil {
+		if err := w.fetch(node); err != nil {
+			w.handle(node, err)
+			return
+		}
+	}
+
+	if node.Type() != "" && (len(w.types) == 0 || w.types[node.Type()]) {
+		if w.handle(node, nil) {
+			return
+		}
+	}
+
+	if depth >= w.maxDepth || node.File != nil {
+		return
+	}
+
+	children, err := w.children(node)
+	if err != nil {
+		w.handle(node, err)
+		return
+	}
+	w.push(children...)
+}
+
+// handle calls fn, and reports whether the node's children should be skipped.
+func (w *walker) handle(node *ResolvedPath, err error) bool {
+	result := w.fn(node, err)
+	if result == nil {
+		return err != nil
+	}
+	if result != SkipSubtree {
+		w.stop(result)
+	}
+	return true
+}
+
+// wantFiles reports whether the walk includes files.
+func (w *walker) wantFiles() bool {
+	return w.maxDepth >= walkDepths["file"]
+}
+
+// fetch replaces the deepest container of a node with the full version of itself.
+func (w *walker) fetch(node *ResolvedPath) error {
+	switch node.Type() {
+	case "project":
+		project, _, err := w.c.GetProject(node.Project.Id)
+		if err != nil {
+			return err
+		}
+		node.Project = project
+	case "session":
+		session, _, err := w.c.GetSession(node.Session.Id)
+		if err != nil {
+			return err
+		}
+		node.Session = session
+	case "acquisition":
+		acquisition, _, err := w.c.GetAcquisition(node.Acquisition.Id)
+		if err != nil {
+			return err
+		}
+		node.Acquisition = acquisition
+	}
+	return nil
+}
+
+// children lists the child containers and files of a node.
+func (w *walker) children(node *ResolvedPath) ([]*walkTask, error) {
+	var tasks []*walkTask
+	child := func(name string, set func(*ResolvedPath)) {
+		x := *node
+		x.Path = append(append([]string{}, node.Path...), name)
+		set(&x)
+		tasks = append(tasks, &walkTask{node: &x})
+	}
+
+	var files []*File
+	childDepth := walkDepths[node.Type()] + 1
+
+	switch node.Type() {
+	case "":
+		groups, _, err := w.c.GetAllGroups()
+		if err != nil {
+			return nil, err
+		}
+		for _, x := range groups {
+			group := x
+			child(group.Id, func(n *ResolvedPath) { n.Group = group })
+		}
+
+	case "group":
+		projects, err := w.groupProjects(node.Group.Id)
+		if err != nil {
+			return nil, err
+		}
+		for _, x := range projects {
+			project := x
+			child(project.Name, func(n *ResolvedPath) { n.Project = project })
+		}
+
+	case "project":
+		files = node.Project.Files
+		if childDepth <= w.maxDepth {
+			sessions, _, err := w.c.GetProjectSessions(node.Project.Id)
+			if err != nil {
+				return nil, err
+			}
+			for _, x := range sessions {
+				session := x
+				child(session.Name, func(n *ResolvedPath) { n.Session = session })
+			}
+		}
+
+	case "session":
+		files = node.Session.Files
+		if childDepth <= w.maxDepth {
+			acquisitions, _, err := w.c.GetSessionAcquisitions(node.Session.Id)
+			if err != nil {
+				return nil, err
+			}
+			for _, x := range acquisitions {
+				acquisition := x
+				child(acquisition.Name, func(n *ResolvedPath) { n.Acquisition = acquisition })
+			}
+		}
+
+	case "acquisition":
+		files = node.Acquisition.Files
+	}
+
+	if w.wantFiles() {
+		for _, x := range files {
+			file := x
+			child(file.Name, func(n *ResolvedPath) { n.File = file })
+		}
+	}
+
+	return tasks, nil
+}
+
+// groupProjects returns the projects of a group.
+// There is no route to list a group's projects, so all projects are listed once and shared by every group.
+func (w *walker) groupProjects(groupId string) ([]*Project, error) {
+	w.projectsOnce.Do(func() {
+		var projects []*Project
+		projects, _, w.projectsErr = w.c.GetAllProjects()
+
+		w.projects = map[string][]*Project{}
+		for _, x := range projects {
+			w.projects[x.GroupId] = append(w.projects[x.GroupId], x)
+		}
+	})
+	return w.projects[groupId], w.projectsErr
+}
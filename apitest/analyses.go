@@ -0,0 +1,192 @@
+package apitest
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"flywheel.io/sdk/api"
+)
+
+// Analysis file groups, by route segment: inputs, and outputs which the real API calls files.
+var analysisGroups = map[string]bool{
+	"inputs": true,
+	"files":  true,
+}
+
+// routeAnalyses handles the analysis routes of a container, which all begin <kind>/<id>/analyses.
+func (s *Server) routeAnalyses(w http.ResponseWriter, kind string, r *request) {
+	var result interface{}
+	var err error
+
+	id := r.path[1]
+
+	switch {
+	case kind == "groups":
+		err = errNotFound()
+
+	case len(r.path) == 3 && r.method == "GET":
+		if _, err = s.get(kind, id); err == nil {
+			result = s.list("analyses", analysisOf(kind, id))
+		}
+
+	case len(r.path) == 3 && r.method == "POST":
+		result, err = s.addAnalysis(kind, id, r)
+
+	case len(r.path) == 4 && r.method == "GET":
+		result, err = s.getAnalysis(kind, id, r.path[3])
+
+	case len(r.path) == 4 && r.method == "DELETE":
+		if _, err = s.getAnalysis(kind, id, r.path[3]); err == nil {
+			s.deleteAnalysis(r.path[3])
+			result = deletedResponse(1)
+		}
+
+	case len(r.path) == 5 && r.path[4] == "notes" && r.method == "POST":
+		if _, err = s.getAnalysis(kind, id, r.path[3]); err == nil {
+			result, err = s.addNote("analyses", r.path[3], r)
+		}
+
+	case len(r.path) == 5 && analysisGroups[r.path[4]] && r.method == "POST":
+		result, err = s.uploadAnalysisFiles(kind, id, r.path[3], r.path[4], r)
+
+	case len(r.path) == 6 && analysisGroups[r.path[4]] && r.method == "GET":
+		err = s.downloadAnalysisFile(w, kind, id, r.path[3], r.path[4], r.path[5])
+		if err == nil {
+			return
+		}
+
+	default:
+		err = errNotFound()
+	}
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, 200, result)
+}
+
+// analysisOf returns a filter for the analyses attached to a container.
+func analysisOf(kind, id string) func(document) bool {
+	return func(doc document) bool {
+		parent, _ := doc["parent"].(map[string]interface{})
+		return parent["type"] == strings.TrimSuffix(kind, "s") && parent["id"] == id
+	}
+}
+
+// getAnalysis returns an analysis, which must be attached to the given container.
+func (s *Server) getAnalysis(kind, id, analysisId string) (document, error) {
+	if _, err := s.get(kind, id); err != nil {
+		return nil, err
+	}
+
+	analysis, err := s.get("analyses", analysisId)
+	if err != nil || !analysisOf(kind, id)(analysis) {
+		return nil, errMissing("analyses", analysisId)
+	}
+	return analysis, nil
+}
+
+// addAnalysis creates an analysis on a container. With the job query parameter,
+// the body also describes a job, which is created with the analysis as its destination.
+func (s *Server) addAnalysis(kind, id string, r *request) (interface{}, error) {
+	if _, err := s.get(kind, id); err != nil {
+		return nil, err
+	}
+
+	var analysis, job document
+	if r.query.Get("job") == "true" {
+		var body struct {
+			Analysis document `json:"analysis"`
+			Job      document `json:"job"`
+		}
+		if err := r.decode(&body); err != nil {
+			return nil, err
+		}
+		if body.Job == nil {
+			return nil, errBadRequest("Job is required")
+		}
+		analysis, job = body.Analysis, body.Job
+	} else {
+		if err := r.decode(&analysis); err != nil {
+			return nil, err
+		}
+	}
+
+	if analysis == nil || analysis["label"] == nil {
+		return nil, errBadRequest("Analysis label is required")
+	}
+
+	analysisId := newId()
+	now := s.now()
+	analysis["parent"] = map[string]interface{}{"type": strings.TrimSuffix(kind, "s"), "id": id}
+	analysis["user"] = r.user
+	analysis["created"] = now
+	analysis["modified"] = now
+	delete(analysis, "inputs")
+	delete(analysis, "files")
+	delete(analysis, "job")
+
+	// The analysis must exist for the job's destination to be valid
+	s.insert("analyses", analysisId, analysis)
+
+	if job != nil {
+		job["destination"] = map[string]interface{}{"type": "analysis", "id": analysisId}
+		created, err := s.addJob(job, r.user)
+		if err != nil {
+			s.remove("analyses", analysisId)
+			return nil, err
+		}
+		analysis["job"] = created["id"]
+	}
+
+	return idResponse(analysisId), nil
+}
+
+// deleteAnalysis removes an analysis and its files.
+func (s *Server) deleteAnalysis(analysisId string) {
+	s.remove("analyses", analysisId)
+
+	prefix := fileKey("analyses", analysisId, "")
+	for key := range s.blobs {
+		if strings.HasPrefix(key, prefix) {
+			delete(s.blobs, key)
+		}
+	}
+}
+
+// analysisFileKey identifies the contents of an analysis input or output in the blob store.
+func analysisFileKey(analysisId, group, name string) string {
+	return fileKey("analyses", analysisId, group+"/"+name)
+}
+
+// uploadAnalysisFiles stores each file of a multipart upload as an input or output of an analysis.
+func (s *Server) uploadAnalysisFiles(kind, id, analysisId, group string, r *request) (interface{}, error) {
+	analysis, err := s.getAnalysis(kind, id, analysisId)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.storeFiles(analysis, group, func(name string) string { return analysisFileKey(analysisId, group, name) }, r)
+}
+
+// downloadAnalysisFile writes the contents of an input or output of an analysis.
+func (s *Server) downloadAnalysisFile(w http.ResponseWriter, kind, id, analysisId, group, name string) error {
+	analysis, err := s.getAnalysis(kind, id, analysisId)
+	if err != nil {
+		return err
+	}
+
+	file, _ := findFileIn(analysis, group, name)
+	if file == nil {
+		return &api.Error{StatusCode: 404, Message: "File " + name + " not found in analysis " + analysisId}
+	}
+
+	content := s.blobs[analysisFileKey(analysisId, group, name)]
+	w.Header().Set("Content-Type", file["mimetype"].(string))
+	w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+	w.WriteHeader(200)
+	w.Write(content)
+	return nil
+}
@@ -0,0 +1,729 @@
+package apitest
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"encoding/hex"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"flywheel.io/sdk/api"
+)
+
+// Parent of each kind of container in the hierarchy, named by the field that references it.
+var parentField = map[string]struct{ kind, field string }{
+	"projects":     {"groups", "group"},
+	"sessions":     {"projects", "project"},
+	"acquisitions": {"sessions", "session"},
+}
+
+// Fields that the real API leaves out of container listings.
+var listOmits = map[string][]string{
+	"projects":     {"files", "notes", "tags", "info"},
+	"sessions":     {"files", "notes", "tags", "info"},
+	"acquisitions": {"files", "notes", "tags", "info"},
+	"collections":  {"files", "notes", "info"},
+}
+
+// File types, as classified by the real API from file extensions.
+var fileTypes = map[string]string{
+	".txt":  "text",
+	".csv":  "tabular data",
+	".tsv":  "tabular data",
+	".json": "json",
+	".dcm":  "dicom",
+	".nii":  "nifti",
+	".zip":  "archive",
+	".tar":  "archive",
+	".gz":   "archive",
+	".pdf":  "pdf",
+	".png":  "image",
+	".jpg":  "image",
+}
+
+// routeContainer handles the routes shared by groups, projects, sessions, acquisitions and collections.
+func (s *Server) routeContainer(w http.ResponseWriter, r *request) {
+	var result interface{}
+	var err error
+
+	kind := r.path[0]
+
+	switch {
+	case len(r.path) == 1:
+		switch r.method {
+		case "GET":
+			result, err = listQuery(kind, s.list(kind, nil), r.query)
+		case "POST":
+			result, err = s.addContainer(kind, r)
+		default:
+			err = errMethod(r)
+		}
+
+	case len(r.path) == 2:
+		switch r.method {
+		case "GET":
+			result, err = s.get(kind, r.path[1])
+		case "PUT":
+			result, err = s.modifyContainer(kind, r.path[1], r)
+		case "DELETE":
+			result, err = s.deleteContainer(kind, r.path[1])
+		default:
+			err = errMethod(r)
+		}
+
+	case len(r.path) >= 3 && r.path[2] == "analyses":
+		s.routeAnalyses(w, kind, r)
+		return
+
+	case len(r.path) >= 3 && r.path[2] == "permissions":
+		result, err = s.routePermissions(kind, r)
+
+	case len(r.path) >= 3 && r.path[2] == "files":
+		if len(r.path) == 4 && r.method == "GET" {
+			err = s.downloadFile(w, kind, r.path[1], r.path[3], r)
+			if err == nil {
+				return
+			}
+			break
+		}
+		result, err = s.routeFiles(kind, r)
+
+	case len(r.path) >= 3 && r.path[2] == "notes":
+		result, err = s.routeNotes(kind, r)
+
+	case len(r.path) == 3 && r.method == "POST" && r.path[2] == "info":
+		result, err = s.modifyContainerInfo(kind, r.path[1], r)
+
+	case len(r.path) >= 3 && r.path[2] == "tags":
+		var doc document
+		if doc, err = s.get(kind, r.path[1]); err == nil {
+			result, err = s.routeTags(doc, doc, r.path[3:], r)
+		}
+
+	case len(r.path) == 3 && r.method == "GET":
+		result, err = s.listChildren(kind, r.path[1], r.path[2], r)
+
+	default:
+		err = errNotFound()
+	}
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, 200, result)
+}
+
+// project returns a container as it appears in listings.
+func project(kind string, doc document) document {
+	result := copyDocument(doc)
+	for _, field := range listOmits[kind] {
+		delete(result, field)
+	}
+
+	if subject, ok := result["subject"].(map[string]interface{}); ok {
+		result["subject"] = map[string]interface{}{
+			"_id":  subject["_id"],
+			"code": subject["code"],
+		}
+	}
+	return result
+}
+
+// listContainers returns the containers of a kind that match a filter, as they appear in listings.
+func (s *Server) listContainers(kind string, filter func(document) bool) []document {
+	result := s.list(kind, filter)
+	for i, doc := range result {
+		result[i] = project(kind, doc)
+	}
+	return result
+}
+
+// listChildren handles routes that list the contents of a container, such as a project's sessions.
+func (s *Server) listChildren(kind, id, child string, r *request) (interface{}, error) {
+	if _, err := s.get(kind, id); err != nil {
+		return nil, err
+	}
+
+	switch kind + "/" + child {
+	case "groups/projects":
+		return s.listContainers("projects", fieldEquals("group", id)), nil
+
+	case "projects/sessions":
+		return s.listContainers("sessions", fieldEquals("project", id)), nil
+
+	case "sessions/acquisitions":
+		return s.listContainers("acquisitions", fieldEquals("session", id)), nil
+
+	case "collections/acquisitions":
+		session := r.query.Get("session")
+		return s.listContainers("acquisitions", func(doc document) bool {
+			return s.inCollection(id, doc["_id"].(string)) && (session == "" || doc["session"] == session)
+		}), nil
+
+	case "collections/sessions":
+		return s.listContainers("sessions", func(doc document) bool {
+			for _, acquisition := range s.collections[id] {
+				if s.docs["acquisitions"][acquisition]["session"] == doc["_id"] {
+					return true
+				}
+			}
+			return false
+		}), nil
+	}
+
+	return nil, errNotFound()
+}
+
+// fieldEquals returns a filter for documents whose field has a value.
+func fieldEquals(field, value string) func(document) bool {
+	return func(doc document) bool {
+		return doc[field] == value
+	}
+}
+
+// inCollection reports whether a collection contains an acquisition.
+func (s *Server) inCollection(collection, acquisition string) bool {
+	for _, id := range s.collections[collection] {
+		if id == acquisition {
+			return true
+		}
+	}
+	return false
+}
+
+// addContainer creates a container, checking its parent and inheriting the parent's permissions.
+func (s *Server) addContainer(kind string, r *request) (interface{}, error) {
+	var doc document
+	if err := r.decode(&doc); err != nil {
+		return nil, err
+	}
+
+	id := newId()
+	permissions := []interface{}{
+		map[string]interface{}{"_id": r.user, "access": "admin"},
+	}
+
+	switch kind {
+	case "groups":
+		id, _ = doc["_id"].(string)
+		if id == "" {
+			return nil, errBadRequest("Group Id is required")
+		}
+		if _, exists := s.docs[kind][id]; exists {
+			return nil, &api.Error{StatusCode: 409, Message: "Group " + id + " already exists"}
+		}
+
+	case "collections":
+		doc["curator"] = r.user
+
+	default:
+		parent := parentField[kind]
+		parentId, _ := doc[parent.field].(string)
+		if parentId == "" {
+			return nil, errBadRequest("Field " + parent.field + " is required")
+		}
+		parentDoc, err := s.get(parent.kind, parentId)
+		if err != nil {
+			return nil, err
+		}
+		if existing, ok := parentDoc["permissions"].([]interface{}); ok {
+			permissions = copyPermissions(existing)
+		}
+
+		if kind == "sessions" {
+			doc["group"] = parentDoc["group"]
+			if subject, ok := doc["subject"].(map[string]interface{}); ok && subject["_id"] == nil {
+				subject["_id"] = newId()
+			}
+		}
+	}
+
+	now := s.now()
+	doc["created"] = now
+	doc["modified"] = now
+	doc["permissions"] = permissions
+
+	s.insert(kind, id, doc)
+	return idResponse(id), nil
+}
+
+// modifyContainer applies a modification, or for collections, may instead change their contents.
+func (s *Server) modifyContainer(kind, id string, r *request) (interface{}, error) {
+	doc, err := s.get(kind, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var mod document
+	if err := r.decode(&mod); err != nil {
+		return nil, err
+	}
+
+	if contents, ok := mod["contents"].(map[string]interface{}); ok && kind == "collections" {
+		err = s.modifyCollectionContents(id, contents)
+		if err != nil {
+			return nil, err
+		}
+		doc["modified"] = s.now()
+		return modifiedResponse(1), nil
+	}
+
+	s.merge(doc, mod)
+	return modifiedResponse(1), nil
+}
+
+// modifyContainerInfo sets, replaces or deletes fields of a container's info.
+func (s *Server) modifyContainerInfo(kind, id string, r *request) (interface{}, error) {
+	doc, err := s.get(kind, id)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := modifyInfo(doc["info"], r)
+	if err != nil {
+		return nil, err
+	}
+	doc["info"] = info
+	doc["modified"] = s.now()
+	return modifiedResponse(1), nil
+}
+
+// modifyCollectionContents adds or removes acquisitions from a collection.
+// Adding a session or project adds all of its acquisitions.
+func (s *Server) modifyCollectionContents(id string, contents map[string]interface{}) error {
+	operation, _ := contents["operation"].(string)
+	if operation != "add" && operation != "remove" {
+		return errBadRequest("Unknown collection operation " + strconv.Quote(operation))
+	}
+
+	nodes, _ := contents["nodes"].([]interface{})
+	for _, raw := range nodes {
+		node, _ := raw.(map[string]interface{})
+		nodeId, _ := node["_id"].(string)
+		level, _ := node["level"].(string)
+
+		var acquisitions []string
+		switch level {
+		case "acquisition":
+			if _, err := s.get("acquisitions", nodeId); err != nil {
+				return err
+			}
+			acquisitions = []string{nodeId}
+
+		case "session", "project":
+			kind := level + "s"
+			if _, err := s.get(kind, nodeId); err != nil {
+				return err
+			}
+			for _, descendant := range s.descendants(kind, nodeId) {
+				if descendant.kind == "acquisitions" {
+					acquisitions = append(acquisitions, descendant.id)
+				}
+			}
+
+		default:
+			return errBadRequest("Unknown collection node level " + strconv.Quote(level))
+		}
+
+		for _, acquisition := range acquisitions {
+			if operation == "add" && !s.inCollection(id, acquisition) {
+				s.collections[id] = append(s.collections[id], acquisition)
+			} else if operation == "remove" {
+				s.removeFromCollections(id, acquisition)
+			}
+		}
+	}
+
+	return nil
+}
+
+// removeFromCollections removes an acquisition from a collection, or from every collection if collection is empty.
+func (s *Server) removeFromCollections(collection, acquisition string) {
+	for id, acquisitions := range s.collections {
+		if collection != "" && id != collection {
+			continue
+		}
+		kept := acquisitions[:0]
+		for _, x := range acquisitions {
+			if x != acquisition {
+				kept = append(kept, x)
+			}
+		}
+		s.collections[id] = kept
+	}
+}
+
+// containerKind returns the kind a container type is stored as, such as "sessions" for "session".
+func containerKind(containerType string) string {
+	if containerType == "analysis" {
+		return "analyses"
+	}
+	return containerType + "s"
+}
+
+// containerRef identifies a stored container.
+type containerRef struct {
+	kind, id string
+}
+
+// descendants returns a container and everything beneath it in the hierarchy, parents before children.
+func (s *Server) descendants(kind, id string) []containerRef {
+	result := []containerRef{{kind, id}}
+
+	for childKind, parent := range parentField {
+		if parent.kind != kind {
+			continue
+		}
+		for _, child := range s.list(childKind, fieldEquals(parent.field, id)) {
+			result = append(result, s.descendants(childKind, child["_id"].(string))...)
+		}
+	}
+	return result
+}
+
+// deleteContainer deletes a container along with everything beneath it.
+func (s *Server) deleteContainer(kind, id string) (interface{}, error) {
+	if _, err := s.get(kind, id); err != nil {
+		return nil, err
+	}
+
+	for _, ref := range s.descendants(kind, id) {
+		s.remove(ref.kind, ref.id)
+
+		prefix := fileKey(ref.kind, ref.id, "")
+		for key := range s.blobs {
+			if strings.HasPrefix(key, prefix) {
+				delete(s.blobs, key)
+			}
+		}
+
+		for _, analysis := range s.list("analyses", analysisOf(ref.kind, ref.id)) {
+			s.deleteAnalysis(analysis["_id"].(string))
+		}
+
+		switch ref.kind {
+		case "acquisitions":
+			s.removeFromCollections("", ref.id)
+		case "collections":
+			delete(s.collections, ref.id)
+		}
+	}
+
+	return deletedResponse(1), nil
+}
+
+// appendField appends a value to a list field of a document.
+func appendField(doc document, field string, value interface{}) {
+	list, _ := doc[field].([]interface{})
+	doc[field] = append(list, value)
+}
+
+// fileKey identifies a file's contents in the blob store.
+func fileKey(kind, id, name string) string {
+	return kind + "/" + id + "/" + name
+}
+
+// findFile returns a container's file of the given name, and its index.
+func findFile(doc document, name string) (map[string]interface{}, int) {
+	return findFileIn(doc, "files", name)
+}
+
+// findFileIn returns a file of the given name from a list field of a document, and its index.
+func findFileIn(doc document, field, name string) (map[string]interface{}, int) {
+	files, _ := doc[field].([]interface{})
+	for i, raw := range files {
+		file, _ := raw.(map[string]interface{})
+		if file["name"] == name {
+			return file, i
+		}
+	}
+	return nil, -1
+}
+
+// getFile returns a container and one of its files, or a 404 error.
+func (s *Server) getFile(kind, id, name string) (document, map[string]interface{}, error) {
+	doc, err := s.get(kind, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	file, _ := findFile(doc, name)
+	if file == nil {
+		return nil, nil, &api.Error{StatusCode: 404, Message: "File " + name + " not found in " + strings.TrimSuffix(kind, "s") + " " + id}
+	}
+	return doc, file, nil
+}
+
+// routeFiles handles the file routes other than downloads.
+func (s *Server) routeFiles(kind string, r *request) (interface{}, error) {
+	id := r.path[1]
+
+	switch {
+	case len(r.path) == 3 && r.method == "POST":
+		return s.uploadFiles(kind, id, r)
+
+	case len(r.path) == 4 && r.method == "PUT":
+		return s.modifyFile(kind, id, r.path[3], r)
+
+	case len(r.path) == 4 && r.method == "DELETE":
+		return s.deleteFile(kind, id, r.path[3])
+
+	case len(r.path) == 5 && r.path[4] == "info" && r.method == "POST":
+		doc, file, err := s.getFile(kind, id, r.path[3])
+		if err != nil {
+			return nil, err
+		}
+		info, err := modifyInfo(file["info"], r)
+		if err != nil {
+			return nil, err
+		}
+		file["info"] = info
+		file["modified"] = s.now()
+		doc["modified"] = file["modified"]
+		return modifiedResponse(1), nil
+
+	case len(r.path) >= 5 && r.path[4] == "tags":
+		doc, file, err := s.getFile(kind, id, r.path[3])
+		if err != nil {
+			return nil, err
+		}
+		return s.routeTags(file, doc, r.path[5:], r)
+	}
+
+	return nil, errNotFound()
+}
+
+// uploadFiles stores each file of a multipart upload on a container, replacing any of the same name.
+func (s *Server) uploadFiles(kind, id string, r *request) (interface{}, error) {
+	doc, err := s.get(kind, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.storeFiles(doc, "files", func(name string) string { return fileKey(kind, id, name) }, r)
+}
+
+// storeFiles stores each file of a multipart upload in a list field of a document, replacing any of the same name.
+// Contents are stored in the blob store under the key given for each name.
+func (s *Server) storeFiles(doc document, field string, key func(string) string, r *request) (interface{}, error) {
+	uploads, err := readUploads(r)
+	if err != nil {
+		return nil, err
+	}
+
+	uploaded := []interface{}{}
+	for _, upload := range uploads {
+		file := s.storeFile(doc, field, key, upload, r.user)
+		uploaded = append(uploaded, uploadedResponse(file))
+	}
+
+	doc["modified"] = s.now()
+	return uploaded, nil
+}
+
+// storeFile stores one uploaded file in a list field of a document, replacing any of the same name, and returns it.
+func (s *Server) storeFile(doc document, field string, key func(string) string, upload upload, user string) map[string]interface{} {
+	file := s.newFile(upload.name, upload.content, user)
+	if _, i := findFileIn(doc, field, file["name"].(string)); i >= 0 {
+		doc[field].([]interface{})[i] = file
+	} else {
+		appendField(doc, field, file)
+	}
+	s.blobs[key(file["name"].(string))] = upload.content
+	return file
+}
+
+// upload is a file read from a multipart upload.
+type upload struct {
+	name    string
+	content []byte
+}
+
+// readUploads returns the files of a multipart upload, of which there must be at least one.
+func readUploads(r *request) ([]upload, error) {
+	_, params, err := mime.ParseMediaType(r.header.Get("Content-Type"))
+	if err != nil || params["boundary"] == "" {
+		return nil, errBadRequest("Upload must be multipart/form-data")
+	}
+
+	reader := multipart.NewReader(bytes.NewReader(r.body), params["boundary"])
+	var uploads []upload
+
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			break
+		}
+		if part.FileName() == "" {
+			continue
+		}
+
+		content, err := ioutil.ReadAll(part)
+		if err != nil {
+			return nil, errBadRequest("Reading upload failed: " + err.Error())
+		}
+		uploads = append(uploads, upload{part.FileName(), content})
+	}
+
+	if len(uploads) == 0 {
+		return nil, errBadRequest("No files were uploaded")
+	}
+	return uploads, nil
+}
+
+// uploadedResponse describes a stored file in the response to an upload.
+func uploadedResponse(file map[string]interface{}) interface{} {
+	return map[string]interface{}{
+		"name": file["name"],
+		"size": file["size"],
+		"hash": file["hash"],
+	}
+}
+
+// deleteFile removes a file and its contents from a container.
+func (s *Server) deleteFile(kind, id, name string) (interface{}, error) {
+	doc, _, err := s.getFile(kind, id, name)
+	if err != nil {
+		return nil, err
+	}
+
+	_, i := findFile(doc, name)
+	files := doc["files"].([]interface{})
+	doc["files"] = append(files[:i:i], files[i+1:]...)
+	delete(s.blobs, fileKey(kind, id, name))
+
+	doc["modified"] = s.now()
+	return deletedResponse(1), nil
+}
+
+// newFile describes an uploaded file, classifying it by extension as the real API does.
+func (s *Server) newFile(name string, content []byte, user string) map[string]interface{} {
+	ext := strings.ToLower(path.Ext(name))
+
+	mimetype, _, _ := mime.ParseMediaType(mime.TypeByExtension(ext))
+	if mimetype == "" {
+		mimetype = "application/octet-stream"
+	}
+
+	sum := sha512.Sum384(content)
+	now := s.now()
+
+	file := map[string]interface{}{
+		"name":     path.Base(name),
+		"size":     len(content),
+		"mimetype": mimetype,
+		"hash":     "v0-sha384-" + hex.EncodeToString(sum[:]),
+		"origin":   map[string]interface{}{"type": "user", "id": user},
+		"info":     map[string]interface{}{},
+		"created":  now,
+		"modified": now,
+	}
+	if fileType, ok := fileTypes[ext]; ok {
+		file["type"] = fileType
+	}
+	return file
+}
+
+// downloadFile writes a file's contents, or the part of them asked for by a Range header.
+func (s *Server) downloadFile(w http.ResponseWriter, kind, id, name string, r *request) error {
+	_, file, err := s.getFile(kind, id, name)
+	if err != nil {
+		return err
+	}
+
+	content := s.blobs[fileKey(kind, id, name)]
+	w.Header().Set("Content-Type", file["mimetype"].(string))
+	http.ServeContent(w, &http.Request{Method: r.method, Header: r.header}, "", time.Time{}, bytes.NewReader(content))
+	return nil
+}
+
+// modifyFile sets a file's attributes.
+func (s *Server) modifyFile(kind, id, name string, r *request) (interface{}, error) {
+	doc, file, err := s.getFile(kind, id, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields *api.FileFields
+	if err := r.decode(&fields); err != nil {
+		return nil, err
+	}
+
+	for k, v := range toDocument(fields) {
+		file[k] = v
+	}
+	file["modified"] = s.now()
+	doc["modified"] = file["modified"]
+
+	return &api.ModifiedAndJobsResponse{ModifiedCount: 1}, nil
+}
+
+// modifyInfo applies an info modification request, of the form sent by the api package:
+// one of "set" or "replace", holding a map, or "delete", holding a list of keys.
+// Keys to set or delete may be dotted paths to nested fields, as the real API's use of MongoDB allows.
+func modifyInfo(existing interface{}, r *request) (map[string]interface{}, error) {
+	info, _ := existing.(map[string]interface{})
+	if info == nil {
+		info = map[string]interface{}{}
+	}
+
+	var body struct {
+		Set     map[string]interface{} `json:"set"`
+		Replace map[string]interface{} `json:"replace"`
+		Delete  []string               `json:"delete"`
+	}
+	if err := r.decode(&body); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case body.Replace != nil:
+		info = body.Replace
+	case body.Set != nil:
+		for k, v := range body.Set {
+			setInfoPath(info, k, v)
+		}
+	case body.Delete != nil:
+		for _, k := range body.Delete {
+			deleteInfoPath(info, k)
+		}
+	default:
+		return nil, errBadRequest("Info modification must set, replace or delete")
+	}
+
+	return info, nil
+}
+
+// setInfoPath sets a possibly dotted key of info, creating or replacing intermediate fields with maps as needed.
+func setInfoPath(info map[string]interface{}, key string, value interface{}) {
+	parts := strings.Split(key, ".")
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := info[part].(map[string]interface{})
+		if !ok {
+			next = map[string]interface{}{}
+			info[part] = next
+		}
+		info = next
+	}
+	info[parts[len(parts)-1]] = value
+}
+
+// deleteInfoPath removes a possibly dotted key of info, if present.
+func deleteInfoPath(info map[string]interface{}, key string) {
+	parts := strings.Split(key, ".")
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := info[part].(map[string]interface{})
+		if !ok {
+			return
+		}
+		info = next
+	}
+	delete(info, parts[len(parts)-1])
+}
@@ -0,0 +1,262 @@
+package apitest
+
+import (
+	"archive/tar"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"flywheel.io/sdk/api"
+)
+
+// ticketEntry is one file of a download ticket, as it is named in the archive.
+type ticketEntry struct {
+	path    string
+	content []byte
+}
+
+// routeDownload handles the bulk download routes.
+// POST download creates a ticket for a set of containers, or with ?bulk=true for a list of files,
+// and GET download?ticket=<ticket> streams the files of a ticket as a tar archive.
+//
+// Files are named in the archive by the labels of their containers, as
+// flywheel/<group>/<project>/<subject>/<session>/<acquisition>/<file>, down to the container each file is in.
+// The contents of a ticket are fixed when it is created. Unlike the real API, tickets do not expire.
+func (s *Server) routeDownload(w http.ResponseWriter, r *request) {
+	var result interface{}
+	var err error
+
+	switch {
+	case len(r.path) != 1:
+		err = errNotFound()
+
+	case r.method == "POST" && r.query.Get("bulk") == "true":
+		result, err = s.createBulkTicket(r)
+
+	case r.method == "POST":
+		result, err = s.createTicket(r)
+
+	case r.method == "GET":
+		err = s.downloadTicket(w, r.query.Get("ticket"))
+		if err == nil {
+			return
+		}
+
+	default:
+		err = errMethod(r)
+	}
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, 200, result)
+}
+
+// createTicket creates a ticket for the files of a set of containers and everything beneath them, less those filtered out.
+func (s *Server) createTicket(r *request) (interface{}, error) {
+	var body *api.BulkDownloadRequest
+	if err := r.decode(&body); err != nil {
+		return nil, err
+	}
+	if body == nil || len(body.Nodes) == 0 {
+		return nil, errBadRequest("Download needs at least one node")
+	}
+
+	var entries []ticketEntry
+	for _, node := range body.Nodes {
+		kind := containerKind(node.Level)
+		if _, ok := parentField[kind]; !ok {
+			return nil, errBadRequest("Cannot download containers of level " + strconv.Quote(node.Level))
+		}
+		if _, err := s.get(kind, node.Id); err != nil {
+			return nil, err
+		}
+
+		for _, ref := range s.descendants(kind, node.Id) {
+			doc := s.docs[ref.kind][ref.id]
+			files, _ := doc["files"].([]interface{})
+			for _, raw := range files {
+				file := raw.(map[string]interface{})
+				if passesFilters(file, body.Filters) {
+					entries = append(entries, s.ticketEntry(ref.kind, ref.id, file["name"].(string)))
+				}
+			}
+		}
+	}
+
+	return s.issueTicket(entries)
+}
+
+// createBulkTicket creates a ticket for a list of files.
+func (s *Server) createBulkTicket(r *request) (interface{}, error) {
+	var body struct {
+		Files []*api.BulkDownloadFile `json:"files"`
+	}
+	if err := r.decode(&body); err != nil {
+		return nil, err
+	}
+
+	var entries []ticketEntry
+	for _, x := range body.Files {
+		kind := containerKind(x.ContainerType)
+		if _, _, err := s.getFile(kind, x.ContainerId, x.Name); err != nil {
+			return nil, err
+		}
+		entries = append(entries, s.ticketEntry(kind, x.ContainerId, x.Name))
+	}
+
+	return s.issueTicket(entries)
+}
+
+// issueTicket stores a ticket for a set of files, of which there must be at least one.
+func (s *Server) issueTicket(entries []ticketEntry) (interface{}, error) {
+	if len(entries) == 0 {
+		return nil, &api.Error{StatusCode: 404, Message: "No files matching the given filter could be found"}
+	}
+
+	size := 0
+	for _, entry := range entries {
+		size += len(entry.content)
+	}
+
+	ticket := newId()
+	s.insert("tickets", ticket, document{
+		"entries": entries,
+		"created": s.now(),
+	})
+	return &api.BulkDownloadTicket{Ticket: ticket, FileCount: len(entries), Size: size}, nil
+}
+
+// passesFilters reports whether a file passes every filter of a download.
+func passesFilters(file map[string]interface{}, filters []*api.BulkDownloadFilter) bool {
+	tags := stringList(file["tags"])
+	fileType, _ := file["type"].(string)
+
+	for _, filter := range filters {
+		if !filterMatches(filter.Tags, tags) || !filterMatches(filter.Types, []string{fileType}) {
+			return false
+		}
+	}
+	return true
+}
+
+// filterMatches reports whether values include every value a filter requires, and none that it excludes.
+// A nil filter matches anything.
+func filterMatches(filter *api.BulkDownloadFilterValue, values []string) bool {
+	if filter == nil {
+		return true
+	}
+
+	has := map[string]bool{}
+	for _, x := range values {
+		has[x] = true
+	}
+	for _, x := range filter.Plus {
+		if !has[x] {
+			return false
+		}
+	}
+	for _, x := range filter.Minus {
+		if has[x] {
+			return false
+		}
+	}
+	return true
+}
+
+// stringList converts a stored list of strings.
+func stringList(raw interface{}) []string {
+	list, _ := raw.([]interface{})
+	result := make([]string, 0, len(list))
+	for _, x := range list {
+		if str, ok := x.(string); ok {
+			result = append(result, str)
+		}
+	}
+	return result
+}
+
+// ticketEntry names a container's file for an archive, and takes its contents.
+func (s *Server) ticketEntry(kind, id, name string) ticketEntry {
+	var labels []string
+	for k, i := kind, id; i != ""; {
+		doc := s.docs[k][i]
+		switch k {
+		case "groups":
+			labels = append(labels, i)
+		case "sessions":
+			label, _ := doc["label"].(string)
+			subject, _ := doc["subject"].(map[string]interface{})
+			code, _ := subject["code"].(string)
+			labels = append(labels, archiveName(label, i), archiveName(code, "unknown"))
+		default:
+			label, _ := doc["label"].(string)
+			labels = append(labels, archiveName(label, i))
+		}
+
+		parent, ok := parentField[k]
+		if !ok {
+			break
+		}
+		k = parent.kind
+		i, _ = doc[parent.field].(string)
+	}
+
+	segments := []string{"flywheel"}
+	for j := len(labels) - 1; j >= 0; j-- {
+		segments = append(segments, labels[j])
+	}
+	segments = append(segments, name)
+
+	return ticketEntry{
+		path:    path.Join(segments...),
+		content: s.blobs[fileKey(kind, id, name)],
+	}
+}
+
+// archiveName returns a label as a path segment, or fallback if it has none.
+func archiveName(label, fallback string) string {
+	if label == "" {
+		return fallback
+	}
+	return strings.Replace(label, "/", "_", -1)
+}
+
+// downloadTicket writes the files of a ticket as a tar archive.
+func (s *Server) downloadTicket(w http.ResponseWriter, ticket string) error {
+	if ticket == "" {
+		return errBadRequest("Ticket is required")
+	}
+	doc, err := s.get("tickets", ticket)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", "attachment; filename=flywheel.tar")
+	w.WriteHeader(200)
+
+	archive := tar.NewWriter(w)
+	for _, entry := range doc["entries"].([]ticketEntry) {
+		header := &tar.Header{
+			Name:     entry.path,
+			Mode:     0644,
+			Size:     int64(len(entry.content)),
+			ModTime:  doc["created"].(time.Time),
+			Typeflag: tar.TypeReg,
+		}
+		if archive.WriteHeader(header) != nil {
+			break
+		}
+		if _, err := archive.Write(entry.content); err != nil {
+			break
+		}
+	}
+
+	// Once the archive has begun, a failure can only show as an archive cut short
+	archive.Close()
+	return nil
+}
@@ -0,0 +1,449 @@
+package apitest
+
+import (
+	"flywheel.io/sdk/api"
+)
+
+// Job state transitions that the real API allows. A job may always be given its current state.
+var jobTransitions = map[api.JobState][]api.JobState{
+	api.Pending: {api.Running, api.Cancelled},
+	api.Running: {api.Complete, api.Failed, api.Cancelled},
+}
+
+// routeGears handles the gear routes.
+func (s *Server) routeGears(r *request) (interface{}, error) {
+	switch {
+	case len(r.path) == 1 && r.method == "GET":
+		return s.list("gears", nil), nil
+
+	case len(r.path) == 2 && r.method == "GET":
+		return s.get("gears", r.path[1])
+
+	case len(r.path) == 2 && r.method == "POST":
+		return s.addGear(r.path[1], r)
+
+	case len(r.path) == 2 && r.method == "DELETE":
+		if !s.remove("gears", r.path[1]) {
+			return nil, errMissing("gears", r.path[1])
+		}
+		return deletedResponse(1), nil
+
+	case len(r.path) == 3 && r.path[2] == "invocation" && r.method == "GET":
+		gear, err := s.get("gears", r.path[1])
+		if err != nil {
+			return nil, err
+		}
+		return invocationSchema(gear), nil
+	}
+
+	return nil, errNotFound()
+}
+
+// addGear creates a gear, whose manifest must name it as the route does.
+func (s *Server) addGear(name string, r *request) (interface{}, error) {
+	var gear *api.GearDoc
+	if err := r.decode(&gear); err != nil {
+		return nil, err
+	}
+	if gear == nil || gear.Gear == nil || gear.Gear.Name != name {
+		return nil, errBadRequest("Gear manifest name must match " + name)
+	}
+
+	doc := toDocument(gear)
+	now := s.now()
+	doc["created"] = now
+	doc["modified"] = now
+
+	id := newId()
+	s.insert("gears", id, doc)
+	return idResponse(id), nil
+}
+
+// invocationSchema returns a JSON schema for invoking a gear.
+func invocationSchema(gear document) interface{} {
+	manifest, _ := gear["gear"].(map[string]interface{})
+
+	return map[string]interface{}{
+		"$schema":     "http://json-schema.org/draft-04/schema#",
+		"title":       "Invocation manifest for " + manifest["name"].(string),
+		"type":        "object",
+		"definitions": map[string]interface{}{},
+		"properties": map[string]interface{}{
+			"config": map[string]interface{}{"type": "object", "properties": manifest["config"]},
+			"inputs": map[string]interface{}{"type": "object", "properties": manifest["inputs"]},
+		},
+		"required": []string{"config", "inputs"},
+	}
+}
+
+// routeJobs handles the job routes.
+func (s *Server) routeJobs(r *request) (interface{}, error) {
+	switch {
+	case len(r.path) == 2 && r.path[1] == "add" && r.method == "POST":
+		var job document
+		if err := r.decode(&job); err != nil {
+			return nil, err
+		}
+		created, err := s.addJob(job, r.user)
+		if err != nil {
+			return nil, err
+		}
+		return idResponse(created["id"].(string)), nil
+
+	case len(r.path) == 2 && r.path[1] == "next" && r.method == "GET":
+		return s.startNextJob(r.query["tags"])
+
+	case len(r.path) == 2 && r.method == "GET":
+		return s.get("jobs", r.path[1])
+
+	case len(r.path) == 2 && r.method == "PUT":
+		return s.modifyJob(r.path[1], r)
+
+	case len(r.path) == 3 && r.path[2] == "logs":
+		if _, err := s.get("jobs", r.path[1]); err != nil {
+			return nil, err
+		}
+
+		switch r.method {
+		case "GET":
+			logs := s.logs[r.path[1]]
+			if logs == nil {
+				logs = []*api.JobLogStatement{}
+			}
+			return map[string]interface{}{"_id": r.path[1], "logs": logs}, nil
+
+		case "POST":
+			var statements []*api.JobLogStatement
+			if err := r.decode(&statements); err != nil {
+				return nil, err
+			}
+			s.logs[r.path[1]] = append(s.logs[r.path[1]], statements...)
+			return nil, nil
+		}
+	}
+
+	return nil, errNotFound()
+}
+
+// addJob creates a pending job for a gear, tagged with the gear's name as the real API does.
+func (s *Server) addJob(job document, user string) (document, error) {
+	gearId, _ := job["gear_id"].(string)
+	gear, err := s.get("gears", gearId)
+	if err != nil {
+		return nil, err
+	}
+
+	if destination, ok := job["destination"].(map[string]interface{}); ok {
+		kind, _ := destination["type"].(string)
+		id, _ := destination["id"].(string)
+		if _, err := s.get(containerKind(kind), id); err != nil {
+			return nil, err
+		}
+	}
+
+	manifest, _ := gear["gear"].(map[string]interface{})
+	appendField(job, "tags", manifest["name"])
+
+	id := newId()
+	now := s.now()
+	job["id"] = id
+	job["state"] = api.Pending
+	job["attempt"] = 1
+	job["origin"] = map[string]interface{}{"type": "user", "id": user}
+	job["created"] = now
+	job["modified"] = now
+	if job["config"] == nil {
+		job["config"] = map[string]interface{}{}
+	}
+
+	s.insert("jobs", id, job)
+	return job, nil
+}
+
+// modifyJob applies a modification to a job. An empty modification is a heartbeat, which only updates the modified time.
+func (s *Server) modifyJob(id string, r *request) (interface{}, error) {
+	job, err := s.get("jobs", id)
+	if err != nil {
+		return nil, err
+	}
+
+	var mod document
+	if err := r.decode(&mod); err != nil {
+		return nil, err
+	}
+
+	if raw, ok := mod["state"].(string); ok {
+		current := jobState(job)
+		if !canTransition(current, api.JobState(raw)) {
+			return nil, errBadRequest("Cannot change job state from " + string(current) + " to " + raw)
+		}
+	}
+
+	s.merge(job, mod)
+	return modifiedResponse(1), nil
+}
+
+// canTransition reports whether a job may move from one state to another.
+func canTransition(from, to api.JobState) bool {
+	if from == to {
+		return true
+	}
+	for _, allowed := range jobTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// jobState returns a job's state, whether stored as a JobState or decoded from JSON.
+func jobState(job document) api.JobState {
+	if state, ok := job["state"].(api.JobState); ok {
+		return state
+	}
+	state, _ := job["state"].(string)
+	return api.JobState(state)
+}
+
+// startNextJob marks the oldest pending job with any of the given tags as running, and returns it with its formula.
+// With no tags, any pending job may be started. Responds 400 if there are none, as the real API does.
+func (s *Server) startNextJob(tags []string) (interface{}, error) {
+	for _, job := range s.list("jobs", nil) {
+		if jobState(job) != api.Pending || !hasAnyTag(job, tags) {
+			continue
+		}
+
+		job["state"] = api.Running
+		job["modified"] = s.now()
+		job["request"] = formula(job)
+		return job, nil
+	}
+
+	return nil, errBadRequest("No jobs to process")
+}
+
+// hasAnyTag reports whether a job has any of tags, or tags is empty.
+func hasAnyTag(job document, tags []string) bool {
+	if len(tags) == 0 {
+		return true
+	}
+
+	jobTags, _ := job["tags"].([]interface{})
+	for _, tag := range tags {
+		for _, jobTag := range jobTags {
+			if jobTag == tag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// formula returns the unit of work for running a job, in the form the real API generates.
+func formula(job document) *api.Formula {
+	result := &api.Formula{
+		Inputs: []*api.Input{},
+		Target: api.Target{
+			Command: []string{"bash", "-c", "rm -rf output; mkdir -p output; ./run; echo \"Exit was $?\""},
+			Env:     map[string]string{},
+			Dir:     "/flywheel/v0",
+		},
+		Outputs: []*api.Output{},
+	}
+
+	inputs, _ := job["inputs"].(map[string]interface{})
+	for name, raw := range inputs {
+		input, _ := raw.(map[string]interface{})
+		kind, _ := input["type"].(string)
+		id, _ := input["id"].(string)
+		file, _ := input["name"].(string)
+
+		result.Inputs = append(result.Inputs, &api.Input{
+			Type:     "scitran",
+			URI:      "/" + kind + "s/" + id + "/files/" + file,
+			Location: "/flywheel/v0/input/" + name,
+		})
+	}
+
+	if destination, ok := job["destination"].(map[string]interface{}); ok {
+		kind, _ := destination["type"].(string)
+		id, _ := destination["id"].(string)
+
+		result.Outputs = append(result.Outputs, &api.Output{
+			Type:     "scitran",
+			URI:      "/engine?level=" + kind + "&id=" + id,
+			Location: "/flywheel/v0/output",
+		})
+	}
+
+	return result
+}
+
+// routeBatches handles the batch routes.
+func (s *Server) routeBatches(r *request) (interface{}, error) {
+	switch {
+	case len(r.path) == 1 && r.method == "GET":
+		return s.list("batch", nil), nil
+
+	case len(r.path) == 1 && r.method == "POST":
+		return s.proposeBatch(r)
+
+	case len(r.path) == 2 && r.method == "GET":
+		return s.get("batch", r.path[1])
+
+	case len(r.path) == 3 && r.path[2] == "run" && r.method == "POST":
+		return s.runBatch(r.path[1], r.user)
+
+	case len(r.path) == 3 && r.path[2] == "cancel" && r.method == "POST":
+		return s.cancelBatch(r.path[1])
+	}
+
+	return nil, errNotFound()
+}
+
+// proposeBatch creates a pending batch, matching each target that exists.
+func (s *Server) proposeBatch(r *request) (interface{}, error) {
+	var body struct {
+		GearId  string                    `json:"gear_id"`
+		Config  map[string]interface{}    `json:"config"`
+		Tags    []string                  `json:"tags"`
+		Targets []*api.ContainerReference `json:"targets"`
+	}
+	if err := r.decode(&body); err != nil {
+		return nil, err
+	}
+	if _, err := s.get("gears", body.GearId); err != nil {
+		return nil, err
+	}
+
+	matched := []interface{}{}
+	notMatched := []interface{}{}
+	for _, target := range body.Targets {
+		kind := containerKind(target.Type)
+		if doc, err := s.get(kind, target.Id); err == nil {
+			matched = append(matched, project(kind, doc))
+		} else {
+			notMatched = append(notMatched, toDocument(target))
+		}
+	}
+
+	if body.Config == nil {
+		body.Config = map[string]interface{}{}
+	}
+
+	id := newId()
+	now := s.now()
+	batch := document{
+		"gear_id":  body.GearId,
+		"config":   body.Config,
+		"state":    api.Pending,
+		"origin":   map[string]interface{}{"type": "user", "id": r.user},
+		"created":  now,
+		"modified": now,
+		"proposal": map[string]interface{}{
+			"targets": body.Targets,
+			"tags":    body.Tags,
+		},
+	}
+	s.insert("batch", id, batch)
+
+	proposal := copyDocument(batch)
+	proposal["matched"] = matched
+	proposal["not_matched"] = notMatched
+	proposal["ambiguous"] = []interface{}{}
+	proposal["improper_permissions"] = []interface{}{}
+	return proposal, nil
+}
+
+// runBatch creates a job for each matched target of a pending batch.
+// Each of the gear's file inputs is given the first file on the target.
+func (s *Server) runBatch(id, user string) (interface{}, error) {
+	batch, err := s.get("batch", id)
+	if err != nil {
+		return nil, err
+	}
+	if jobState(batch) != api.Pending {
+		return nil, errBadRequest("Can only run pending batches")
+	}
+
+	gear, err := s.get("gears", batch["gear_id"].(string))
+	if err != nil {
+		return nil, err
+	}
+	manifest, _ := gear["gear"].(map[string]interface{})
+	gearInputs, _ := manifest["inputs"].(map[string]interface{})
+
+	proposal := batch["proposal"].(map[string]interface{})
+	targets, _ := proposal["targets"].([]*api.ContainerReference)
+	tags, _ := proposal["tags"].([]string)
+
+	jobs := []interface{}{}
+	jobIds := []string{}
+
+	for _, target := range targets {
+		doc, err := s.get(target.Type+"s", target.Id)
+		if err != nil {
+			continue
+		}
+
+		inputs := map[string]interface{}{}
+		files, _ := doc["files"].([]interface{})
+		for name, raw := range gearInputs {
+			input, _ := raw.(map[string]interface{})
+			if input["base"] == "file" && len(files) > 0 {
+				file := files[0].(map[string]interface{})
+				inputs[name] = toDocument(&api.FileReference{Id: target.Id, Type: target.Type, Name: file["name"].(string)})
+			}
+		}
+
+		jobTags := []interface{}{}
+		for _, tag := range tags {
+			jobTags = append(jobTags, tag)
+		}
+
+		job, err := s.addJob(document{
+			"gear_id":     batch["gear_id"],
+			"config":      batch["config"],
+			"inputs":      inputs,
+			"destination": toDocument(target),
+			"tags":        jobTags,
+		}, user)
+		if err != nil {
+			return nil, err
+		}
+
+		jobs = append(jobs, job)
+		jobIds = append(jobIds, job["id"].(string))
+	}
+
+	batch["jobs"] = jobIds
+	batch["state"] = api.Running
+	batch["modified"] = s.now()
+	return jobs, nil
+}
+
+// cancelBatch cancels each unfinished job of a batch.
+func (s *Server) cancelBatch(id string) (interface{}, error) {
+	batch, err := s.get("batch", id)
+	if err != nil {
+		return nil, err
+	}
+
+	cancelled := 0
+	jobIds, _ := batch["jobs"].([]string)
+	for _, jobId := range jobIds {
+		job := s.docs["jobs"][jobId]
+		if job == nil {
+			continue
+		}
+		if state := jobState(job); state == api.Pending || state == api.Running {
+			job["state"] = api.Cancelled
+			job["modified"] = s.now()
+			cancelled++
+		}
+	}
+
+	batch["state"] = api.Cancelled
+	batch["modified"] = s.now()
+	return map[string]interface{}{"number_cancelled": cancelled}, nil
+}
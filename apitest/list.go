@@ -0,0 +1,267 @@
+package apitest
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Filter operators, longest first so that "<=" is not read as "<".
+var filterOperators = []string{"=~", "!=", "<=", ">=", "=", "<", ">"}
+
+// filterCondition is one condition of a filter query parameter.
+type filterCondition struct {
+	field, operator, value string
+	pattern                *regexp.Regexp
+}
+
+// listQuery applies the filter, sort, after_id, limit and fields query parameters to a listing of a kind of container.
+// Paged listings are in Id order unless sorted otherwise. Containers appear as in listings, unless fields are given.
+func listQuery(kind string, docs []document, query url.Values) ([]document, error) {
+	conditions, err := parseFilter(query.Get("filter"))
+	if err != nil {
+		return nil, err
+	}
+
+	result := []document{}
+	for _, doc := range docs {
+		if matchesFilter(doc, conditions) {
+			result = append(result, doc)
+		}
+	}
+
+	afterId := query.Get("after_id")
+	limit := 0
+	if raw := query.Get("limit"); raw != "" {
+		if limit, err = strconv.Atoi(raw); err != nil || limit < 0 {
+			return nil, errBadRequest("Invalid limit " + strconv.Quote(raw))
+		}
+	}
+
+	sortBy := query.Get("sort")
+	if sortBy == "" && (afterId != "" || limit > 0) {
+		sortBy = "_id"
+	}
+	if sortBy != "" {
+		if err := sortDocuments(result, sortBy); err != nil {
+			return nil, err
+		}
+	}
+
+	if afterId != "" {
+		paged := []document{}
+		for _, doc := range result {
+			if doc["_id"].(string) > afterId {
+				paged = append(paged, doc)
+			}
+		}
+		result = paged
+	}
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+
+	var fields []string
+	if raw := query.Get("fields"); raw != "" {
+		fields = strings.Split(raw, ",")
+	}
+	for i, doc := range result {
+		if fields != nil {
+			result[i] = projectFields(doc, fields)
+		} else {
+			result[i] = project(kind, doc)
+		}
+	}
+	return result, nil
+}
+
+// parseFilter reads a comma-separated list of conditions, such as "label=foo,created>2017".
+func parseFilter(filter string) ([]*filterCondition, error) {
+	var conditions []*filterCondition
+	if filter == "" {
+		return conditions, nil
+	}
+
+	for _, raw := range strings.Split(filter, ",") {
+		var condition *filterCondition
+		for _, operator := range filterOperators {
+			if i := strings.Index(raw, operator); i > 0 {
+				// The earliest operator in the condition wins, so that values may contain operators
+				if condition == nil || i < len(condition.field) {
+					condition = &filterCondition{field: raw[:i], operator: operator, value: raw[i+len(operator):]}
+				}
+			}
+		}
+		if condition == nil {
+			return nil, errBadRequest("Invalid filter condition " + strconv.Quote(raw))
+		}
+
+		if condition.operator == "=~" {
+			pattern, err := regexp.Compile(condition.value)
+			if err != nil {
+				return nil, errBadRequest("Invalid filter pattern " + strconv.Quote(condition.value))
+			}
+			condition.pattern = pattern
+		}
+		conditions = append(conditions, condition)
+	}
+	return conditions, nil
+}
+
+// matchesFilter reports whether a document meets every condition.
+func matchesFilter(doc document, conditions []*filterCondition) bool {
+	for _, condition := range conditions {
+		value, ok := lookupPath(doc, condition.field)
+		if !ok {
+			if condition.operator != "!=" {
+				return false
+			}
+			continue
+		}
+
+		if condition.pattern != nil {
+			if !condition.pattern.MatchString(fieldString(value)) {
+				return false
+			}
+			continue
+		}
+
+		cmp := compareValues(value, condition.value)
+		var match bool
+		switch condition.operator {
+		case "=":
+			match = cmp == 0
+		case "!=":
+			match = cmp != 0
+		case "<":
+			match = cmp < 0
+		case "<=":
+			match = cmp <= 0
+		case ">":
+			match = cmp > 0
+		case ">=":
+			match = cmp >= 0
+		}
+		if !match {
+			return false
+		}
+	}
+	return true
+}
+
+// sortDocuments orders documents by a comma-separated list of fields, each optionally followed by ":asc" or ":desc".
+// Documents missing a field sort before those that have it.
+func sortDocuments(docs []document, sortBy string) error {
+	type key struct {
+		field      string
+		descending bool
+	}
+	var keys []key
+
+	for _, raw := range strings.Split(sortBy, ",") {
+		parts := strings.SplitN(raw, ":", 2)
+		k := key{field: parts[0]}
+		if len(parts) == 2 {
+			switch parts[1] {
+			case "asc", "1":
+			case "desc", "-1":
+				k.descending = true
+			default:
+				return errBadRequest("Invalid sort direction " + strconv.Quote(parts[1]))
+			}
+		}
+		keys = append(keys, k)
+	}
+
+	sort.SliceStable(docs, func(i, j int) bool {
+		for _, k := range keys {
+			a, aOk := lookupPath(docs[i], k.field)
+			b, bOk := lookupPath(docs[j], k.field)
+
+			var cmp int
+			switch {
+			case !aOk && !bOk:
+				cmp = 0
+			case !aOk:
+				cmp = -1
+			case !bOk:
+				cmp = 1
+			default:
+				cmp = compareValues(a, fieldString(b))
+			}
+
+			if k.descending {
+				cmp = -cmp
+			}
+			if cmp != 0 {
+				return cmp < 0
+			}
+		}
+		return false
+	})
+	return nil
+}
+
+// projectFields returns a document with only the given fields, which may be dotted paths, and its Id.
+func projectFields(doc document, fields []string) document {
+	result := document{"_id": doc["_id"]}
+	for _, field := range fields {
+		value, ok := lookupPath(doc, field)
+		if ok {
+			setInfoPath(result, field, value)
+		}
+	}
+	return result
+}
+
+// lookupPath returns the value of a possibly dotted field of a document.
+func lookupPath(doc document, path string) (interface{}, bool) {
+	var current interface{} = map[string]interface{}(doc)
+	for _, part := range strings.Split(path, ".") {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		current, ok = m[part]
+		if !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// fieldString returns the form of a stored value that filters and sorts compare.
+func fieldString(value interface{}) string {
+	switch x := value.(type) {
+	case string:
+		return x
+	case time.Time:
+		// Fixed width, so that times compare correctly as strings
+		return x.UTC().Format("2006-01-02T15:04:05.000000000Z")
+	default:
+		return fmt.Sprint(x)
+	}
+}
+
+// compareValues compares a stored value with one given in a query, numerically if both are numbers.
+func compareValues(value interface{}, other string) int {
+	a := fieldString(value)
+
+	aNumber, aErr := strconv.ParseFloat(a, 64)
+	bNumber, bErr := strconv.ParseFloat(other, 64)
+	if aErr == nil && bErr == nil {
+		switch {
+		case aNumber < bNumber:
+			return -1
+		case aNumber > bNumber:
+			return 1
+		}
+		return 0
+	}
+
+	return strings.Compare(a, other)
+}
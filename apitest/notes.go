@@ -0,0 +1,121 @@
+package apitest
+
+import (
+	"flywheel.io/sdk/api"
+)
+
+// routeNotes handles the note routes of a container, which all begin <kind>/<id>/notes.
+// Only a note's author, or an admin of the container, may change or delete it.
+func (s *Server) routeNotes(kind string, r *request) (interface{}, error) {
+	id := r.path[1]
+
+	switch {
+	case len(r.path) == 3 && r.method == "POST":
+		return s.addNote(kind, id, r)
+
+	case len(r.path) == 4 && r.method == "GET":
+		_, note, _, err := s.getNote(kind, id, r.path[3])
+		return note, err
+
+	case len(r.path) == 4 && r.method == "PUT":
+		doc, note, _, err := s.getNote(kind, id, r.path[3])
+		if err != nil {
+			return nil, err
+		}
+		if err := checkNoteAuthor(doc, note, r.user); err != nil {
+			return nil, err
+		}
+
+		var mod struct {
+			Text string `json:"text"`
+		}
+		if err := r.decode(&mod); err != nil {
+			return nil, err
+		}
+		if mod.Text == "" {
+			return nil, errBadRequest("Note text is required")
+		}
+
+		now := s.now()
+		note["text"] = mod.Text
+		note["modified"] = now
+		doc["modified"] = now
+		return modifiedResponse(1), nil
+
+	case len(r.path) == 4 && r.method == "DELETE":
+		doc, note, i, err := s.getNote(kind, id, r.path[3])
+		if err != nil {
+			return nil, err
+		}
+		if err := checkNoteAuthor(doc, note, r.user); err != nil {
+			return nil, err
+		}
+
+		notes := doc["notes"].([]interface{})
+		doc["notes"] = append(notes[:i:i], notes[i+1:]...)
+		doc["modified"] = s.now()
+		return deletedResponse(1), nil
+	}
+
+	return nil, errNotFound()
+}
+
+// addNote adds a note, attributed to the requesting user.
+func (s *Server) addNote(kind, id string, r *request) (interface{}, error) {
+	doc, err := s.get(kind, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var note *api.Note
+	if err := r.decode(&note); err != nil {
+		return nil, err
+	}
+	if note == nil || note.Text == "" {
+		return nil, errBadRequest("Note text is required")
+	}
+
+	now := s.now()
+	note.Id = newId()
+	note.UserId = r.user
+	note.Created = &now
+	note.Modified = &now
+
+	appendField(doc, "notes", map[string]interface{}(toDocument(note)))
+	doc["modified"] = now
+	return modifiedResponse(1), nil
+}
+
+// getNote returns a container, one of its notes, and the note's index, or a 404 error.
+func (s *Server) getNote(kind, id, noteId string) (document, map[string]interface{}, int, error) {
+	doc, err := s.get(kind, id)
+	if err != nil {
+		return nil, nil, -1, err
+	}
+
+	notes, _ := doc["notes"].([]interface{})
+	for i, raw := range notes {
+		note, _ := raw.(map[string]interface{})
+		if note["id"] == noteId {
+			return doc, note, i, nil
+		}
+	}
+	return nil, nil, -1, errMissing("notes", noteId)
+}
+
+// checkNoteAuthor returns a 403 error unless a user wrote a note or is an admin of its container.
+func checkNoteAuthor(doc document, note map[string]interface{}, user string) error {
+	if note["user"] == user {
+		return nil
+	}
+
+	permissions, _ := doc["permissions"].([]interface{})
+	for _, raw := range permissions {
+		permission, _ := raw.(map[string]interface{})
+		if permission["_id"] == user && permission["access"] == string(api.Admin) {
+			return nil
+		}
+	}
+
+	return &api.Error{StatusCode: 403, Message: "User " + user + " is not the author of note " + note["id"].(string)}
+}
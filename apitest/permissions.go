@@ -0,0 +1,88 @@
+package apitest
+
+import (
+	"flywheel.io/sdk/api"
+)
+
+// routePermissions handles the permission routes of groups, projects and collections.
+// As with the real API, changes to a project's permissions are copied to its sessions and acquisitions.
+func (s *Server) routePermissions(kind string, r *request) (interface{}, error) {
+	if kind != "groups" && kind != "projects" && kind != "collections" {
+		return nil, errNotFound()
+	}
+
+	doc, err := s.get(kind, r.path[1])
+	if err != nil {
+		return nil, err
+	}
+	permissions, _ := doc["permissions"].([]interface{})
+
+	// Index of the permission for the user in the route, if any
+	index := -1
+	if len(r.path) == 4 {
+		for i, raw := range permissions {
+			if raw.(map[string]interface{})["_id"] == r.path[3] {
+				index = i
+			}
+		}
+		if index < 0 {
+			return nil, errMissing("permissions", r.path[3])
+		}
+	}
+
+	var permission *api.Permission
+	if r.method == "POST" || r.method == "PUT" {
+		if err := r.decode(&permission); err != nil {
+			return nil, err
+		}
+		// Unknown levels allow nothing, not even NoAccess
+		if permission == nil || permission.Level == api.NoAccess || !permission.Level.Allows(api.NoAccess) {
+			return nil, errBadRequest("Access level must be one of ro, rw or admin")
+		}
+	}
+
+	switch {
+	case len(r.path) == 3 && r.method == "POST":
+		if permission.Id == "" {
+			return nil, errBadRequest("Permission user Id is required")
+		}
+		if _, err := s.get("users", permission.Id); err != nil {
+			return nil, err
+		}
+		for _, raw := range permissions {
+			if raw.(map[string]interface{})["_id"] == permission.Id {
+				return nil, &api.Error{StatusCode: 409, Message: "User " + permission.Id + " already has a permission"}
+			}
+		}
+		permissions = append(permissions, map[string]interface{}(toDocument(permission)))
+
+	case len(r.path) == 4 && r.method == "PUT":
+		permissions[index].(map[string]interface{})["access"] = permission.Level
+
+	case len(r.path) == 4 && r.method == "DELETE":
+		permissions = append(permissions[:index:index], permissions[index+1:]...)
+
+	default:
+		return nil, errNotFound()
+	}
+
+	doc["permissions"] = permissions
+	doc["modified"] = s.now()
+
+	if kind == "projects" {
+		for _, ref := range s.descendants(kind, r.path[1])[1:] {
+			s.docs[ref.kind][ref.id]["permissions"] = copyPermissions(permissions)
+		}
+	}
+
+	return modifiedResponse(1), nil
+}
+
+// copyPermissions copies a list of permissions, so that containers do not share them.
+func copyPermissions(permissions []interface{}) []interface{} {
+	result := make([]interface{}, len(permissions))
+	for i, raw := range permissions {
+		result[i] = map[string]interface{}(copyDocument(raw.(map[string]interface{})))
+	}
+	return result
+}
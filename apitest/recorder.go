@@ -0,0 +1,358 @@
+package apitest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Mode selects whether a Recorder records or replays.
+type Mode string
+
+const (
+	// ModeRecord sends requests to the underlying transport, and saves each interaction to the cassette.
+	ModeRecord Mode = "record"
+
+	// ModeReplay serves responses from the cassette, without sending any requests.
+	ModeReplay Mode = "replay"
+)
+
+// scrubbed replaces the API key in cassettes.
+const scrubbed = "[REDACTED]"
+
+// multipartBoundary replaces the random boundary of multipart bodies, so that uploads can be matched.
+const multipartBoundary = "RECORDED-BOUNDARY"
+
+// RecordedRequest is a request saved in a cassette.
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"headers,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// RecordedResponse is a response saved in a cassette.
+// Bodies that are not valid UTF-8, such as downloaded files, are base64-encoded.
+type RecordedResponse struct {
+	StatusCode int         `json:"status"`
+	Header     http.Header `json:"headers,omitempty"`
+	Body       string      `json:"body,omitempty"`
+	Base64     bool        `json:"base64,omitempty"`
+}
+
+// Interaction is one request and its response.
+type Interaction struct {
+	Request  *RecordedRequest  `json:"request"`
+	Response *RecordedResponse `json:"response"`
+}
+
+// Cassette is the file format of a Recorder.
+type Cassette struct {
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Recorder is an http.RoundTripper that records interactions to a cassette file, or replays them from one.
+// Install it on a client with api.UseMiddleware(recorder.Middleware), and call Close when done to save a recording.
+//
+// The Authorization header is dropped from recordings, and ApiKey is scrubbed wherever it appears.
+// Requests are recorded by path and query only, so that cassettes recorded against one host can be replayed for another.
+//
+// When replaying, each recorded interaction is used at most once, in the order recorded.
+// A request matches an interaction if its method, URL and body are equal, after masking Variables.
+type Recorder struct {
+	Mode Mode
+
+	// Path of the cassette file.
+	Path string
+
+	// ApiKey is the key of the client being recorded, which is replaced wherever it appears in a recording.
+	ApiKey string
+
+	// Variables are patterns for parts of requests that change between runs, such as randomly generated names.
+	// When matching, each match is masked. When replaying, the value recorded for each match is replaced with
+	// the live value in all later responses, so that a test sees the names it generated.
+	Variables []*regexp.Regexp
+
+	// Match decides whether a live request matches a recorded one, after Variables are masked in both.
+	// Defaults to comparing method, URL and body.
+	Match func(live, recorded *RecordedRequest) bool
+
+	// Transport is the underlying HTTP transport to record from.
+	// It will default to http.DefaultTransport if nil.
+	Transport http.RoundTripper
+
+	mu       sync.Mutex
+	cassette *Cassette
+	used     []bool
+
+	// Recorded values of Variables, and the live values they were replaced with.
+	replacements []string
+}
+
+// NewRecorder creates a Recorder. In replay mode, the cassette at path is loaded immediately.
+func NewRecorder(mode Mode, path string) (*Recorder, error) {
+	r := &Recorder{
+		Mode:     mode,
+		Path:     path,
+		cassette: &Cassette{Interactions: []*Interaction{}},
+	}
+
+	switch mode {
+	case ModeRecord:
+	case ModeReplay:
+		raw, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		err = json.Unmarshal(raw, r.cassette)
+		if err != nil {
+			return nil, errors.New("Invalid cassette " + path + ": " + err.Error())
+		}
+		r.used = make([]bool, len(r.cassette.Interactions))
+	default:
+		return nil, errors.New("Unknown recorder mode " + string(mode))
+	}
+
+	return r, nil
+}
+
+// MatchExact compares the method, URL and body of two requests.
+func MatchExact(live, recorded *RecordedRequest) bool {
+	return live.Method == recorded.Method && live.URL == recorded.URL && live.Body == recorded.Body
+}
+
+// MatchIgnoringBody compares the method and URL of two requests.
+func MatchIgnoringBody(live, recorded *RecordedRequest) bool {
+	return live.Method == recorded.Method && live.URL == recorded.URL
+}
+
+// RoundTrip implements the RoundTripper interface.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	recorded, err := r.recordRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.Mode == ModeReplay {
+		// Fail cancelled requests as a real transport would
+		if err := req.Context().Err(); err != nil {
+			return nil, err
+		}
+		return r.replay(req, recorded)
+	}
+
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cassette.Interactions = append(r.cassette.Interactions, &Interaction{
+		Request:  recorded,
+		Response: r.recordResponse(resp, body),
+	})
+	return resp, nil
+}
+
+// Middleware sets the underlying transport and returns the Recorder, for use with api.UseMiddleware.
+func (r *Recorder) Middleware(rt http.RoundTripper) http.RoundTripper {
+	r.Transport = rt
+	return r
+}
+
+// Close saves the cassette, if recording.
+func (r *Recorder) Close() error {
+	if r.Mode != ModeRecord {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	raw, err := json.MarshalIndent(r.cassette, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(r.Path), 0755)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(r.Path, append(raw, '\n'), 0644)
+}
+
+// replay finds the first unused interaction that matches a request, and returns its response.
+func (r *Recorder) replay(req *http.Request, live *RecordedRequest) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	match := r.Match
+	if match == nil {
+		match = MatchExact
+	}
+
+	maskedLive, liveValues := r.mask(live)
+
+	for i, interaction := range r.cassette.Interactions {
+		if r.used[i] {
+			continue
+		}
+
+		maskedRecorded, recordedValues := r.mask(interaction.Request)
+		if len(liveValues) != len(recordedValues) || !match(maskedLive, maskedRecorded) {
+			continue
+		}
+
+		r.used[i] = true
+		for j := range recordedValues {
+			if recordedValues[j] != liveValues[j] {
+				r.replacements = append(r.replacements, recordedValues[j], liveValues[j])
+			}
+		}
+
+		return r.replayResponse(req, interaction.Response)
+	}
+
+	return nil, errors.New("No recorded interaction in " + r.Path + " matches " + live.Method + " " + live.URL)
+}
+
+// replayResponse builds a response from a recording, replacing recorded values of Variables with live ones.
+func (r *Recorder) replayResponse(req *http.Request, recorded *RecordedResponse) (*http.Response, error) {
+	body := []byte(recorded.Body)
+	if recorded.Base64 {
+		var err error
+		body, err = base64.StdEncoding.DecodeString(recorded.Body)
+		if err != nil {
+			return nil, err
+		}
+	} else if len(r.replacements) > 0 {
+		body = []byte(strings.NewReplacer(r.replacements...).Replace(recorded.Body))
+	}
+
+	header := http.Header{}
+	for k, v := range recorded.Header {
+		header[k] = v
+	}
+	header.Del("Content-Length")
+
+	return &http.Response{
+		Status:        http.StatusText(recorded.StatusCode),
+		StatusCode:    recorded.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// mask returns a copy of a request with each match of Variables masked, and the values that were masked in order.
+func (r *Recorder) mask(req *RecordedRequest) (*RecordedRequest, []string) {
+	masked := *req
+	var values []string
+
+	for _, pattern := range r.Variables {
+		for _, field := range []*string{&masked.URL, &masked.Body} {
+			*field = pattern.ReplaceAllStringFunc(*field, func(value string) string {
+				values = append(values, value)
+				return "{variable}"
+			})
+		}
+	}
+
+	return &masked, values
+}
+
+// recordRequest captures a request for the cassette, leaving its body readable.
+func (r *Recorder) recordRequest(req *http.Request) (*RecordedRequest, error) {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	header := r.scrubHeader(req.Header)
+
+	// Multipart boundaries are random; replace them so that uploads can be matched
+	mediaType, params, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err == nil && strings.HasPrefix(mediaType, "multipart/") && params["boundary"] != "" {
+		body = bytes.Replace(body, []byte(params["boundary"]), []byte(multipartBoundary), -1)
+		header.Set("Content-Type", mediaType+"; boundary="+multipartBoundary)
+	}
+
+	return &RecordedRequest{
+		Method: req.Method,
+		URL:    r.scrub(req.URL.RequestURI()),
+		Header: header,
+		Body:   r.scrub(string(body)),
+	}, nil
+}
+
+// recordResponse captures a response for the cassette.
+func (r *Recorder) recordResponse(resp *http.Response, body []byte) *RecordedResponse {
+	recorded := &RecordedResponse{
+		StatusCode: resp.StatusCode,
+		Header:     r.scrubHeader(resp.Header),
+	}
+
+	if utf8.Valid(body) {
+		recorded.Body = r.scrub(string(body))
+	} else {
+		recorded.Body = base64.StdEncoding.EncodeToString(body)
+		recorded.Base64 = true
+	}
+	return recorded
+}
+
+// scrubHeader returns a copy of header without the Authorization header, and with the API key scrubbed from the rest.
+func (r *Recorder) scrubHeader(header http.Header) http.Header {
+	clean := http.Header{}
+	for k, v := range header {
+		values := make([]string, len(v))
+		for i, x := range v {
+			values[i] = r.scrub(x)
+		}
+		clean[k] = values
+	}
+	clean.Del("Authorization")
+	return clean
+}
+
+// scrub replaces the API key in a recorded value.
+func (r *Recorder) scrub(value string) string {
+	if r.ApiKey == "" {
+		return value
+	}
+	return strings.Replace(value, r.ApiKey, scrubbed, -1)
+}
@@ -0,0 +1,421 @@
+// Package apitest provides an in-memory fake of the Flywheel API, for testing code that uses the SDK without a live instance.
+//
+// A Server implements http.Handler, and is usually served with httptest:
+//
+//	ts := httptest.NewServer(apitest.NewServer())
+//	defer ts.Close()
+//
+//	client := apitest.NewClient(ts)
+//	user, _, err := client.GetCurrentUser()
+//
+// The fake covers the routes used by the api package, with behavior close enough to the real API for the SDK's own test suite.
+// It does not implement permission checks, and state is lost when the Server is discarded.
+package apitest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"flywheel.io/sdk/api"
+)
+
+const (
+	// RootUserId is the Id of the root user that every Server starts with.
+	RootUserId = "a@b.c"
+
+	// RootKey is the API key of the root user, without host or port.
+	RootKey = "insecure-key"
+)
+
+// Message of the error returned for unknown routes, matching the real API.
+const notFoundMessage = "The resource could not be found."
+
+// document is a stored object, kept in its JSON form as the real API keeps BSON.
+type document map[string]interface{}
+
+// Server is an in-memory fake of the Flywheel API.
+// It is safe for concurrent use.
+type Server struct {
+	mu sync.Mutex
+
+	// Stored objects, by kind and then Id.
+	// Kinds are named after their routes: "groups", "projects", "sessions" and so on.
+	docs map[string]map[string]document
+
+	// Insertion order of each kind, so that listings are stable.
+	order map[string][]string
+
+	// File contents, by fileKey.
+	blobs map[string][]byte
+
+	// Acquisition Ids that each collection contains.
+	collections map[string][]string
+
+	// Log statements for each job.
+	logs map[string][]*api.JobLogStatement
+
+	// The last timestamp handed out; see now.
+	last time.Time
+
+	created time.Time
+}
+
+// NewServer returns a Server that contains only the root user, whose key is RootKey.
+func NewServer() *Server {
+	s := &Server{
+		docs:        map[string]map[string]document{},
+		order:       map[string][]string{},
+		blobs:       map[string][]byte{},
+		collections: map[string][]string{},
+		logs:        map[string][]*api.JobLogStatement{},
+	}
+	s.created = s.now()
+
+	root := true
+	created := s.now()
+	s.insert("users", RootUserId, toDocument(&api.User{
+		Id:         RootUserId,
+		Email:      RootUserId,
+		Firstname:  "Test",
+		Lastname:   "User",
+		Created:    &created,
+		Modified:   &created,
+		RootAccess: &root,
+		ApiKey: &api.Key{
+			Key:      RootKey,
+			Created:  &created,
+			LastUsed: &created,
+		},
+	}))
+
+	return s
+}
+
+// ApiKey returns an API key for the root user of a Server served by ts.
+func ApiKey(ts *httptest.Server) string {
+	return strings.TrimPrefix(ts.URL, "http://") + ":" + RootKey
+}
+
+// IssueKey gives an existing user a new API key and returns it, so that tests can act as users other than root.
+// The real API only lets users create keys for themselves.
+func (s *Server) IssueKey(userId string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, err := s.get("users", userId)
+	if err != nil {
+		return "", err
+	}
+
+	now := s.now()
+	key := newId()
+	user["api_key"] = map[string]interface{}{
+		"key":       key,
+		"created":   now,
+		"last_used": now,
+	}
+	return key, nil
+}
+
+// NewClient returns a client that uses the root user of a Server served by ts.
+// Options given are applied after those needed to connect.
+func NewClient(ts *httptest.Server, options ...api.ApiKeyClientOption) *api.Client {
+	options = append([]api.ApiKeyClientOption{api.InsecureUsePlaintext}, options...)
+	return api.NewApiKeyClient(ApiKey(ts), options...)
+}
+
+// request is an incoming request, read in full and parsed for the route handlers.
+type request struct {
+	method string
+	path   []string
+	query  url.Values
+	header http.Header
+	body   []byte
+
+	// The authenticated user's Id.
+	user string
+}
+
+// decode unmarshals the request body into x.
+func (r *request) decode(x interface{}) error {
+	err := json.Unmarshal(r.body, x)
+	if err != nil {
+		return &api.Error{StatusCode: 400, Message: "Invalid JSON: " + err.Error()}
+	}
+	return nil
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, &api.Error{StatusCode: 400, Message: err.Error()})
+		return
+	}
+
+	path := strings.Trim(r.URL.Path, "/")
+	if !strings.HasPrefix(path, "api/") && path != "api" {
+		writeError(w, &api.Error{StatusCode: 404, Message: notFoundMessage})
+		return
+	}
+	segments := strings.Split(strings.TrimPrefix(strings.TrimPrefix(path, "api"), "/"), "/")
+
+	req := &request{
+		method: r.Method,
+		path:   segments,
+		query:  r.URL.Query(),
+		header: r.Header,
+		body:   body,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	req.user, err = s.authenticate(r.Header.Get("Authorization"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	s.route(w, req)
+}
+
+// authenticate returns the Id of the user whose key is in an Authorization header.
+func (s *Server) authenticate(header string) (string, error) {
+	key := strings.TrimPrefix(header, "scitran-user ")
+	if key == header || key == "" {
+		return "", &api.Error{StatusCode: 401, Message: "User not authenticated"}
+	}
+
+	for _, id := range s.order["users"] {
+		apiKey, _ := s.docs["users"][id]["api_key"].(map[string]interface{})
+		if apiKey != nil && apiKey["key"] == key {
+			apiKey["last_used"] = s.now()
+			return id, nil
+		}
+	}
+
+	return "", &api.Error{StatusCode: 401, Message: "Invalid API key"}
+}
+
+// route dispatches a request to the handler for its first path segment.
+func (s *Server) route(w http.ResponseWriter, r *request) {
+	var result interface{}
+	var err error
+
+	switch r.path[0] {
+	case "groups", "projects", "sessions", "acquisitions", "collections":
+		s.routeContainer(w, r)
+		return
+
+	case "users":
+		result, err = s.routeUsers(r)
+	case "gears":
+		result, err = s.routeGears(r)
+	case "jobs":
+		result, err = s.routeJobs(r)
+	case "batch":
+		result, err = s.routeBatches(r)
+
+	case "download":
+		s.routeDownload(w, r)
+		return
+
+	case "config":
+		result, err = s.getConfig(r)
+	case "version":
+		result, err = s.getVersion(r)
+	case "dataexplorer":
+		result, err = s.routeSearch(r)
+
+	default:
+		err = errNotFound()
+	}
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, 200, result)
+}
+
+// writeJSON writes a value as a JSON response.
+func writeJSON(w http.ResponseWriter, status int, x interface{}) {
+	raw, err := json.Marshal(x)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write(raw)
+}
+
+// writeError writes an error in the form the real API uses. Errors other than *api.Error are reported as 500s.
+func writeError(w http.ResponseWriter, err error) {
+	aerr, ok := err.(*api.Error)
+	if !ok {
+		aerr = &api.Error{StatusCode: 500, Message: err.Error()}
+	}
+
+	raw, _ := json.Marshal(aerr)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(aerr.StatusCode)
+	w.Write(raw)
+}
+
+func errNotFound() error {
+	return &api.Error{StatusCode: 404, Message: notFoundMessage}
+}
+
+func errMethod(r *request) error {
+	return &api.Error{StatusCode: 405, Message: "The method " + r.method + " is not allowed for this resource."}
+}
+
+func errMissing(kind, id string) error {
+	return &api.Error{StatusCode: 404, Message: "Element " + id + " not found in container " + kind}
+}
+
+func errBadRequest(message string) error {
+	return &api.Error{StatusCode: 400, Message: message}
+}
+
+// now returns the current time, guaranteed to be later than any previous call.
+// Clients compare created and modified timestamps, so they must never collide.
+func (s *Server) now() time.Time {
+	now := time.Now().UTC()
+	if !now.After(s.last) {
+		now = s.last.Add(time.Microsecond)
+	}
+	s.last = now
+	return now
+}
+
+// newId returns a random Id in the form of a Mongo ObjectId.
+func newId() string {
+	raw := make([]byte, 12)
+	rand.Read(raw)
+	return hex.EncodeToString(raw)
+}
+
+// toDocument converts a value to its JSON form.
+func toDocument(x interface{}) document {
+	var doc document
+	raw, _ := json.Marshal(x)
+	json.Unmarshal(raw, &doc)
+	if doc == nil {
+		doc = document{}
+	}
+	return doc
+}
+
+// convert converts a stored value, such as a document, back to a typed value.
+func convert(from interface{}, to interface{}) {
+	raw, _ := json.Marshal(from)
+	json.Unmarshal(raw, to)
+}
+
+// copyDocument returns a shallow copy of doc, so that fields can be removed from a response without affecting storage.
+func copyDocument(doc document) document {
+	result := make(document, len(doc))
+	for k, v := range doc {
+		result[k] = v
+	}
+	return result
+}
+
+// insert stores a document under an Id.
+func (s *Server) insert(kind, id string, doc document) {
+	if s.docs[kind] == nil {
+		s.docs[kind] = map[string]document{}
+	}
+	if _, exists := s.docs[kind][id]; !exists {
+		s.order[kind] = append(s.order[kind], id)
+	}
+	doc["_id"] = id
+	s.docs[kind][id] = doc
+}
+
+// get returns a stored document, or a 404 error.
+func (s *Server) get(kind, id string) (document, error) {
+	doc, ok := s.docs[kind][id]
+	if !ok {
+		return nil, errMissing(kind, id)
+	}
+	return doc, nil
+}
+
+// remove deletes a stored document, reporting whether it existed.
+func (s *Server) remove(kind, id string) bool {
+	if _, ok := s.docs[kind][id]; !ok {
+		return false
+	}
+	delete(s.docs[kind], id)
+
+	ids := s.order[kind]
+	for i, x := range ids {
+		if x == id {
+			s.order[kind] = append(ids[:i:i], ids[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+// list returns the stored documents of a kind that match a filter, in insertion order.
+func (s *Server) list(kind string, filter func(document) bool) []document {
+	result := []document{}
+	for _, id := range s.order[kind] {
+		doc := s.docs[kind][id]
+		if filter == nil || filter(doc) {
+			result = append(result, doc)
+		}
+	}
+	return result
+}
+
+// merge applies a modification to a stored document, as with the real API's PUT routes.
+// Info is merged key by key; other fields are replaced. Ids and creation times cannot be changed.
+func (s *Server) merge(doc, mod document) {
+	for k, v := range mod {
+		switch k {
+		case "_id", "created":
+			continue
+		case "info":
+			existing, _ := doc["info"].(map[string]interface{})
+			update, ok := v.(map[string]interface{})
+			if existing != nil && ok {
+				for ik, iv := range update {
+					existing[ik] = iv
+				}
+				continue
+			}
+		}
+		doc[k] = v
+	}
+	doc["modified"] = s.now()
+}
+
+// idResponse is the response to a route that creates an object.
+func idResponse(id string) interface{} {
+	return &api.IdResponse{Id: id}
+}
+
+// modifiedResponse is the response to a route that modifies an object.
+func modifiedResponse(count int) interface{} {
+	return &api.ModifiedResponse{ModifiedCount: count}
+}
+
+// deletedResponse is the response to a route that deletes an object.
+func deletedResponse(count int) interface{} {
+	return &api.DeletedResponse{DeletedCount: count}
+}
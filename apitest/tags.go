@@ -0,0 +1,109 @@
+package apitest
+
+import (
+	"flywheel.io/sdk/api"
+)
+
+// routeTags handles the tag routes of a container or file, given the path after "tags".
+// Tags are kept on holder, which is either the container itself or one of its files.
+func (s *Server) routeTags(holder map[string]interface{}, container document, path []string, r *request) (interface{}, error) {
+	var err error
+
+	switch {
+	case len(path) == 0 && r.method == "POST":
+		var tag string
+		if tag, err = decodeTag(r); err == nil {
+			err = addTag(holder, tag)
+		}
+
+	case len(path) == 1 && r.method == "PUT":
+		var tag string
+		if tag, err = decodeTag(r); err == nil {
+			err = renameTag(holder, path[0], tag)
+		}
+
+	case len(path) == 1 && r.method == "DELETE":
+		err = deleteTag(holder, path[0])
+
+	default:
+		err = errNotFound()
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	holder["modified"] = now
+	container["modified"] = now
+	return modifiedResponse(1), nil
+}
+
+// decodeTag returns the tag value of a request body.
+func decodeTag(r *request) (string, error) {
+	var tag struct {
+		Value string `json:"value"`
+	}
+	if err := r.decode(&tag); err != nil {
+		return "", err
+	}
+	if tag.Value == "" {
+		return "", errBadRequest("Tag value is required")
+	}
+	return tag.Value, nil
+}
+
+// tagIndex returns the position of a tag in a document's tags, or -1 if it is not present.
+func tagIndex(holder map[string]interface{}, tag string) int {
+	tags, _ := holder["tags"].([]interface{})
+	for i, existing := range tags {
+		if existing == tag {
+			return i
+		}
+	}
+	return -1
+}
+
+// addTag adds a tag, which must not already be present.
+func addTag(holder map[string]interface{}, tag string) error {
+	if tagIndex(holder, tag) >= 0 {
+		return errTagExists(tag)
+	}
+
+	appendField(holder, "tags", tag)
+	return nil
+}
+
+// renameTag changes the value of a tag, which must be present, to one that is not.
+func renameTag(holder map[string]interface{}, tag, newTag string) error {
+	i := tagIndex(holder, tag)
+	if i < 0 {
+		return errTagMissing(tag)
+	}
+	if tag != newTag && tagIndex(holder, newTag) >= 0 {
+		return errTagExists(newTag)
+	}
+
+	holder["tags"].([]interface{})[i] = newTag
+	return nil
+}
+
+// deleteTag removes a tag, which must be present.
+func deleteTag(holder map[string]interface{}, tag string) error {
+	i := tagIndex(holder, tag)
+	if i < 0 {
+		return errTagMissing(tag)
+	}
+
+	tags := holder["tags"].([]interface{})
+	holder["tags"] = append(tags[:i:i], tags[i+1:]...)
+	return nil
+}
+
+func errTagExists(tag string) error {
+	return &api.Error{StatusCode: 409, Message: "Tag " + tag + " already exists"}
+}
+
+func errTagMissing(tag string) error {
+	return &api.Error{StatusCode: 404, Message: "Tag " + tag + " not found"}
+}
@@ -0,0 +1,227 @@
+package apitest
+
+import (
+	"strings"
+
+	"flywheel.io/sdk/api"
+)
+
+// DatabaseVersion is the schema level reported by the version route.
+const DatabaseVersion = 43
+
+// routeUsers handles the user routes.
+// Only the current user's own document includes their API key.
+func (s *Server) routeUsers(r *request) (interface{}, error) {
+	switch {
+	case len(r.path) == 1 && r.method == "GET":
+		users := s.list("users", nil)
+		for i, user := range users {
+			users[i] = withoutKey(user)
+		}
+		return users, nil
+
+	case len(r.path) == 1 && r.method == "POST":
+		return s.addUser(r)
+
+	case len(r.path) == 2 && r.path[1] == "self" && r.method == "GET":
+		return s.get("users", r.user)
+
+	case len(r.path) == 2 && r.method == "GET":
+		user, err := s.get("users", r.path[1])
+		if err != nil {
+			return nil, err
+		}
+		return withoutKey(user), nil
+
+	case len(r.path) == 2 && r.method == "PUT":
+		user, err := s.get("users", r.path[1])
+		if err != nil {
+			return nil, err
+		}
+		var mod document
+		if err := r.decode(&mod); err != nil {
+			return nil, err
+		}
+		delete(mod, "api_key")
+		s.merge(user, mod)
+		return modifiedResponse(1), nil
+
+	case len(r.path) == 2 && r.method == "DELETE":
+		if r.path[1] == r.user {
+			return nil, &api.Error{StatusCode: 400, Message: "Users cannot delete themselves"}
+		}
+		if !s.remove("users", r.path[1]) {
+			return nil, errMissing("users", r.path[1])
+		}
+		return deletedResponse(1), nil
+	}
+
+	return nil, errNotFound()
+}
+
+// withoutKey returns a user as seen by others.
+func withoutKey(user document) document {
+	result := copyDocument(user)
+	delete(result, "api_key")
+	return result
+}
+
+// addUser creates a user, whose Id is required and conventionally their email address.
+func (s *Server) addUser(r *request) (interface{}, error) {
+	var user document
+	if err := r.decode(&user); err != nil {
+		return nil, err
+	}
+
+	id, _ := user["_id"].(string)
+	if id == "" {
+		return nil, errBadRequest("User Id is required")
+	}
+	if _, exists := s.docs["users"][id]; exists {
+		return nil, &api.Error{StatusCode: 409, Message: "User " + id + " already exists"}
+	}
+	delete(user, "api_key")
+
+	now := s.now()
+	user["created"] = now
+	user["modified"] = now
+	user["avatars"] = map[string]interface{}{}
+
+	s.insert("users", id, user)
+	return idResponse(id), nil
+}
+
+// getConfig returns a server configuration like that of a development instance.
+func (s *Server) getConfig(r *request) (interface{}, error) {
+	if len(r.path) != 1 || r.method != "GET" {
+		return nil, errNotFound()
+	}
+
+	return &api.Config{
+		Auth: map[string]interface{}{
+			"auth_type": "google",
+			"client_id": "apitest",
+		},
+		Site: map[string]interface{}{
+			"id":   "local",
+			"name": "Local",
+		},
+		Created:  s.created,
+		Modified: s.created,
+	}, nil
+}
+
+// getVersion returns the version of the fake's schema.
+func (s *Server) getVersion(r *request) (interface{}, error) {
+	if len(r.path) != 1 || r.method != "GET" {
+		return nil, errNotFound()
+	}
+
+	return &api.Version{Database: DatabaseVersion}, nil
+}
+
+// routeSearch handles the data explorer search route.
+//
+// Instead of Elasticsearch, this matches the search string as a case-insensitive substring of names, and ignores filters.
+func (s *Server) routeSearch(r *request) (interface{}, error) {
+	if len(r.path) != 2 || r.path[1] != "search" || r.method != "POST" {
+		return nil, errNotFound()
+	}
+
+	var query *api.SearchQuery
+	if err := r.decode(&query); err != nil {
+		return nil, err
+	}
+	if query == nil || query.ReturnType == "" {
+		return nil, errBadRequest("Search return type is required")
+	}
+
+	search := strings.ToLower(query.SearchString)
+	matches := func(name interface{}) bool {
+		label, _ := name.(string)
+		return strings.Contains(strings.ToLower(label), search)
+	}
+
+	results := []*api.SearchResponse{}
+
+	switch query.ReturnType {
+	case api.SessionString:
+		for _, session := range s.list("sessions", nil) {
+			if matches(session["label"]) || s.anyAcquisitionMatches(session, matches) {
+				results = append(results, s.searchResult(session["_id"].(string), "sessions", session))
+			}
+		}
+
+	case api.AcquisitionString:
+		for _, acquisition := range s.list("acquisitions", nil) {
+			if matches(acquisition["label"]) {
+				results = append(results, s.searchResult(acquisition["_id"].(string), "acquisitions", acquisition))
+			}
+		}
+
+	case api.FileString:
+		for _, kind := range []string{"projects", "sessions", "acquisitions"} {
+			for _, doc := range s.list(kind, nil) {
+				files, _ := doc["files"].([]interface{})
+				for _, raw := range files {
+					file := raw.(map[string]interface{})
+					if !matches(file["name"]) && !matches(doc["label"]) {
+						continue
+					}
+
+					result := s.searchResult(newId(), kind, doc)
+					convert(file, &result.Source.File)
+					result.Source.Parent = &api.ParentSearchResponse{
+						Type: strings.TrimSuffix(kind, "s"),
+						Id:   doc["_id"].(string),
+					}
+					results = append(results, result)
+				}
+			}
+		}
+	}
+
+	return &api.SearchResponseList{Results: results}, nil
+}
+
+// anyAcquisitionMatches reports whether any acquisition of a session has a matching name.
+func (s *Server) anyAcquisitionMatches(doc document, matches func(interface{}) bool) bool {
+	for _, child := range s.list("acquisitions", fieldEquals("session", doc["_id"].(string))) {
+		if matches(child["label"]) {
+			return true
+		}
+	}
+	return false
+}
+
+// searchResult describes a container and its ancestors, as a search result.
+func (s *Server) searchResult(id, kind string, doc document) *api.SearchResponse {
+	source := &api.SourceResponse{}
+
+	// Walk up the hierarchy, filling in each level
+	for doc != nil {
+		switch kind {
+		case "acquisitions":
+			convert(doc, &source.Acquisition)
+		case "sessions":
+			convert(doc, &source.Session)
+			if subject, ok := doc["subject"].(map[string]interface{}); ok {
+				convert(subject, &source.Subject)
+			}
+		case "projects":
+			convert(doc, &source.Project)
+			convert(doc["permissions"], &source.Permissions)
+		case "groups":
+			convert(doc, &source.Group)
+		}
+
+		parent, ok := parentField[kind]
+		if !ok {
+			break
+		}
+		parentId, _ := doc[parent.field].(string)
+		kind, doc = parent.kind, s.docs[parent.kind][parentId]
+	}
+
+	return &api.SearchResponse{Id: id, Source: source}
+}
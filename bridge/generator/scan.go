@@ -192,6 +192,9 @@ func GetRelevantFunctionSignatures(path string) (*token.FileSet, []*ast.FuncDecl
 			// JobState enum
 			"ChangeJobState",
 
+			// AccessLevel enum
+			"GetEffectiveAccess",
+
 			// map string -> interface
 			"ProposeBatch",
 
@@ -204,6 +207,65 @@ func GetRelevantFunctionSignatures(path string) (*token.FileSet, []*ast.FuncDecl
 			// Doesn't detect map[string]interface{} return
 			"GetGearInvocation",
 
+			// context.Context parameter
+			"WithContext",
+
+			// io.Writer parameter
+			"DownloadRange",
+			"DownloadBulkTar",
+			"DownloadBulkTarSimple",
+
+			// Callback parameter
+			"Walk",
+
+			// Iterator return
+			"IterateContainers",
+
+			// ContainerRef parameter; the per-type functions are bridged instead
+			"GetContainer",
+			"ModifyContainer",
+			"DeleteContainer",
+			"SetContainerInfo",
+			"ReplaceContainerInfo",
+			"DeleteContainerInfoFields",
+			"AddContainerNote",
+			"GetContainerNote",
+			"ModifyContainerNote",
+			"DeleteContainerNote",
+			"AddContainerTag",
+			"RenameContainerTag",
+			"DeleteContainerTag",
+			"UploadToContainer",
+			"DownloadFromContainer",
+			"ModifyContainerFile",
+			"SetContainerFileInfo",
+			"ReplaceContainerFileInfo",
+			"DeleteContainerFileInfoFields",
+			"RenameContainerFile",
+			"ReplaceContainerFile",
+			"DeleteContainerFile",
+			"AddContainerFileTag",
+			"RenameContainerFileTag",
+			"DeleteContainerFileTag",
+			"UploadFileToContainer",
+			"DownloadFileFromContainer",
+			"ReplaceFileInContainer",
+			"DownloadFromContainerResumable",
+			"DownloadFileFromContainerResumable",
+			"DownloadContainerFileRange",
+
+			// Per-session results, which hold errors
+			"RenameSubject",
+			"MergeSubjects",
+			"SetSubjectInfo",
+			"ReplaceSubjectInfo",
+			"DeleteSubjectInfoFields",
+
+			// Per-target results, which hold errors
+			"BulkTag",
+			"UploadDirectory",
+			"Export",
+
 			// Progress reporting
 			"Upload",
 			"UploadSimple",
@@ -211,12 +273,36 @@ func GetRelevantFunctionSignatures(path string) (*token.FileSet, []*ast.FuncDecl
 			"UploadToSession",
 			"UploadToAcquisition",
 			"UploadToCollection",
+			"ReplaceProjectFile",
+			"ReplaceSessionFile",
+			"ReplaceAcquisitionFile",
+			"ReplaceCollectionFile",
 			"Download",
 			"DownloadSimple",
+			"DownloadResumable",
+			"DownloadResumableSimple",
+			"ExtractBulkDownload",
+			"ExtractBulkDownloadSimple",
 			"DownloadFromProject",
 			"DownloadFromSession",
 			"DownloadFromAcquisition",
 			"DownloadFromCollection",
+			"UploadInputsToProjectAnalysis",
+			"UploadInputsToSessionAnalysis",
+			"UploadInputsToAcquisitionAnalysis",
+			"UploadInputsToCollectionAnalysis",
+			"UploadOutputsToProjectAnalysis",
+			"UploadOutputsToSessionAnalysis",
+			"UploadOutputsToAcquisitionAnalysis",
+			"UploadOutputsToCollectionAnalysis",
+			"DownloadInputFromProjectAnalysis",
+			"DownloadInputFromSessionAnalysis",
+			"DownloadInputFromAcquisitionAnalysis",
+			"DownloadInputFromCollectionAnalysis",
+			"DownloadOutputFromProjectAnalysis",
+			"DownloadOutputFromSessionAnalysis",
+			"DownloadOutputFromAcquisitionAnalysis",
+			"DownloadOutputFromCollectionAnalysis",
 		}
 		if stringInSlice(name, blacklist) {
 			return false
@@ -1,7 +1,11 @@
 package tests
 
 import (
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
 	"sync"
 	"testing"
 
@@ -9,6 +13,7 @@ import (
 	"github.com/smartystreets/gunit"
 
 	"flywheel.io/sdk/api"
+	"flywheel.io/sdk/apitest"
 )
 
 /*
@@ -26,12 +31,11 @@ import (
 	> which uses t.Parallel() under the hood for every test case. - @mdwhatcott
 	> https://github.com/smartystreets/goconvey/issues/360
 
-	For goal #4, my plan is to incorporate go-vcr:
+	For goal #4, each test's requests can be recorded to a cassette and replayed later, in the spirit of go-vcr:
 	https://github.com/dnaeon/go-vcr
 
-	The implementation throws requests in YAML files, which... eh, let's try it maybe.
-	There will have to be some setup trickery to transparently hit live or recorded.
-	I think the vcr transport should handle that.
+	Cassettes are JSON rather than YAML, and are handled by apitest.Recorder; see SdkTestVCR.
+	Randomly-generated names are masked when matching requests, so tests need no changes to replay.
 
 
 	Test requirements:
@@ -66,11 +70,15 @@ func TestSuite(t *testing.T) {
 	//
 	// In an attempt to combat this, let's query to the API once, ignoring results and any errors.
 	// Hopefully, this moves timing to the suite and out of the individual tests.
-	workAroundClient := makeClient()
-	// begin := time.Now()
-	workAroundClient.GetCurrentUser()
-	// duration := time.Since(begin)
-	// PrintFormat("Connecting to the API took " + duration.String())
+	//
+	// When replaying, there is no API to wait for.
+	if os.Getenv(SdkTestVCR) != string(apitest.ModeReplay) {
+		workAroundClient := makeClient()
+		// begin := time.Now()
+		workAroundClient.GetCurrentUser()
+		// duration := time.Since(begin)
+		// PrintFormat("Connecting to the API took " + duration.String())
+	}
 
 	gunit.Run(new(F), t)
 }
@@ -80,11 +88,15 @@ type F struct {
 	*gunit.Fixture
 
 	*api.Client
+
+	// Records or replays this test's requests, if SdkTestVCR is set.
+	recorder *apitest.Recorder
 }
 
 const (
 	// SdkTestMode is the environment variable that sets the test mode.
 	// Valid values are "unit" and "integration".
+	// Unit mode runs against an in-memory fake of the API; see the apitest package.
 	SdkTestMode = "SdkTestMode"
 
 	// SdkTestKey is the environment variable that sets the test API key.
@@ -97,13 +109,28 @@ const (
 	// No affect in unit test mode.
 	SdkProtocolKey = "SdkTestProtocol"
 
+	// SdkTestVCR is the environment variable that records or replays each test's requests.
+	// Valid values are "record" and "replay"; when unset, requests are neither recorded nor replayed.
+	// Each test has its own cassette in CassetteDir. Replay mode needs no API.
+	SdkTestVCR = "SdkTestVCR"
+
+	// CassetteDir is where cassettes are kept, relative to this package.
+	CassetteDir = "cassettes"
+
 	DefaultMode     = "integration"
 	DefaultKey      = "localhost:8443:change-me"
 	DefaultProtocol = "https"
 )
 
+// randomNames matches the names generated by RandString and RandStringLower, so that cassettes replay despite them.
+var randomNames = regexp.MustCompile(`\b` + randPrefix + `[A-Za-z]{10}\b`)
+
+// The in-memory API used in unit mode, shared by all clients.
+var serverOnce sync.Once
+var server *httptest.Server
+
 // makeClient reads settings from the environment and returns the corresponding client
-func makeClient() *api.Client {
+func makeClient(options ...api.ApiKeyClientOption) *api.Client {
 	mode, modeSet := os.LookupEnv(SdkTestMode)
 
 	if !modeSet {
@@ -113,7 +140,11 @@ func makeClient() *api.Client {
 	var client *api.Client
 
 	if mode == "unit" {
-		panic("Unit test mode is not supported yet")
+		// Serve an in-memory fake of the API for the life of the test process
+		serverOnce.Do(func() {
+			server = httptest.NewServer(apitest.NewServer())
+		})
+		client = apitest.NewClient(server, options...)
 
 	} else if mode == "integration" {
 		key, keySet := os.LookupEnv(SdkTestKey)
@@ -128,12 +159,13 @@ func makeClient() *api.Client {
 		}
 
 		if protocol == "https" {
-			client = api.NewApiKeyClient(key, api.InsecureNoSSLVerification)
+			options = append([]api.ApiKeyClientOption{api.InsecureNoSSLVerification}, options...)
 		} else if protocol == "http" {
-			client = api.NewApiKeyClient(key, api.InsecureNoSSLVerification, api.InsecureUsePlaintext)
+			options = append([]api.ApiKeyClientOption{api.InsecureNoSSLVerification, api.InsecureUsePlaintext}, options...)
 		} else {
 			panic("Protocol must be http or https, was " + protocol)
 		}
+		client = api.NewApiKeyClient(key, options...)
 
 	} else {
 		panic("Unsupported test mode " + mode)
@@ -142,6 +174,20 @@ func makeClient() *api.Client {
 	return client
 }
 
+// testApiKey returns the key that makeClient uses, so that recordings can scrub it.
+func testApiKey() string {
+	if os.Getenv(SdkTestMode) == "unit" {
+		return apitest.RootKey
+	}
+
+	key, keySet := os.LookupEnv(SdkTestKey)
+	if !keySet {
+		key = DefaultKey
+	}
+	_, _, key, _ = api.ParseApiKey(key)
+	return key
+}
+
 // Re-use state: clients are safe for concurrent use and are stateless.
 var once sync.Once
 var client *api.Client
@@ -155,6 +201,21 @@ func (t *F) Setup() {
 	t.AddFatalAssertion(ShouldNotBeNil)
 	t.AddFatalAssertion(ShouldHaveLength)
 
+	// Recording or replaying needs a client per test, so that each test has its own cassette
+	mode, vcr := os.LookupEnv(SdkTestVCR)
+	if vcr {
+		cassette := filepath.Join(CassetteDir, strings.Replace(t.Name(), "/", "_", -1)+".json")
+
+		var err error
+		t.recorder, err = apitest.NewRecorder(apitest.Mode(mode), cassette)
+		t.So(err, ShouldBeNil)
+		t.recorder.Variables = []*regexp.Regexp{randomNames}
+		t.recorder.ApiKey = testApiKey()
+
+		t.Client = makeClient(api.UseMiddleware(t.recorder.Middleware))
+		return
+	}
+
 	once.Do(func() {
 		client = makeClient()
 	})
@@ -162,6 +223,13 @@ func (t *F) Setup() {
 	t.Client = client
 }
 
+// Teardown saves the test's cassette, if recording. Runs once per test.
+func (t *F) Teardown() {
+	if t.recorder != nil {
+		t.So(t.recorder.Close(), ShouldBeNil)
+	}
+}
+
 /*
 // An example test:
 func (t *F) SkipTestExample() {
@@ -0,0 +1,156 @@
+package tests
+
+import (
+	"net/http"
+	"time"
+
+	. "github.com/smartystreets/assertions"
+
+	"flywheel.io/sdk/api"
+)
+
+func (t *F) TestSessionAnalyses() {
+	_, _, sessionId := t.createTestSession()
+
+	analysis := &api.Analysis{
+		Name:        RandString(),
+		Description: RandString(),
+	}
+
+	// Add
+	analysisId, _, err := t.AddSessionAnalysis(sessionId, analysis)
+	t.So(err, ShouldBeNil)
+
+	// Get
+	rAnalysis, _, err := t.GetSessionAnalysis(sessionId, analysisId)
+	t.So(err, ShouldBeNil)
+	t.So(rAnalysis.Id, ShouldEqual, analysisId)
+	t.So(rAnalysis.Name, ShouldEqual, analysis.Name)
+	t.So(rAnalysis.Description, ShouldEqual, analysis.Description)
+	t.So(rAnalysis.Parent, ShouldResemble, &api.ContainerReference{Id: sessionId, Type: "session"})
+	t.So(rAnalysis.JobId, ShouldBeEmpty)
+	now := time.Now()
+	t.So(*rAnalysis.Created, ShouldHappenBefore, now)
+	t.So(*rAnalysis.Modified, ShouldHappenBefore, now)
+
+	// Get all
+	analyses, _, err := t.GetSessionAnalyses(sessionId)
+	t.So(err, ShouldBeNil)
+	t.So(analyses, ShouldHaveLength, 1)
+	t.So(analyses[0].Id, ShouldEqual, analysisId)
+
+	// Inputs and outputs, which may share names
+	input := "Turning and turning in the widening gyre"
+	output := "The falcon cannot hear the falconer;"
+	uploadInput := func(id string, files ...*api.UploadSource) (chan int64, chan error) {
+		return t.UploadInputsToSessionAnalysis(id, analysisId, files...)
+	}
+	uploadOutput := func(id string, files ...*api.UploadSource) (chan int64, chan error) {
+		return t.UploadOutputsToSessionAnalysis(id, analysisId, files...)
+	}
+	t.uploadText(uploadInput, sessionId, "yeats.txt", input)
+	t.uploadText(uploadOutput, sessionId, "yeats.txt", output)
+
+	rAnalysis, _, err = t.GetSessionAnalysis(sessionId, analysisId)
+	t.So(err, ShouldBeNil)
+	t.So(rAnalysis.Inputs, ShouldHaveLength, 1)
+	t.So(rAnalysis.Inputs[0].Name, ShouldEqual, "yeats.txt")
+	t.So(rAnalysis.Inputs[0].Size, ShouldEqual, len(input))
+	t.So(rAnalysis.Outputs, ShouldHaveLength, 1)
+	t.So(rAnalysis.Outputs[0].Size, ShouldEqual, len(output))
+
+	downloadInput := func(id, filename string, dest *api.DownloadSource) (chan int64, chan error) {
+		return t.DownloadInputFromSessionAnalysis(id, analysisId, filename, dest)
+	}
+	downloadOutput := func(id, filename string, dest *api.DownloadSource) (chan int64, chan error) {
+		return t.DownloadOutputFromSessionAnalysis(id, analysisId, filename, dest)
+	}
+	t.downloadText(downloadInput, sessionId, "yeats.txt", input)
+	t.downloadText(downloadOutput, sessionId, "yeats.txt", output)
+
+	// Add note
+	text := RandString()
+	_, err = t.AddSessionAnalysisNote(sessionId, analysisId, text)
+	t.So(err, ShouldBeNil)
+	rAnalysis, _, err = t.GetSessionAnalysis(sessionId, analysisId)
+	t.So(err, ShouldBeNil)
+	t.So(rAnalysis.Notes, ShouldHaveLength, 1)
+	t.So(rAnalysis.Notes[0].Text, ShouldEqual, text)
+
+	// Delete
+	_, err = t.DeleteSessionAnalysis(sessionId, analysisId)
+	t.So(err, ShouldBeNil)
+	_, _, err = t.GetSessionAnalysis(sessionId, analysisId)
+	t.So(api.IsNotFound(err), ShouldBeTrue)
+	analyses, _, err = t.GetSessionAnalyses(sessionId)
+	t.So(err, ShouldBeNil)
+	t.So(analyses, ShouldBeEmpty)
+}
+
+func (t *F) TestAnalysisWithJob() {
+	_, _, _, acquisitionId := t.createTestAcquisition()
+	gearId := t.createTestGear()
+
+	poem := "Mere anarchy is loosed upon the world,"
+	t.uploadText(t.UploadToAcquisition, acquisitionId, "yeats.txt", poem)
+
+	analysis := &api.Analysis{
+		Name: RandString(),
+	}
+	job := &api.Job{
+		GearId: gearId,
+		Inputs: map[string]interface{}{
+			"any-file": &api.FileReference{
+				Id:   acquisitionId,
+				Type: "acquisition",
+				Name: "yeats.txt",
+			},
+		},
+	}
+
+	analysisId, _, err := t.AddAcquisitionAnalysisWithJob(acquisitionId, analysis, job)
+	t.So(err, ShouldBeNil)
+
+	rAnalysis, _, err := t.GetAcquisitionAnalysis(acquisitionId, analysisId)
+	t.So(err, ShouldBeNil)
+	t.So(rAnalysis.JobId, ShouldNotBeEmpty)
+
+	// The job writes its outputs to the analysis
+	rJob, _, err := t.GetJob(rAnalysis.JobId)
+	t.So(err, ShouldBeNil)
+	t.So(rJob.GearId, ShouldEqual, gearId)
+	t.So(rJob.Destination, ShouldResemble, &api.ContainerReference{Id: analysisId, Type: "analysis"})
+}
+
+func (t *F) TestAnalysesOnEachContainer() {
+	_, projectId, sessionId, acquisitionId := t.createTestAcquisition()
+	collectionId, _, err := t.AddCollection(&api.Collection{Name: RandString()})
+	t.So(err, ShouldBeNil)
+
+	containers := []struct {
+		id   string
+		add  func(string, *api.Analysis) (string, *http.Response, error)
+		list func(string) ([]*api.Analysis, *http.Response, error)
+	}{
+		{projectId, t.AddProjectAnalysis, t.GetProjectAnalyses},
+		{sessionId, t.AddSessionAnalysis, t.GetSessionAnalyses},
+		{acquisitionId, t.AddAcquisitionAnalysis, t.GetAcquisitionAnalyses},
+		{collectionId, t.AddCollectionAnalysis, t.GetCollectionAnalyses},
+	}
+
+	for _, x := range containers {
+		analysisId, _, err := x.add(x.id, &api.Analysis{Name: RandString()})
+		t.So(err, ShouldBeNil)
+
+		analyses, _, err := x.list(x.id)
+		t.So(err, ShouldBeNil)
+		t.So(analyses, ShouldHaveLength, 1)
+		t.So(analyses[0].Id, ShouldEqual, analysisId)
+	}
+
+	// Analyses belong to one container
+	analyses, _, err := t.GetProjectAnalyses(projectId)
+	t.So(err, ShouldBeNil)
+	_, _, err = t.GetSessionAnalysis(sessionId, analyses[0].Id)
+	t.So(api.IsNotFound(err), ShouldBeTrue)
+}
@@ -0,0 +1,57 @@
+package tests
+
+import (
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/smartystreets/assertions"
+
+	"flywheel.io/sdk/api"
+	"flywheel.io/sdk/apitest"
+)
+
+func (t *F) TestApitestServer() {
+	ts := httptest.NewServer(apitest.NewServer())
+	defer ts.Close()
+
+	// Each server has its own state
+	client := apitest.NewClient(ts)
+	groups, _, err := client.GetAllGroups()
+	t.So(err, ShouldBeNil)
+	t.So(groups, ShouldBeEmpty)
+
+	user, _, err := client.GetCurrentUser()
+	t.So(err, ShouldBeNil)
+	t.So(user.Id, ShouldEqual, apitest.RootUserId)
+	t.So(user.ApiKey.Key, ShouldEqual, apitest.RootKey)
+
+	// Other keys are rejected
+	badClient := api.NewApiKeyClient(strings.TrimPrefix(ts.URL, "http://")+":not-a-key", api.InsecureUsePlaintext)
+	_, _, err = badClient.GetCurrentUser()
+	t.So(api.IsUnauthorized(err), ShouldBeTrue)
+
+	// Parents must exist
+	_, _, err = client.AddProject(&api.Project{Name: "orphan", GroupId: "does-not-exist"})
+	t.So(api.IsNotFound(err), ShouldBeTrue)
+
+	// Deletes cascade
+	groupId := RandStringLower()
+	_, _, err = client.AddGroup(&api.Group{Id: groupId})
+	t.So(err, ShouldBeNil)
+	projectId, _, err := client.AddProject(&api.Project{Name: "child", GroupId: groupId})
+	t.So(err, ShouldBeNil)
+
+	_, err = client.DeleteGroup(groupId)
+	t.So(err, ShouldBeNil)
+	_, _, err = client.GetProject(projectId)
+	t.So(api.IsNotFound(err), ShouldBeTrue)
+
+	// Duplicate tags conflict
+	groupId = RandStringLower()
+	_, _, err = client.AddGroup(&api.Group{Id: groupId})
+	t.So(err, ShouldBeNil)
+	_, err = client.AddGroupTag(groupId, "tag")
+	t.So(err, ShouldBeNil)
+	_, err = client.AddGroupTag(groupId, "tag")
+	t.So(api.IsConflict(err), ShouldBeTrue)
+}
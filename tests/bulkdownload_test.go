@@ -0,0 +1,176 @@
+package tests
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+
+	. "github.com/smartystreets/assertions"
+
+	"flywheel.io/sdk/api"
+)
+
+// readTar returns the contents of each file in a tar archive, by path.
+func (t *F) readTar(raw []byte) map[string]string {
+	result := map[string]string{}
+	archive := tar.NewReader(bytes.NewReader(raw))
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return result
+		}
+		t.So(err, ShouldBeNil)
+		content, err := ioutil.ReadAll(archive)
+		t.So(err, ShouldBeNil)
+		result[header.Name] = string(content)
+	}
+}
+
+// baseNames returns the contents of each file in a tar archive, by file name.
+func (t *F) baseNames(raw []byte) map[string]string {
+	result := map[string]string{}
+	for name, content := range t.readTar(raw) {
+		result[path.Base(name)] = content
+	}
+	return result
+}
+
+func (t *F) TestBulkDownload() {
+	_, _, sessionId, acquisitionId := t.createTestAcquisition()
+	session, _, err := t.GetSession(sessionId)
+	t.So(err, ShouldBeNil)
+	acquisition, _, err := t.GetAcquisition(acquisitionId)
+	t.So(err, ShouldBeNil)
+
+	sessionRef := &api.ContainerRef{Type: "session", Id: sessionId}
+	acquisitionRef := &api.ContainerRef{Type: "acquisition", Id: acquisitionId}
+	files := []struct {
+		ref           *api.ContainerRef
+		name, content string
+	}{
+		{sessionRef, "notes.txt", "I will arise and go now, and go to Innisfree,"},
+		{acquisitionRef, "scan.nii", "And a small cabin build there, of clay and wattles made;"},
+		{acquisitionRef, "log.txt", "Nine bean-rows will I have there, a hive for the honey-bee,"},
+	}
+	for _, x := range files {
+		progress, result := t.UploadToContainer(x.ref, UploadSourceFromString(x.name, x.content))
+		drainProgress(progress)
+		t.So(<-result, ShouldBeNil)
+	}
+	_, err = t.AddAcquisitionFileTag(acquisitionId, "log.txt", "verbose")
+	t.So(err, ShouldBeNil)
+
+	// A session ticket covers its acquisitions
+	ticket, _, err := t.CreateBulkDownloadTicket(&api.BulkDownloadRequest{
+		Nodes: []*api.BulkDownloadNode{{Level: "session", Id: sessionId}},
+	})
+	t.So(err, ShouldBeNil)
+	t.So(ticket.Ticket, ShouldNotBeEmpty)
+	t.So(ticket.FileCount, ShouldEqual, 3)
+	t.So(ticket.Size, ShouldEqual, len(files[0].content)+len(files[1].content)+len(files[2].content))
+
+	var buffer bytes.Buffer
+	progress, result := t.DownloadBulkTarSimple(ticket.Ticket, &buffer)
+	t.So(<-result, ShouldBeNil)
+	t.So(drainProgress(progress), ShouldEqual, int64(buffer.Len()))
+
+	archive := t.readTar(buffer.Bytes())
+	t.So(archive, ShouldHaveLength, 3)
+	for name := range archive {
+		t.So(name, ShouldContainSubstring, "/"+session.Name+"/")
+		if path.Base(name) != "notes.txt" {
+			t.So(path.Dir(name), ShouldEndWith, "/"+acquisition.Name)
+		}
+	}
+	t.So(t.baseNames(buffer.Bytes()), ShouldResemble, map[string]string{
+		"notes.txt": files[0].content,
+		"scan.nii":  files[1].content,
+		"log.txt":   files[2].content,
+	})
+
+	// Filters by type and tag
+	download := func(request *api.BulkDownloadRequest) map[string]string {
+		ticket, _, err := t.CreateBulkDownloadTicket(request)
+		t.So(err, ShouldBeNil)
+		var buffer bytes.Buffer
+		progress, result := t.DownloadBulkTarSimple(ticket.Ticket, &buffer)
+		drainProgress(progress)
+		t.So(<-result, ShouldBeNil)
+		return t.baseNames(buffer.Bytes())
+	}
+	nodes := []*api.BulkDownloadNode{{Level: "session", Id: sessionId}}
+
+	t.So(download(&api.BulkDownloadRequest{
+		Nodes:   nodes,
+		Filters: []*api.BulkDownloadFilter{{Types: &api.BulkDownloadFilterValue{Plus: []string{"nifti"}}}},
+	}), ShouldResemble, map[string]string{"scan.nii": files[1].content})
+
+	t.So(download(&api.BulkDownloadRequest{
+		Nodes:   nodes,
+		Filters: []*api.BulkDownloadFilter{{Tags: &api.BulkDownloadFilterValue{Minus: []string{"verbose"}}}},
+	}), ShouldResemble, map[string]string{"notes.txt": files[0].content, "scan.nii": files[1].content})
+
+	_, _, err = t.CreateBulkDownloadTicket(&api.BulkDownloadRequest{
+		Nodes:   nodes,
+		Filters: []*api.BulkDownloadFilter{{Types: &api.BulkDownloadFilterValue{Plus: []string{"dicom"}}}},
+	})
+	t.So(err, ShouldNotBeNil)
+
+	// A ticket for single files
+	t.So(download(&api.BulkDownloadRequest{
+		Files: []*api.BulkDownloadFile{
+			{ContainerType: "acquisition", ContainerId: acquisitionId, Name: "log.txt"},
+			{ContainerType: "session", ContainerId: sessionId, Name: "notes.txt"},
+		},
+	}), ShouldResemble, map[string]string{"log.txt": files[2].content, "notes.txt": files[0].content})
+
+	_, _, err = t.CreateBulkDownloadTicket(&api.BulkDownloadRequest{
+		Files: []*api.BulkDownloadFile{{ContainerType: "acquisition", ContainerId: acquisitionId, Name: "missing.txt"}},
+	})
+	t.So(err, ShouldNotBeNil)
+
+	// Extracting as the archive downloads
+	dest, err := ioutil.TempDir("", "sdk-test-")
+	t.So(err, ShouldBeNil)
+	defer os.RemoveAll(dest)
+
+	progress, result = t.ExtractBulkDownloadSimple(ticket.Ticket, dest)
+	t.So(<-result, ShouldBeNil)
+	t.So(drainProgress(progress), ShouldEqual, int64(buffer.Len()))
+
+	for name, content := range archive {
+		raw, err := ioutil.ReadFile(filepath.Join(dest, filepath.FromSlash(name)))
+		t.So(err, ShouldBeNil)
+		t.So(string(raw), ShouldEqual, content)
+	}
+
+	// Entries outside of dir are refused
+	for _, name := range []string{"../escape.txt", "a/../../escape.txt", "/escape.txt"} {
+		var raw bytes.Buffer
+		writer := tar.NewWriter(&raw)
+		t.So(writer.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: 4, Typeflag: tar.TypeReg}), ShouldBeNil)
+		_, err = writer.Write([]byte("evil"))
+		t.So(err, ShouldBeNil)
+		t.So(writer.Close(), ShouldBeNil)
+
+		handler, _ := failingHandler(0, 200, raw.String())
+		client, closeServer := makeTestServerClient(handler)
+		inner := filepath.Join(dest, "inner")
+		progress, result = client.ExtractBulkDownloadSimple("ticket", inner)
+		drainProgress(progress)
+		t.So(<-result, ShouldNotBeNil)
+		closeServer()
+
+		_, err = os.Stat(filepath.Join(dest, "escape.txt"))
+		t.So(os.IsNotExist(err), ShouldBeTrue)
+	}
+
+	// Unknown tickets
+	progress, result = t.DownloadBulkTarSimple(RandString(), ioutil.Discard)
+	drainProgress(progress)
+	t.So(<-result, ShouldNotBeNil)
+}
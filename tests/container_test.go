@@ -0,0 +1,125 @@
+package tests
+
+import (
+	. "github.com/smartystreets/assertions"
+
+	"flywheel.io/sdk/api"
+)
+
+func (t *F) TestContainers() {
+	groupId, projectId, sessionId, acquisitionId := t.createTestAcquisition()
+	collectionId, _, err := t.AddCollection(&api.Collection{Name: RandString()})
+	t.So(err, ShouldBeNil)
+
+	refs := []*api.ContainerRef{
+		{Type: "project", Id: projectId},
+		{Type: "session", Id: sessionId},
+		{Type: "acquisition", Id: acquisitionId},
+		{Type: "collection", Id: collectionId},
+	}
+
+	// The same code works on any type of container
+	for _, ref := range refs {
+		// Get and modify
+		name := RandString()
+		_, err = t.ModifyContainer(ref, &api.Container{Name: name})
+		t.So(err, ShouldBeNil)
+		container, _, err := t.GetContainer(ref)
+		t.So(err, ShouldBeNil)
+		t.So(container.Id, ShouldEqual, ref.Id)
+		t.So(container.Name, ShouldEqual, name)
+
+		// Notes and tags
+		_, err = t.AddContainerNote(ref, "note")
+		t.So(err, ShouldBeNil)
+		_, err = t.AddContainerTag(ref, "tag")
+		t.So(err, ShouldBeNil)
+		container, _, err = t.GetContainer(ref)
+		t.So(err, ShouldBeNil)
+		t.So(container.Notes, ShouldHaveLength, 1)
+		t.So(container.Tags, ShouldResemble, []string{"tag"})
+
+		_, err = t.ModifyContainerNote(ref, container.Notes[0].Id, "edited")
+		t.So(err, ShouldBeNil)
+		note, _, err := t.GetContainerNote(ref, container.Notes[0].Id)
+		t.So(err, ShouldBeNil)
+		t.So(note.Text, ShouldEqual, "edited")
+		_, err = t.RenameContainerTag(ref, "tag", "renamed")
+		t.So(err, ShouldBeNil)
+
+		// Files and their info
+		poem := "And what rough beast, its hour come round at last,"
+		progress, result := t.UploadToContainer(ref, UploadSourceFromString("yeats.txt", poem))
+		t.checkProgressChanEndsWith(progress, int64(len(poem)))
+		t.So(<-result, ShouldBeNil)
+
+		_, _, err = t.ModifyContainerFile(ref, "yeats.txt", &api.FileFields{Modality: "MR"})
+		t.So(err, ShouldBeNil)
+		_, err = t.SetContainerFileInfo(ref, "yeats.txt", map[string]interface{}{"a": 1, "b": 2})
+		t.So(err, ShouldBeNil)
+		_, err = t.DeleteContainerFileInfoFields(ref, "yeats.txt", []string{"a"})
+		t.So(err, ShouldBeNil)
+		_, err = t.AddContainerFileTag(ref, "yeats.txt", "poem")
+		t.So(err, ShouldBeNil)
+
+		container, _, err = t.GetContainer(ref)
+		t.So(err, ShouldBeNil)
+		t.So(container.Tags, ShouldResemble, []string{"renamed"})
+		t.So(container.Files, ShouldHaveLength, 1)
+		t.So(container.Files[0].Modality, ShouldEqual, "MR")
+		t.So(container.Files[0].Info, ShouldResemble, map[string]interface{}{"b": 2.0})
+		t.So(container.Files[0].Tags, ShouldResemble, []string{"poem"})
+
+		t.downloadText(func(id, filename string, dest *api.DownloadSource) (chan int64, chan error) {
+			return t.DownloadFromContainer(ref, filename, dest)
+		}, ref.Id, "yeats.txt", poem)
+
+		_, err = t.RenameContainerFile(ref, "yeats.txt", "second-coming.txt")
+		t.So(err, ShouldBeNil)
+		_, err = t.DeleteContainerFile(ref, "second-coming.txt")
+		t.So(err, ShouldBeNil)
+		_, err = t.DeleteContainerNote(ref, note.Id)
+		t.So(err, ShouldBeNil)
+		_, err = t.DeleteContainerTag(ref, "renamed")
+		t.So(err, ShouldBeNil)
+
+		container, _, err = t.GetContainer(ref)
+		t.So(err, ShouldBeNil)
+		t.So(container.Files, ShouldBeEmpty)
+		t.So(container.Notes, ShouldBeEmpty)
+		t.So(container.Tags, ShouldBeEmpty)
+	}
+
+	// Parents are filled in according to type
+	container, _, err := t.GetContainer(&api.ContainerRef{Type: "session", Id: sessionId})
+	t.So(err, ShouldBeNil)
+	t.So(container.GroupId, ShouldEqual, groupId)
+	t.So(container.ProjectId, ShouldEqual, projectId)
+	container, _, err = t.GetContainer(&api.ContainerRef{Type: "group", Id: groupId})
+	t.So(err, ShouldBeNil)
+	t.So(container.Id, ShouldEqual, groupId)
+
+	// Typed values may be used to modify type-specific fields
+	_, err = t.ModifyContainer(&api.ContainerRef{Type: "session", Id: sessionId}, &api.Session{Timezone: "Europe/Dublin"})
+	t.So(err, ShouldBeNil)
+	session, _, err := t.GetSession(sessionId)
+	t.So(err, ShouldBeNil)
+	t.So(session.Timezone, ShouldEqual, "Europe/Dublin")
+
+	// Delete
+	_, err = t.DeleteContainer(refs[2])
+	t.So(err, ShouldBeNil)
+	_, _, err = t.GetAcquisition(acquisitionId)
+	t.So(api.IsNotFound(err), ShouldBeTrue)
+
+	// Unknown types fail without a request
+	bad := &api.ContainerRef{Type: "subject", Id: sessionId}
+	_, _, err = t.GetContainer(bad)
+	t.So(err, ShouldNotBeNil)
+	_, err = t.AddContainerTag(bad, "tag")
+	t.So(err, ShouldNotBeNil)
+	progress, result := t.UploadToContainer(bad, UploadSourceFromString("yeats.txt", "text"))
+	for range progress {
+	}
+	t.So(<-result, ShouldNotBeNil)
+}
@@ -0,0 +1,48 @@
+package tests
+
+import (
+	"context"
+	"time"
+
+	. "github.com/smartystreets/assertions"
+)
+
+func (t *F) TestContext() {
+	// Default context
+	t.So(t.Context() == context.Background(), ShouldBeTrue)
+
+	// Cancelled context
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cClient := t.WithContext(ctx)
+	t.So(cClient.Context() == ctx, ShouldBeTrue)
+
+	_, _, err := cClient.GetCurrentUser()
+	t.So(err, ShouldNotBeNil)
+	t.So(err.Error(), ShouldContainSubstring, context.Canceled.Error())
+
+	// Original client is unaffected
+	_, _, err = t.GetCurrentUser()
+	t.So(err, ShouldBeNil)
+
+	// Cancelled uploads and downloads
+	source := UploadSourceFromString("yeats.txt", "Things fall apart; the centre cannot hold;")
+	_, result := cClient.UploadSimple("not-an-endpoint", nil, source)
+	t.So(<-result, ShouldEqual, context.Canceled)
+
+	_, dest := DownloadSourceToBuffer()
+	_, result = cClient.DownloadSimple("not-an-endpoint", dest)
+	err = <-result
+	t.So(err, ShouldNotBeNil)
+	t.So(err.Error(), ShouldContainSubstring, context.Canceled.Error())
+
+	// Expired deadline
+	ctx, cancel = context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+	_, _, err = t.WithContext(ctx).GetCurrentUser()
+	t.So(err, ShouldNotBeNil)
+	t.So(err.Error(), ShouldContainSubstring, context.DeadlineExceeded.Error())
+
+	t.So(func() { t.WithContext(nil) }, ShouldPanic)
+}
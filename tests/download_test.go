@@ -1,6 +1,10 @@
 package tests
 
 import (
+	"bytes"
+	"io/ioutil"
+	"os"
+
 	. "github.com/smartystreets/assertions"
 
 	"flywheel.io/sdk/api"
@@ -27,9 +31,12 @@ func (t *F) TestBadDownloads() {
 	buffer, source := DownloadSourceToBuffer()
 	_, result = t.DownloadSimple("not-an-endpoint", source)
 
-	// Could improve this in the future
 	err := <-result
-	t.So(err.Error(), ShouldEqual, "{\"status_code\": 404, \"message\": \"The resource could not be found.\"}")
+	t.So(err.Error(), ShouldEqual, "(404) The resource could not be found.")
+	t.So(api.IsNotFound(err), ShouldBeTrue)
+	aerr := err.(*api.Error)
+	t.So(aerr.Method, ShouldEqual, "GET")
+	t.So(aerr.URL, ShouldEndWith, "/api/not-an-endpoint")
 	t.So(buffer.String(), ShouldEqual, "")
 }
 
@@ -43,3 +50,101 @@ func (t *F) downloadText(fn func(string, string, *api.DownloadSource) (chan int6
 	t.So(<-resultChan, ShouldBeNil)
 	t.So(buffer.String(), ShouldEqual, text)
 }
+
+func (t *F) TestResumableDownload() {
+	_, projectId := t.createTestProject()
+	ref := &api.ContainerRef{Type: "project", Id: projectId}
+
+	poem := "Turning and turning in the widening gyre / The falcon cannot hear the falconer;"
+	progress, result := t.UploadToContainer(ref, UploadSourceFromString("yeats.txt", poem))
+	t.checkProgressChanEndsWith(progress, int64(len(poem)))
+	t.So(<-result, ShouldBeNil)
+
+	path := t.createTempFile("")
+	defer os.Remove(path)
+
+	// Fresh download
+	progress, result = t.DownloadFromContainerResumable(ref, "yeats.txt", path)
+	t.So(drainProgress(progress), ShouldEqual, len(poem))
+	t.So(<-result, ShouldBeNil)
+	raw, err := ioutil.ReadFile(path)
+	t.So(err, ShouldBeNil)
+	t.So(string(raw), ShouldEqual, poem)
+
+	// An interrupted download keeps what it has
+	t.So(ioutil.WriteFile(path, []byte(poem[:20]), 0600), ShouldBeNil)
+	err = t.DownloadFileFromContainerResumable(ref, "yeats.txt", path)
+	t.So(err, ShouldBeNil)
+	raw, err = ioutil.ReadFile(path)
+	t.So(err, ShouldBeNil)
+	t.So(string(raw), ShouldEqual, poem)
+
+	// So does one without a file to check against
+	url := "projects/" + projectId + "/files/yeats.txt"
+	t.So(ioutil.WriteFile(path, []byte(poem[:30]), 0600), ShouldBeNil)
+	progress, result = t.DownloadResumableSimple(url, path, nil)
+	t.So(drainProgress(progress), ShouldEqual, len(poem))
+	t.So(<-result, ShouldBeNil)
+	raw, err = ioutil.ReadFile(path)
+	t.So(err, ShouldBeNil)
+	t.So(string(raw), ShouldEqual, poem)
+
+	// Complete downloads are left as they are
+	progress, result = t.DownloadResumableSimple(url, path, nil)
+	drainProgress(progress)
+	t.So(<-result, ShouldBeNil)
+
+	// Files longer than expected start over
+	t.So(ioutil.WriteFile(path, []byte(poem+poem), 0600), ShouldBeNil)
+	progress, result = t.DownloadResumableSimple(url, path, nil)
+	drainProgress(progress)
+	t.So(<-result, ShouldBeNil)
+	raw, err = ioutil.ReadFile(path)
+	t.So(err, ShouldBeNil)
+	t.So(string(raw), ShouldEqual, poem)
+
+	// Corrupt downloads fail their hash check, and are removed
+	corrupt := []byte(poem[:20] + "X")
+	t.So(ioutil.WriteFile(path, corrupt, 0600), ShouldBeNil)
+	err = t.DownloadFileFromContainerResumable(ref, "yeats.txt", path)
+	t.So(err, ShouldNotBeNil)
+	_, err = os.Stat(path)
+	t.So(os.IsNotExist(err), ShouldBeTrue)
+
+	err = t.DownloadFileFromContainerResumable(ref, "missing.txt", path)
+	t.So(api.IsNotFound(err), ShouldBeTrue)
+}
+
+func (t *F) TestDownloadRange() {
+	_, projectId := t.createTestProject()
+	ref := &api.ContainerRef{Type: "project", Id: projectId}
+
+	poem := "The blood-dimmed tide is loosed, and everywhere"
+	progress, result := t.UploadToContainer(ref, UploadSourceFromString("yeats.txt", poem))
+	t.checkProgressChanEndsWith(progress, int64(len(poem)))
+	t.So(<-result, ShouldBeNil)
+
+	cases := []struct {
+		offset, length int64
+		expected       string
+	}{
+		{0, 9, poem[:9]},
+		{4, 5, poem[4:9]},
+		{10, -1, poem[10:]},
+		{40, 100, poem[40:]},
+		{5, 0, ""},
+		{100, 10, ""},
+	}
+	for _, x := range cases {
+		var buffer bytes.Buffer
+		n, err := t.DownloadContainerFileRange(ref, "yeats.txt", x.offset, x.length, &buffer)
+		t.So(err, ShouldBeNil)
+		t.So(n, ShouldEqual, len(x.expected))
+		t.So(buffer.String(), ShouldEqual, x.expected)
+	}
+
+	_, err := t.DownloadContainerFileRange(ref, "missing.txt", 0, 10, &bytes.Buffer{})
+	t.So(api.IsNotFound(err), ShouldBeTrue)
+	_, err = t.DownloadContainerFileRange(ref, "yeats.txt", -1, 10, &bytes.Buffer{})
+	t.So(err, ShouldNotBeNil)
+}
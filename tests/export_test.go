@@ -0,0 +1,166 @@
+package tests
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/smartystreets/assertions"
+
+	"flywheel.io/sdk/api"
+)
+
+// exportStatus returns the status of each file in a manifest, by slash-separated path relative to root.
+func exportStatus(root string, manifest *api.ExportManifest) map[string]string {
+	return manifestStatus(root, &api.UploadManifest{Files: manifest.Files})
+}
+
+func (t *F) TestExport() {
+	groupId, projectId, sessionId, acquisitionId := t.createTestAcquisition()
+	session, _, err := t.GetSession(sessionId)
+	t.So(err, ShouldBeNil)
+	acquisition, _, err := t.GetAcquisition(acquisitionId)
+	t.So(err, ShouldBeNil)
+
+	files := []struct {
+		ref           *api.ContainerRef
+		name, content string
+	}{
+		{&api.ContainerRef{Type: "project", Id: projectId}, "protocol.txt", "Turning and turning in the widening gyre"},
+		{&api.ContainerRef{Type: "session", Id: sessionId}, "notes.txt", "The falcon cannot hear the falconer;"},
+		{&api.ContainerRef{Type: "acquisition", Id: acquisitionId}, "scan.nii", "Things fall apart; the centre cannot hold;"},
+	}
+	for _, x := range files {
+		progress, result := t.UploadToContainer(x.ref, UploadSourceFromString(x.name, x.content))
+		drainProgress(progress)
+		t.So(<-result, ShouldBeNil)
+	}
+	_, _, err = t.ModifyContainerFile(files[2].ref, "scan.nii", &api.FileFields{Modality: "MR"})
+	t.So(err, ShouldBeNil)
+
+	dest, err := ioutil.TempDir("", "sdk-test-")
+	t.So(err, ShouldBeNil)
+	defer os.RemoveAll(dest)
+
+	// Export a project, by label path
+	sessionDir := filepath.Join(dest, session.Name)
+	acquisitionDir := filepath.Join(sessionDir, acquisition.Name)
+	localPaths := []string{
+		filepath.Join(dest, "protocol.txt"),
+		filepath.Join(sessionDir, "notes.txt"),
+		filepath.Join(acquisitionDir, "scan.nii"),
+	}
+	root := groupId + "/" + projectId
+
+	manifest, err := t.Export(root, dest, &api.ExportOptions{Sidecars: true, Workers: 2})
+	t.So(err, ShouldBeNil)
+	t.So(exportStatus(dest, manifest), ShouldResemble, map[string]string{
+		"protocol.txt":              api.ManifestDownloaded,
+		session.Name + "/notes.txt": api.ManifestDownloaded,
+		session.Name + "/" + acquisition.Name + "/scan.nii": api.ManifestDownloaded,
+	})
+	for i, x := range files {
+		raw, err := ioutil.ReadFile(localPaths[i])
+		t.So(err, ShouldBeNil)
+		t.So(string(raw), ShouldEqual, x.content)
+	}
+
+	// Sidecars hold each container's metadata
+	t.So(manifest.Sidecars, ShouldHaveLength, 3)
+	raw, err := ioutil.ReadFile(filepath.Join(acquisitionDir, api.ExportSidecarName))
+	t.So(err, ShouldBeNil)
+	var sidecar api.Acquisition
+	t.So(json.Unmarshal(raw, &sidecar), ShouldBeNil)
+	t.So(sidecar.Id, ShouldEqual, acquisitionId)
+	t.So(sidecar.Files, ShouldHaveLength, 1)
+	t.So(sidecar.Files[0].Modality, ShouldEqual, "MR")
+
+	// Incremental runs skip files that are up to date
+	manifest, err = t.Export(root, dest, &api.ExportOptions{Incremental: true})
+	t.So(err, ShouldBeNil)
+	t.So(manifest.Files, ShouldHaveLength, 3)
+	for _, file := range manifest.Files {
+		t.So(file.Status, ShouldEqual, api.ManifestSkipped)
+	}
+	t.So(manifest.Sidecars, ShouldBeEmpty)
+
+	t.So(ioutil.WriteFile(localPaths[1], []byte("changed"), 0644), ShouldBeNil)
+	manifest, err = t.Export(root, dest, &api.ExportOptions{Incremental: true})
+	t.So(err, ShouldBeNil)
+	t.So(exportStatus(dest, manifest)[session.Name+"/notes.txt"], ShouldEqual, api.ManifestDownloaded)
+	raw, err = ioutil.ReadFile(localPaths[1])
+	t.So(err, ShouldBeNil)
+	t.So(string(raw), ShouldEqual, files[1].content)
+
+	// Filters
+	filtered := func(options *api.ExportOptions) []string {
+		manifest, err := t.Export(root, dest, options)
+		t.So(err, ShouldBeNil)
+		var names []string
+		for _, file := range manifest.Files {
+			names = append(names, file.Name)
+		}
+		return names
+	}
+	t.So(filtered(&api.ExportOptions{Include: []string{"*.nii"}}), ShouldResemble, []string{"scan.nii"})
+	t.So(filtered(&api.ExportOptions{Exclude: []string{"*.nii", "notes*"}}), ShouldResemble, []string{"protocol.txt"})
+	t.So(filtered(&api.ExportOptions{Types: []string{"text"}}), ShouldHaveLength, 2)
+	t.So(filtered(&api.ExportOptions{Modalities: []string{"MR"}}), ShouldResemble, []string{"scan.nii"})
+
+	// Files named like a directory beside them are numbered
+	progress, result := t.UploadToContainer(files[1].ref, UploadSourceFromString(acquisition.Name, "Mere anarchy is loosed upon the world,"))
+	drainProgress(progress)
+	t.So(<-result, ShouldBeNil)
+
+	manifest, err = t.Export(root, dest, &api.ExportOptions{Include: []string{acquisition.Name, "scan.nii"}})
+	t.So(err, ShouldBeNil)
+	t.So(exportStatus(dest, manifest), ShouldResemble, map[string]string{
+		session.Name + "/" + acquisition.Name + "_1":        api.ManifestDownloaded,
+		session.Name + "/" + acquisition.Name + "/scan.nii": api.ManifestDownloaded,
+	})
+
+	// Siblings of the same label are told apart by Id
+	twinId, _, err := t.AddAcquisition(&api.Acquisition{Name: acquisition.Name, SessionId: sessionId})
+	t.So(err, ShouldBeNil)
+	twin := &api.ContainerRef{Type: "acquisition", Id: twinId}
+	progress, result = t.UploadToContainer(twin, UploadSourceFromString("scan.nii", "Surely some revelation is at hand;"))
+	drainProgress(progress)
+	t.So(<-result, ShouldBeNil)
+
+	twinDest, err := ioutil.TempDir("", "sdk-test-")
+	t.So(err, ShouldBeNil)
+	defer os.RemoveAll(twinDest)
+
+	manifest, err = t.Export(root, twinDest, &api.ExportOptions{Include: []string{"scan.nii"}, Workers: 2})
+	t.So(err, ShouldBeNil)
+	t.So(exportStatus(twinDest, manifest), ShouldResemble, map[string]string{
+		session.Name + "/" + acquisition.Name + "_" + acquisitionId + "/scan.nii": api.ManifestDownloaded,
+		session.Name + "/" + acquisition.Name + "_" + twinId + "/scan.nii":        api.ManifestDownloaded,
+	})
+
+	// Files cannot be overwritten by sidecars
+	progress, result = t.UploadToContainer(twin, UploadSourceFromString(api.ExportSidecarName, "{}"))
+	drainProgress(progress)
+	t.So(<-result, ShouldBeNil)
+
+	manifest, err = t.Export(root, twinDest, &api.ExportOptions{Include: []string{api.ExportSidecarName}, Sidecars: true})
+	t.So(err, ShouldNotBeNil)
+	t.So(manifest.Files, ShouldHaveLength, 1)
+	t.So(manifest.Files[0].Status, ShouldEqual, api.ManifestFailed)
+
+	// Exporting a group gives each project a directory
+	project, _, err := t.GetProject(projectId)
+	t.So(err, ShouldBeNil)
+	groupDest, err := ioutil.TempDir("", "sdk-test-")
+	t.So(err, ShouldBeNil)
+	defer os.RemoveAll(groupDest)
+
+	manifest, err = t.Export(groupId, groupDest, &api.ExportOptions{Include: []string{"protocol.txt"}})
+	t.So(err, ShouldBeNil)
+	t.So(manifest.Files, ShouldHaveLength, 1)
+	t.So(manifest.Files[0].Path, ShouldEqual, filepath.Join(groupDest, project.Name, "protocol.txt"))
+
+	_, err = t.Export(groupId+"/"+RandString(), groupDest, nil)
+	t.So(api.IsNotFound(err), ShouldBeTrue)
+}
@@ -0,0 +1,132 @@
+package tests
+
+import (
+	"net/http"
+
+	. "github.com/smartystreets/assertions"
+
+	"flywheel.io/sdk/api"
+)
+
+func (t *F) TestFileLifecycle() {
+	_, projectId, sessionId, acquisitionId := t.createTestAcquisition()
+	collectionId, _, err := t.AddCollection(&api.Collection{Name: RandString()})
+	t.So(err, ShouldBeNil)
+
+	getFiles := func(kind, id string) []*api.File {
+		var files []*api.File
+		var err error
+		switch kind {
+		case "project":
+			var x *api.Project
+			x, _, err = t.GetProject(id)
+			files = x.Files
+		case "session":
+			var x *api.Session
+			x, _, err = t.GetSession(id)
+			files = x.Files
+		case "acquisition":
+			var x *api.Acquisition
+			x, _, err = t.GetAcquisition(id)
+			files = x.Files
+		case "collection":
+			var x *api.Collection
+			x, _, err = t.GetCollection(id)
+			files = x.Files
+		}
+		t.So(err, ShouldBeNil)
+		return files
+	}
+
+	containers := []struct {
+		kind     string
+		id       string
+		upload   func(string, ...*api.UploadSource) (chan int64, chan error)
+		download func(string, string, *api.DownloadSource) (chan int64, chan error)
+		modify   func(string, string, *api.FileFields) (*http.Response, *api.ModifiedAndJobsResponse, error)
+		setInfo  func(string, string, map[string]interface{}) (*http.Response, error)
+		addTag   func(string, string, string) (*http.Response, error)
+		rename   func(string, string, string) (*http.Response, error)
+		replace  func(string, string, *api.UploadSource) (chan int64, chan error)
+		delete   func(string, string) (*http.Response, error)
+	}{
+		{"project", projectId, t.UploadToProject, t.DownloadFromProject, t.ModifyProjectFile, t.SetProjectFileInfo,
+			t.AddProjectFileTag, t.RenameProjectFile, t.ReplaceProjectFile, t.DeleteProjectFile},
+		{"session", sessionId, t.UploadToSession, t.DownloadFromSession, t.ModifySessionFile, t.SetSessionFileInfo,
+			t.AddSessionFileTag, t.RenameSessionFile, t.ReplaceSessionFile, t.DeleteSessionFile},
+		{"acquisition", acquisitionId, t.UploadToAcquisition, t.DownloadFromAcquisition, t.ModifyAcquisitionFile, t.SetAcquisitionFileInfo,
+			t.AddAcquisitionFileTag, t.RenameAcquisitionFile, t.ReplaceAcquisitionFile, t.DeleteAcquisitionFile},
+		{"collection", collectionId, t.UploadToCollection, t.DownloadFromCollection, t.ModifyCollectionFile, t.SetCollectionFileInfo,
+			t.AddCollectionFileTag, t.RenameCollectionFile, t.ReplaceCollectionFile, t.DeleteCollectionFile},
+	}
+
+	for _, x := range containers {
+		poem := "Surely some revelation is at hand;"
+		t.uploadText(x.upload, x.id, "yeats.txt", poem)
+		t.uploadText(x.upload, x.id, "other.txt", poem)
+
+		_, _, err = x.modify(x.id, "yeats.txt", &api.FileFields{Modality: "MR", Measurements: []string{"functional"}})
+		t.So(err, ShouldBeNil)
+		_, err = x.setInfo(x.id, "yeats.txt", map[string]interface{}{"poet": "yeats"})
+		t.So(err, ShouldBeNil)
+		_, err = x.addTag(x.id, "yeats.txt", "poem")
+		t.So(err, ShouldBeNil)
+
+		files := getFiles(x.kind, x.id)
+		t.So(files, ShouldHaveLength, 2)
+		t.So(files[0].Info, ShouldResemble, map[string]interface{}{"poet": "yeats"})
+
+		// Replace keeps the name and metadata, which are restored where the server drops them
+		revised := "Surely the Second Coming is at hand."
+		progress, result := x.replace(x.id, "yeats.txt", UploadSourceFromString("draft.txt", revised))
+		t.checkProgressChanEndsWith(progress, int64(len(revised)))
+		t.So(<-result, ShouldBeNil)
+
+		replaced := getFiles(x.kind, x.id)[0]
+		t.So(replaced.Name, ShouldEqual, "yeats.txt")
+		t.So(replaced.Size, ShouldEqual, len(revised))
+		t.So(replaced.Modality, ShouldEqual, "MR")
+		t.So(replaced.Measurements, ShouldResemble, []string{"functional"})
+		t.So(replaced.Info, ShouldResemble, files[0].Info)
+		t.So(replaced.Tags, ShouldResemble, []string{"poem"})
+		t.So(*replaced.Modified, ShouldHappenAfter, *files[0].Modified)
+		t.downloadText(x.download, x.id, "yeats.txt", revised)
+
+		_, result = x.replace(x.id, "missing.txt", UploadSourceFromString("missing.txt", revised))
+		t.So(api.IsNotFound(<-result), ShouldBeTrue)
+		t.So(getFiles(x.kind, x.id), ShouldHaveLength, 2)
+
+		// Rename moves the contents, info and tags to the new name
+		_, err = x.rename(x.id, "yeats.txt", "second-coming.txt")
+		t.So(err, ShouldBeNil)
+		files = getFiles(x.kind, x.id)
+		t.So(files, ShouldHaveLength, 2)
+		t.So(files[0].Name, ShouldEqual, "other.txt")
+		renamed := files[1]
+		t.So(renamed.Name, ShouldEqual, "second-coming.txt")
+		t.So(renamed.Modality, ShouldEqual, "MR")
+		t.So(renamed.Measurements, ShouldResemble, []string{"functional"})
+		t.So(renamed.Info, ShouldResemble, replaced.Info)
+		t.So(renamed.Tags, ShouldResemble, []string{"poem"})
+		t.downloadText(x.download, x.id, "second-coming.txt", revised)
+
+		_, err = x.rename(x.id, "yeats.txt", "anything.txt")
+		t.So(api.IsNotFound(err), ShouldBeTrue)
+		_, err = x.rename(x.id, "second-coming.txt", "other.txt")
+		t.So(api.IsConflict(err), ShouldBeTrue)
+		t.So(getFiles(x.kind, x.id), ShouldHaveLength, 2)
+
+		// Delete
+		_, err = x.delete(x.id, "second-coming.txt")
+		t.So(err, ShouldBeNil)
+		files = getFiles(x.kind, x.id)
+		t.So(files, ShouldHaveLength, 1)
+		t.So(files[0].Name, ShouldEqual, "other.txt")
+
+		_, err = x.delete(x.id, "second-coming.txt")
+		t.So(api.IsNotFound(err), ShouldBeTrue)
+		_, dest := DownloadSourceToBuffer()
+		_, result = x.download(x.id, "second-coming.txt", dest)
+		t.So(api.IsNotFound(<-result), ShouldBeTrue)
+	}
+}
@@ -0,0 +1,106 @@
+package tests
+
+import (
+	"net/http"
+
+	. "github.com/smartystreets/assertions"
+
+	"flywheel.io/sdk/api"
+)
+
+func (t *F) TestContainerInfo() {
+	groupId, projectId, sessionId, acquisitionId := t.createTestAcquisition()
+	collectionId, _, err := t.AddCollection(&api.Collection{Name: RandString()})
+	t.So(err, ShouldBeNil)
+
+	containers := []struct {
+		ref     *api.ContainerRef
+		set     func(string, map[string]interface{}) (*http.Response, error)
+		replace func(string, map[string]interface{}) (*http.Response, error)
+		delete  func(string, []string) (*http.Response, error)
+	}{
+		{&api.ContainerRef{Type: "group", Id: groupId}, t.SetGroupInfo, t.ReplaceGroupInfo, t.DeleteGroupInfoFields},
+		{&api.ContainerRef{Type: "project", Id: projectId}, t.SetProjectInfo, t.ReplaceProjectInfo, t.DeleteProjectInfoFields},
+		{&api.ContainerRef{Type: "session", Id: sessionId}, t.SetSessionInfo, t.ReplaceSessionInfo, t.DeleteSessionInfoFields},
+		{&api.ContainerRef{Type: "acquisition", Id: acquisitionId}, t.SetAcquisitionInfo, t.ReplaceAcquisitionInfo, t.DeleteAcquisitionInfoFields},
+		{&api.ContainerRef{Type: "collection", Id: collectionId}, t.SetCollectionInfo, t.ReplaceCollectionInfo, t.DeleteCollectionInfoFields},
+	}
+
+	getInfo := func(ref *api.ContainerRef) map[string]interface{} {
+		container, _, err := t.GetContainer(ref)
+		t.So(err, ShouldBeNil)
+		return container.Info
+	}
+
+	for _, x := range containers {
+		id := x.ref.Id
+
+		// Replace
+		_, err = x.replace(id, map[string]interface{}{
+			"scanner": map[string]interface{}{
+				"vendor": "acme",
+				"coil":   32,
+			},
+			"quality": "good",
+		})
+		t.So(err, ShouldBeNil)
+
+		// Set leaves other keys alone, and dotted keys leave nested siblings alone
+		_, err = x.set(id, map[string]interface{}{
+			"scanner.coil": 64,
+			"site.name":    "north",
+			"reviewed":     true,
+		})
+		t.So(err, ShouldBeNil)
+		t.So(getInfo(x.ref), ShouldResemble, map[string]interface{}{
+			"scanner": map[string]interface{}{
+				"vendor": "acme",
+				"coil":   64.0,
+			},
+			"site": map[string]interface{}{
+				"name": "north",
+			},
+			"quality":  "good",
+			"reviewed": true,
+		})
+
+		// Delete, including nested keys and keys that are not present
+		_, err = x.delete(id, []string{"scanner.vendor", "quality", "missing.key"})
+		t.So(err, ShouldBeNil)
+		t.So(getInfo(x.ref), ShouldResemble, map[string]interface{}{
+			"scanner": map[string]interface{}{
+				"coil": 64.0,
+			},
+			"site": map[string]interface{}{
+				"name": "north",
+			},
+			"reviewed": true,
+		})
+
+		// Info changes do not affect other fields
+		container, _, err := t.GetContainer(x.ref)
+		t.So(err, ShouldBeNil)
+		t.So(container.Id, ShouldEqual, id)
+		t.So(container.Created, ShouldNotBeNil)
+	}
+
+	// Likewise through the generic functions
+	ref := containers[2].ref
+	_, err = t.SetContainerInfo(ref, map[string]interface{}{"a.b.c": "deep"})
+	t.So(err, ShouldBeNil)
+	_, err = t.DeleteContainerInfoFields(ref, []string{"scanner", "site", "reviewed"})
+	t.So(err, ShouldBeNil)
+	t.So(getInfo(ref), ShouldResemble, map[string]interface{}{
+		"a": map[string]interface{}{
+			"b": map[string]interface{}{
+				"c": "deep",
+			},
+		},
+	})
+	_, err = t.ReplaceContainerInfo(ref, map[string]interface{}{})
+	t.So(err, ShouldBeNil)
+	t.So(getInfo(ref), ShouldBeEmpty)
+
+	_, err = t.SetContainerInfo(&api.ContainerRef{Type: "session", Id: RandString()}, map[string]interface{}{"a": 1})
+	t.So(api.IsNotFound(err), ShouldBeTrue)
+}
@@ -0,0 +1,113 @@
+package tests
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	. "github.com/smartystreets/assertions"
+
+	"flywheel.io/sdk/api"
+)
+
+// Returns a handler that holds each request for delay, and records the peak number of concurrent requests.
+func concurrencyHandler(delay time.Duration) (http.Handler, *int32) {
+	var current, peak int32
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		now := atomic.AddInt32(&current, 1)
+		defer atomic.AddInt32(&current, -1)
+
+		for {
+			old := atomic.LoadInt32(&peak)
+			if now <= old || atomic.CompareAndSwapInt32(&peak, old, now) {
+				break
+			}
+		}
+
+		time.Sleep(delay)
+		w.Write([]byte(`{"_id": "my-user"}`))
+	}), &peak
+}
+
+// Fire n concurrent requests with client, and return any errors.
+func getUsersConcurrently(client *api.Client, n int) []error {
+	var wg sync.WaitGroup
+	errs := make([]error, n)
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			_, _, errs[i] = client.GetCurrentUser()
+			wg.Done()
+		}(i)
+	}
+
+	wg.Wait()
+	return errs
+}
+
+func (t *F) TestLimitInFlight() {
+	handler, peak := concurrencyHandler(20 * time.Millisecond)
+	limiter := api.NewLimiter(0, 0, 2)
+	client, done := makeTestServerClient(handler, api.LimitRequests(limiter))
+	defer done()
+
+	for _, err := range getUsersConcurrently(client, 8) {
+		t.So(err, ShouldBeNil)
+	}
+	t.So(atomic.LoadInt32(peak), ShouldEqual, 2)
+
+	stats := limiter.Stats()
+	t.So(stats.Requests, ShouldEqual, 8)
+	t.So(stats.Delayed, ShouldBeGreaterThan, 0)
+	t.So(stats.MaxWait, ShouldBeGreaterThan, 0)
+	t.So(stats.TotalWait, ShouldBeGreaterThanOrEqualTo, stats.MaxWait)
+	t.So(stats.InFlight, ShouldEqual, 0)
+
+	// Adjust at runtime
+	limiter.SetMaxInFlight(4)
+	atomic.StoreInt32(peak, 0)
+	for _, err := range getUsersConcurrently(client, 8) {
+		t.So(err, ShouldBeNil)
+	}
+	t.So(atomic.LoadInt32(peak), ShouldBeBetweenOrEqual, 3, 4)
+}
+
+func (t *F) TestLimitRate() {
+	handler, _ := concurrencyHandler(0)
+	limiter := api.NewLimiter(100, 1, 0)
+	client, done := makeTestServerClient(handler, api.LimitRequests(limiter))
+	defer done()
+
+	// The first request uses the burst; the rest wait for roughly 10ms each
+	begin := time.Now()
+	for _, err := range getUsersConcurrently(client, 6) {
+		t.So(err, ShouldBeNil)
+	}
+	t.So(time.Since(begin), ShouldBeGreaterThanOrEqualTo, 40*time.Millisecond)
+	t.So(limiter.Stats().Delayed, ShouldBeGreaterThanOrEqualTo, 4)
+
+	// Adjusting the rate does not refill the bucket
+	limiter.SetRate(20, 1)
+	_, _, err := client.GetCurrentUser()
+	t.So(err, ShouldBeNil)
+	limiter.SetRate(20, 1)
+	begin = time.Now()
+	_, _, err = client.GetCurrentUser()
+	t.So(err, ShouldBeNil)
+	t.So(time.Since(begin), ShouldBeGreaterThanOrEqualTo, 30*time.Millisecond)
+
+	// Shared by downloads
+	limiter.SetRate(0, 0)
+	limiter.SetMaxInFlight(1)
+	buffer, dest := DownloadSourceToBuffer()
+	progress, result := client.DownloadSimple("files/yeats.txt", dest)
+	for range progress {
+	}
+	t.So(<-result, ShouldBeNil)
+	t.So(buffer.String(), ShouldEqual, `{"_id": "my-user"}`)
+	t.So(limiter.Stats().InFlight, ShouldEqual, 0)
+	t.So(limiter.Stats().Requests, ShouldEqual, 9)
+}
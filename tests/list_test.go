@@ -0,0 +1,167 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+
+	. "github.com/smartystreets/assertions"
+
+	"flywheel.io/sdk/api"
+	"flywheel.io/sdk/apitest"
+)
+
+func (t *F) TestListOptions() {
+	_, projectId, sessionId := t.createTestSession()
+
+	var ids []string
+	for i := 0; i < 5; i++ {
+		acquisitionId, _, err := t.AddAcquisition(&api.Acquisition{
+			Name:      "scan-" + strconv.Itoa(i),
+			SessionId: sessionId,
+			Info:      map[string]interface{}{"run": i, "site": map[string]interface{}{"name": "north"}},
+		})
+		t.So(err, ShouldBeNil)
+		ids = append(ids, acquisitionId)
+	}
+	inSession := "session=" + sessionId
+
+	// Filter
+	acquisitions, _, err := t.ListAcquisitions(&api.ListOptions{Filter: inSession + ",info.run>=3"})
+	t.So(err, ShouldBeNil)
+	t.So(acquisitions, ShouldHaveLength, 2)
+	t.So(acquisitions[0].Id, ShouldEqual, ids[3])
+	t.So(acquisitions[1].Id, ShouldEqual, ids[4])
+
+	acquisitions, _, err = t.ListAcquisitions(&api.ListOptions{Filter: inSession + ",label=~-[02]$"})
+	t.So(err, ShouldBeNil)
+	t.So(acquisitions, ShouldHaveLength, 2)
+	t.So(acquisitions[0].Name, ShouldEqual, "scan-0")
+	t.So(acquisitions[1].Name, ShouldEqual, "scan-2")
+
+	acquisitions, _, err = t.ListAcquisitions(&api.ListOptions{Filter: inSession + ",label!=scan-1"})
+	t.So(err, ShouldBeNil)
+	t.So(acquisitions, ShouldHaveLength, 4)
+
+	// Sort
+	acquisitions, _, err = t.ListAcquisitions(&api.ListOptions{Filter: inSession, Sort: "info.run:desc"})
+	t.So(err, ShouldBeNil)
+	t.So(acquisitions, ShouldHaveLength, 5)
+	for i, acquisition := range acquisitions {
+		t.So(acquisition.Id, ShouldEqual, ids[4-i])
+	}
+
+	// Projection, which may include fields that listings usually leave out
+	acquisitions, _, err = t.ListAcquisitions(&api.ListOptions{Filter: inSession, Fields: []string{"label", "info.site"}})
+	t.So(err, ShouldBeNil)
+	t.So(acquisitions, ShouldHaveLength, 5)
+	t.So(acquisitions[0].Id, ShouldNotBeEmpty)
+	t.So(acquisitions[0].Name, ShouldStartWith, "scan-")
+	t.So(acquisitions[0].SessionId, ShouldBeEmpty)
+	t.So(acquisitions[0].Info, ShouldResemble, map[string]interface{}{"site": map[string]interface{}{"name": "north"}})
+
+	// Paging
+	var paged []string
+	options := &api.ListOptions{Filter: inSession, Limit: 2}
+	for {
+		page, _, err := t.ListAcquisitions(options)
+		t.So(err, ShouldBeNil)
+		for _, acquisition := range page {
+			paged = append(paged, acquisition.Id)
+		}
+		if len(page) < options.Limit {
+			break
+		}
+		options.AfterId = page[len(page)-1].Id
+	}
+	t.So(paged, ShouldHaveLength, 5)
+	for _, id := range ids {
+		t.So(paged, ShouldContain, id)
+	}
+
+	// Other container types
+	sessions, _, err := t.ListSessions(&api.ListOptions{Filter: "project=" + projectId})
+	t.So(err, ShouldBeNil)
+	t.So(sessions, ShouldHaveLength, 1)
+	t.So(sessions[0].Id, ShouldEqual, sessionId)
+
+	containers, _, err := t.ListContainers("session", &api.ListOptions{Filter: "project=" + projectId})
+	t.So(err, ShouldBeNil)
+	t.So(containers, ShouldHaveLength, 1)
+	t.So(containers[0].ProjectId, ShouldEqual, projectId)
+
+	// Bad options
+	_, _, err = t.ListAcquisitions(&api.ListOptions{Filter: "label"})
+	t.So(api.IsBadRequest(err), ShouldBeTrue)
+	_, _, err = t.ListContainers("subject", nil)
+	t.So(err, ShouldNotBeNil)
+}
+
+func (t *F) TestContainerIterator() {
+	_, _, sessionId := t.createTestSession()
+
+	ids := map[string]bool{}
+	for i := 0; i < 7; i++ {
+		acquisitionId, _, err := t.AddAcquisition(&api.Acquisition{
+			Name:      RandString(),
+			SessionId: sessionId,
+		})
+		t.So(err, ShouldBeNil)
+		ids[acquisitionId] = true
+	}
+
+	// Pages smaller than, and a multiple of, the listing
+	for _, pageSize := range []int{3, 7} {
+		it := t.IterateContainers("acquisition", &api.ListOptions{Filter: "session=" + sessionId, Limit: pageSize})
+		seen := map[string]bool{}
+		for it.Next() {
+			var acquisition api.Acquisition
+			t.So(it.Decode(&acquisition), ShouldBeNil)
+			t.So(acquisition.SessionId, ShouldEqual, sessionId)
+			t.So(it.Container().Id, ShouldEqual, acquisition.Id)
+			seen[acquisition.Id] = true
+		}
+		t.So(it.Err(), ShouldBeNil)
+		t.So(seen, ShouldResemble, ids)
+		t.So(it.Next(), ShouldBeFalse)
+	}
+
+	// No results
+	it := t.IterateContainers("acquisition", &api.ListOptions{Filter: "session=" + RandString()})
+	t.So(it.Next(), ShouldBeFalse)
+	t.So(it.Err(), ShouldBeNil)
+	t.So(it.Decode(&api.Acquisition{}), ShouldNotBeNil)
+
+	// Errors end iteration
+	it = t.IterateContainers("acquisition", &api.ListOptions{Sort: "label"})
+	t.So(it.Next(), ShouldBeFalse)
+	t.So(it.Err(), ShouldNotBeNil)
+
+	it = t.IterateContainers("acquisition", &api.ListOptions{Filter: "label=~("})
+	t.So(it.Next(), ShouldBeFalse)
+	t.So(api.IsBadRequest(it.Err()), ShouldBeTrue)
+
+	// A server that ignores after_id fails iteration rather than repeating the first page forever
+	ts := httptest.NewServer(apitest.NewServer())
+	defer ts.Close()
+	client := apitest.NewClient(ts, api.UseMiddleware(func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			query := req.URL.Query()
+			query.Del("after_id")
+			req.URL.RawQuery = query.Encode()
+			return next.RoundTrip(req)
+		})
+	}))
+	for i := 0; i < 3; i++ {
+		_, _, err := client.AddGroup(&api.Group{Id: RandStringLower()})
+		t.So(err, ShouldBeNil)
+	}
+
+	it = client.IterateContainers("group", &api.ListOptions{Limit: 2})
+	count := 0
+	for it.Next() {
+		count++
+	}
+	t.So(count, ShouldEqual, 2)
+	t.So(it.Err(), ShouldNotBeNil)
+}
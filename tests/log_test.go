@@ -0,0 +1,164 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+
+	. "github.com/smartystreets/assertions"
+
+	"flywheel.io/sdk/api"
+)
+
+// Answers file requests with a binary body, and everything else with JSON.
+var echoHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	if r.Body != nil {
+		ioutil.ReadAll(r.Body)
+	}
+
+	if strings.HasPrefix(r.URL.Path, "/api/files/") {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write(bytes.Repeat([]byte{0}, 1024))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method == "GET" {
+		w.Write([]byte(`{"_id": "my-user"}`))
+	} else {
+		w.Write([]byte(`{"_id": "my-user", "modified": 1}`))
+	}
+})
+
+func (t *F) TestLogTransportText() {
+	var buffer bytes.Buffer
+	client, done := makeTestServerClient(echoHandler, api.DebugLogRequests(&buffer))
+	defer done()
+
+	_, _, err := client.GetCurrentUser()
+	t.So(err, ShouldBeNil)
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	t.So(lines, ShouldHaveLength, 1)
+	t.So(lines[0], ShouldContainSubstring, " GET http://")
+	t.So(lines[0], ShouldContainSubstring, "/api/users/self 200 ")
+	t.So(lines[0], ShouldContainSubstring, " received=")
+	t.So(lines[0], ShouldNotContainSubstring, "my-key")
+}
+
+func (t *F) TestLogTransportJSON() {
+	var buffer bytes.Buffer
+	client, done := makeTestServerClient(echoHandler, api.DebugLogRequests(&buffer), api.DebugLogFormat(api.LogJSON))
+	defer done()
+
+	_, err := client.ModifyUser("my-user", &api.User{Firstname: "William"})
+	t.So(err, ShouldBeNil)
+
+	// Downloads log their full size once complete
+	_, dest := DownloadSourceToBuffer()
+	progress, result := client.DownloadSimple("files/yeats.bin", dest)
+	for range progress {
+	}
+	t.So(<-result, ShouldBeNil)
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	t.So(lines, ShouldHaveLength, 2)
+
+	var entry api.RequestLogEntry
+	t.So(json.Unmarshal([]byte(lines[0]), &entry), ShouldBeNil)
+	t.So(entry.Method, ShouldEqual, "PUT")
+	t.So(entry.URL, ShouldEndWith, "/api/users/my-user")
+	t.So(entry.Status, ShouldEqual, 200)
+	t.So(entry.RequestBytes, ShouldBeGreaterThan, 0)
+	t.So(entry.ResponseBytes, ShouldBeGreaterThan, 0)
+	t.So(entry.Duration, ShouldBeGreaterThan, 0)
+
+	t.So(json.Unmarshal([]byte(lines[1]), &entry), ShouldBeNil)
+	t.So(entry.Method, ShouldEqual, "GET")
+	t.So(entry.ResponseBytes, ShouldEqual, 1024)
+}
+
+func (t *F) TestLogTransportRedaction() {
+	var buffer bytes.Buffer
+	client := &http.Client{Transport: &api.LogTransport{
+		Writer:    &buffer,
+		Headers:   true,
+		Bodies:    true,
+		Transport: &stubTransport{handler: echoHandler},
+	}}
+
+	req, _ := http.NewRequest("PUT", "http://hostname.example/api/download?ticket=my-ticket", strings.NewReader(`{"label": "yeats"}`))
+	req.Header.Set("Authorization", "scitran-user my-key")
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	t.So(err, ShouldBeNil)
+	ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	// Binary bodies are not logged
+	req, _ = http.NewRequest("GET", "http://hostname.example/api/files/yeats.bin", nil)
+	resp, err = client.Do(req)
+	t.So(err, ShouldBeNil)
+	ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	message := buffer.String()
+	t.So(message, ShouldContainSubstring, "Authorization: scitran-user [REDACTED]")
+	t.So(message, ShouldContainSubstring, "ticket=%5BREDACTED%5D")
+	t.So(message, ShouldContainSubstring, `request: {"label": "yeats"}`)
+	t.So(message, ShouldContainSubstring, `response: {"_id": "my-user", "modified": 1}`)
+	t.So(message, ShouldContainSubstring, "[1024 bytes of application/octet-stream omitted]")
+	t.So(message, ShouldNotContainSubstring, "my-key")
+	t.So(message, ShouldNotContainSubstring, "my-ticket")
+}
+
+func (t *F) TestDebugTransportOmitsBinaryBodies() {
+	var buffer bytes.Buffer
+	client, done := makeTestServerClient(echoHandler, api.DebugLogRequests(&buffer), api.DebugLogFormat(api.LogRaw))
+	defer done()
+
+	path := t.createTempFile("Surely some revelation is at hand;")
+	defer os.Remove(path)
+	t.So(client.UploadFileToProject("my-project", path), ShouldBeNil)
+
+	message := buffer.String()
+	t.So(message, ShouldContainSubstring, "[Body omitted: multipart/form-data; boundary=")
+	t.So(message, ShouldContainSubstring, "unknown size]")
+	t.So(message, ShouldNotContainSubstring, "Surely some revelation")
+}
+
+func (t *F) TestDebugTransportOmitsBodiesOfUnknownLength() {
+	// Flushing part way through sends the body chunked, without a length
+	streaming := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"_id": `))
+		w.(http.Flusher).Flush()
+		w.Write([]byte(`"my-user"}`))
+	})
+
+	var buffer bytes.Buffer
+	client, done := makeTestServerClient(streaming, api.DebugLogRequests(&buffer), api.DebugLogFormat(api.LogRaw))
+	defer done()
+
+	user, _, err := client.GetCurrentUser()
+	t.So(err, ShouldBeNil)
+	t.So(user.Id, ShouldEqual, "my-user")
+
+	message := buffer.String()
+	t.So(message, ShouldContainSubstring, "[Body omitted: application/json, unknown size]")
+	t.So(message, ShouldNotContainSubstring, "my-user")
+}
+
+// stubTransport serves requests from a handler, without a network.
+type stubTransport struct {
+	handler http.Handler
+}
+
+func (s *stubTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	recorder := httptest.NewRecorder()
+	s.handler.ServeHTTP(recorder, req)
+	return recorder.Result(), nil
+}
@@ -0,0 +1,148 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/smartystreets/assertions"
+
+	"flywheel.io/sdk/api"
+	"flywheel.io/sdk/apitest"
+)
+
+func (t *F) TestNotes() {
+	_, projectId, sessionId, acquisitionId := t.createTestAcquisition()
+	collectionId, _, err := t.AddCollection(&api.Collection{Name: RandString()})
+	t.So(err, ShouldBeNil)
+
+	getNotes := func(kind, id string) []*api.Note {
+		var notes []*api.Note
+		var err error
+		switch kind {
+		case "project":
+			var x *api.Project
+			x, _, err = t.GetProject(id)
+			notes = x.Notes
+		case "session":
+			var x *api.Session
+			x, _, err = t.GetSession(id)
+			notes = x.Notes
+		case "acquisition":
+			var x *api.Acquisition
+			x, _, err = t.GetAcquisition(id)
+			notes = x.Notes
+		case "collection":
+			var x *api.Collection
+			x, _, err = t.GetCollection(id)
+			notes = x.Notes
+		}
+		t.So(err, ShouldBeNil)
+		return notes
+	}
+
+	containers := []struct {
+		kind   string
+		id     string
+		add    func(string, string) (*http.Response, error)
+		get    func(string, string) (*api.Note, *http.Response, error)
+		modify func(string, string, string) (*http.Response, error)
+		delete func(string, string) (*http.Response, error)
+	}{
+		{"project", projectId, t.AddProjectNote, t.GetProjectNote, t.ModifyProjectNote, t.DeleteProjectNote},
+		{"session", sessionId, t.AddSessionNote, t.GetSessionNote, t.ModifySessionNote, t.DeleteSessionNote},
+		{"acquisition", acquisitionId, t.AddAcquisitionNote, t.GetAcquisitionNote, t.ModifyAcquisitionNote, t.DeleteAcquisitionNote},
+		{"collection", collectionId, t.AddCollectionNote, t.GetCollectionNote, t.ModifyCollectionNote, t.DeleteCollectionNote},
+	}
+
+	for _, x := range containers {
+		text := RandString()
+		_, err = x.add(x.id, text)
+		t.So(err, ShouldBeNil)
+		notes := getNotes(x.kind, x.id)
+		t.So(notes, ShouldHaveLength, 1)
+		noteId := notes[0].Id
+
+		// Get
+		note, _, err := x.get(x.id, noteId)
+		t.So(err, ShouldBeNil)
+		t.So(note, ShouldResemble, notes[0])
+		t.So(note.Text, ShouldEqual, text)
+
+		// Modify
+		newText := RandString()
+		_, err = x.modify(x.id, noteId, newText)
+		t.So(err, ShouldBeNil)
+		rNote, _, err := x.get(x.id, noteId)
+		t.So(err, ShouldBeNil)
+		t.So(rNote.Text, ShouldEqual, newText)
+		t.So(rNote.UserId, ShouldEqual, note.UserId)
+		t.So(*rNote.Created, ShouldEqual, *note.Created)
+		t.So(*rNote.Modified, ShouldHappenAfter, *note.Modified)
+		t.So(*rNote.Modified, ShouldHappenBefore, time.Now())
+
+		// Delete
+		_, err = x.delete(x.id, noteId)
+		t.So(err, ShouldBeNil)
+		t.So(getNotes(x.kind, x.id), ShouldBeEmpty)
+
+		_, _, err = x.get(x.id, noteId)
+		t.So(api.IsNotFound(err), ShouldBeTrue)
+		_, err = x.modify(x.id, noteId, newText)
+		t.So(api.IsNotFound(err), ShouldBeTrue)
+		_, err = x.delete(x.id, noteId)
+		t.So(api.IsNotFound(err), ShouldBeTrue)
+	}
+}
+
+func (t *F) TestNoteAuthors() {
+	// Acting as another user needs a key for them, which only the in-memory API can issue
+	s := apitest.NewServer()
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+	client := apitest.NewClient(ts)
+
+	groupId := RandStringLower()
+	_, _, err := client.AddGroup(&api.Group{Id: groupId})
+	t.So(err, ShouldBeNil)
+	projectId, _, err := client.AddProject(&api.Project{Name: RandString(), GroupId: groupId})
+	t.So(err, ShouldBeNil)
+	_, err = client.AddProjectNote(projectId, RandString())
+	t.So(err, ShouldBeNil)
+	project, _, err := client.GetProject(projectId)
+	t.So(err, ShouldBeNil)
+	noteId := project.Notes[0].Id
+
+	userId, _, err := client.AddUser(&api.User{Id: "author@example.com", Email: "author@example.com"})
+	t.So(err, ShouldBeNil)
+	key, err := s.IssueKey(userId)
+	t.So(err, ShouldBeNil)
+	other := api.NewApiKeyClient(strings.TrimPrefix(ts.URL, "http://")+":"+key, api.InsecureUsePlaintext)
+
+	// Other users can read, but not change, the note
+	_, _, err = other.GetProjectNote(projectId, noteId)
+	t.So(err, ShouldBeNil)
+	_, err = other.ModifyProjectNote(projectId, noteId, RandString())
+	t.So(api.IsForbidden(err), ShouldBeTrue)
+	_, err = other.DeleteProjectNote(projectId, noteId)
+	t.So(api.IsForbidden(err), ShouldBeTrue)
+
+	// Their own notes are theirs to change
+	_, err = other.AddProjectNote(projectId, RandString())
+	t.So(err, ShouldBeNil)
+	project, _, err = other.GetProject(projectId)
+	t.So(err, ShouldBeNil)
+	t.So(project.Notes, ShouldHaveLength, 2)
+	t.So(project.Notes[1].UserId, ShouldEqual, userId)
+	_, err = other.ModifyProjectNote(projectId, project.Notes[1].Id, RandString())
+	t.So(err, ShouldBeNil)
+
+	// Admins of the container may change anyone's notes
+	_, err = client.ModifyProjectNote(projectId, project.Notes[1].Id, RandString())
+	t.So(err, ShouldBeNil)
+	_, err = client.AddProjectPermission(projectId, &api.Permission{Id: userId, Level: api.Admin})
+	t.So(err, ShouldBeNil)
+	_, err = other.DeleteProjectNote(projectId, noteId)
+	t.So(err, ShouldBeNil)
+}
@@ -1,6 +1,9 @@
 package tests
 
 import (
+	"context"
+	"errors"
+	"net/http"
 	"time"
 
 	. "github.com/smartystreets/assertions"
@@ -37,4 +40,31 @@ func (t *F) TestError() {
 	t.So(resp.StatusCode, ShouldEqual, 404)
 	t.So(aerr.StatusCode, ShouldEqual, 404)
 	t.So(aerr.Message, ShouldEqual, "The resource could not be found.")
+	t.So(aerr.Method, ShouldEqual, "GET")
+	t.So(aerr.URL, ShouldEndWith, "/api/does-not-exist")
+
+	// Client methods return the same error type
+	_, _, err = t.GetProject("000000000000000000000000")
+	t.So(api.IsNotFound(err), ShouldBeTrue)
+	t.So(api.IsForbidden(err), ShouldBeFalse)
+	var apiErr *api.Error
+	t.So(errors.As(err, &apiErr), ShouldBeTrue)
+	t.So(apiErr.StatusCode, ShouldEqual, 404)
+}
+
+func (t *F) TestErrorWithoutBody() {
+	empty := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	client, done := makeTestServerClient(empty)
+	defer done()
+
+	for _, c := range []*api.Client{client, client.WithContext(context.Background())} {
+		_, _, err := c.GetCurrentUser()
+		var aerr *api.Error
+		t.So(errors.As(err, &aerr), ShouldBeTrue)
+		t.So(aerr.StatusCode, ShouldEqual, http.StatusBadGateway)
+		t.So(aerr.Method, ShouldEqual, "GET")
+		t.So(aerr.URL, ShouldEndWith, "/api/users/self")
+	}
 }
@@ -0,0 +1,111 @@
+package tests
+
+import (
+	"net/http"
+
+	. "github.com/smartystreets/assertions"
+
+	"flywheel.io/sdk/api"
+)
+
+func (t *F) TestAccessLevels() {
+	t.So(api.Admin.Allows(api.ReadWrite), ShouldBeTrue)
+	t.So(api.ReadWrite.Allows(api.ReadWrite), ShouldBeTrue)
+	t.So(api.ReadOnly.Allows(api.ReadWrite), ShouldBeFalse)
+	t.So(api.NoAccess.Allows(api.ReadOnly), ShouldBeFalse)
+	t.So(api.NoAccess.Allows(api.NoAccess), ShouldBeTrue)
+	t.So(api.AccessLevel("superuser").Allows(api.NoAccess), ShouldBeFalse)
+}
+
+func (t *F) TestPermissions() {
+	groupId, projectId, sessionId, acquisitionId := t.createTestAcquisition()
+	collectionId, _, err := t.AddCollection(&api.Collection{Name: RandString()})
+	t.So(err, ShouldBeNil)
+	userId := t.createTestUser()
+
+	containers := []struct {
+		kind   string
+		id     string
+		add    func(string, *api.Permission) (*http.Response, error)
+		modify func(string, *api.Permission) (*http.Response, error)
+		delete func(string, string) (*http.Response, error)
+	}{
+		{"group", groupId, t.AddGroupPermission, t.ModifyGroupPermission, t.DeleteGroupPermission},
+		{"project", projectId, t.AddProjectPermission, t.ModifyProjectPermission, t.DeleteProjectPermission},
+		{"collection", collectionId, t.AddCollectionPermission, t.ModifyCollectionPermission, t.DeleteCollectionPermission},
+	}
+
+	for _, x := range containers {
+		// Add
+		_, err = x.add(x.id, &api.Permission{Id: userId, Level: api.ReadOnly})
+		t.So(err, ShouldBeNil)
+		level, _, err := t.GetEffectiveAccess(x.kind, x.id, userId)
+		t.So(err, ShouldBeNil)
+		t.So(level, ShouldEqual, api.ReadOnly)
+
+		_, err = x.add(x.id, &api.Permission{Id: userId, Level: api.Admin})
+		t.So(api.IsConflict(err), ShouldBeTrue)
+		_, err = x.add(x.id, &api.Permission{Id: RandString(), Level: api.Admin})
+		t.So(api.IsNotFound(err), ShouldBeTrue)
+
+		// Modify
+		_, err = x.modify(x.id, &api.Permission{Id: userId, Level: api.ReadWrite})
+		t.So(err, ShouldBeNil)
+		level, _, err = t.GetEffectiveAccess(x.kind, x.id, userId)
+		t.So(err, ShouldBeNil)
+		t.So(level, ShouldEqual, api.ReadWrite)
+
+		_, err = x.modify(x.id, &api.Permission{Id: userId, Level: "superuser"})
+		t.So(api.IsBadRequest(err), ShouldBeTrue)
+
+		// Delete
+		_, err = x.delete(x.id, userId)
+		t.So(err, ShouldBeNil)
+		_, err = x.delete(x.id, userId)
+		t.So(api.IsNotFound(err), ShouldBeTrue)
+		level, _, err = t.GetEffectiveAccess(x.kind, x.id, userId)
+		t.So(err, ShouldBeNil)
+		t.So(level, ShouldEqual, api.NoAccess)
+	}
+
+	// Project permissions apply to the project's sessions and acquisitions
+	_, err = t.AddProjectPermission(projectId, &api.Permission{Id: userId, Level: api.ReadWrite})
+	t.So(err, ShouldBeNil)
+	for _, x := range []struct{ kind, id string }{{"session", sessionId}, {"acquisition", acquisitionId}} {
+		level, _, err := t.GetEffectiveAccess(x.kind, x.id, userId)
+		t.So(err, ShouldBeNil)
+		t.So(level, ShouldEqual, api.ReadWrite)
+	}
+
+	// Otherwise, access comes from the nearest ancestor that grants any
+	_, err = t.DeleteProjectPermission(projectId, userId)
+	t.So(err, ShouldBeNil)
+	_, err = t.AddGroupPermission(groupId, &api.Permission{Id: userId, Level: api.ReadOnly})
+	t.So(err, ShouldBeNil)
+	level, _, err := t.GetEffectiveAccess("acquisition", acquisitionId, userId)
+	t.So(err, ShouldBeNil)
+	t.So(level, ShouldEqual, api.ReadOnly)
+
+	// The creator is an admin
+	user, _, err := t.GetCurrentUser()
+	t.So(err, ShouldBeNil)
+	level, _, err = t.GetEffectiveAccess("acquisition", acquisitionId, user.Id)
+	t.So(err, ShouldBeNil)
+	t.So(level, ShouldEqual, api.Admin)
+
+	_, _, err = t.GetEffectiveAccess("subject", acquisitionId, userId)
+	t.So(err, ShouldNotBeNil)
+}
+
+func (t *F) createTestUser() string {
+	email := RandString() + "@" + RandString() + ".com"
+	userId, _, err := t.AddUser(&api.User{
+		Id:        email,
+		Email:     email,
+		Firstname: RandString(),
+		Lastname:  RandString(),
+	})
+	t.So(err, ShouldBeNil)
+
+	return userId
+}
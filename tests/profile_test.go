@@ -0,0 +1,144 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+
+	. "github.com/smartystreets/assertions"
+
+	"flywheel.io/sdk/api"
+)
+
+func (t *F) TestProfileConfigFile() {
+	path := t.createTempFile(strings.Join([]string{
+		"default_profile: local",
+		"profiles:",
+		"  local:",
+		"    host: localhost",
+		"    port: 8443",
+		"    key: change-me",
+		"    insecure_skip_verify: true",
+		"  prod:",
+		"    api_key: flywheel.example.com:my-key",
+		"    proxy: http://proxy.example.com:3128",
+	}, "\n"))
+	defer os.Remove(path)
+
+	config, err := api.LoadConfigFile(path)
+	t.So(err, ShouldBeNil)
+
+	profile, err := config.Profile("")
+	t.So(err, ShouldBeNil)
+	t.So(profile.Host, ShouldEqual, "localhost")
+	t.So(profile.Port, ShouldEqual, 8443)
+	t.So(profile.InsecureSkipVerify, ShouldBeTrue)
+
+	profile, err = config.Profile("prod")
+	t.So(err, ShouldBeNil)
+	t.So(profile.ApiKey, ShouldEqual, "flywheel.example.com:my-key")
+
+	options, err := profile.Options()
+	t.So(err, ShouldBeNil)
+	t.So(options, ShouldHaveLength, 1)
+
+	// Profiles are copies, so changes do not affect the file
+	profile.ApiKey = "changed"
+	profile, _ = config.Profile("prod")
+	t.So(profile.ApiKey, ShouldEqual, "flywheel.example.com:my-key")
+
+	_, err = config.Profile("missing")
+	t.So(err, ShouldNotBeNil)
+	t.So(err.Error(), ShouldContainSubstring, `"missing"`)
+
+	// JSON files are YAML too. Malformed key fields are reported as the profile loads
+	malformed := t.createTempFile(`{
+		"profiles": {
+			"colon":  { "host": "localhost:8443", "key": "change-me" },
+			"port":   { "host": "localhost", "port": 70000, "key": "change-me" },
+			"apikey": { "api_key": "localhost:not-a-port:change-me" },
+			"flags":  { "insecure_skip_verify": true }
+		}
+	}`)
+	defer os.Remove(malformed)
+
+	config, err = api.LoadConfigFile(malformed)
+	t.So(err, ShouldBeNil)
+	for _, name := range []string{"colon", "port", "apikey"} {
+		_, err = config.Profile(name)
+		t.So(err, ShouldNotBeNil)
+		t.So(err.Error(), ShouldContainSubstring, `"`+name+`" is invalid`)
+	}
+
+	// A profile may leave its key to the environment
+	_, err = config.Profile("flags")
+	t.So(err, ShouldBeNil)
+
+	// Invalid files are reported with their path
+	invalid := t.createTempFile(`{ "profiles": `)
+	defer os.Remove(invalid)
+
+	_, err = api.LoadConfigFile(invalid)
+	t.So(err, ShouldNotBeNil)
+	t.So(err.Error(), ShouldContainSubstring, invalid)
+}
+
+func (t *F) TestProfileNewClient() {
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		w.Write([]byte(`{"_id": "my-user"}`))
+	}))
+	defer server.Close()
+
+	host, port, _, err := api.ParseApiKey(strings.TrimPrefix(server.URL, "http://") + ":unused")
+	t.So(err, ShouldBeNil)
+
+	// Separate fields override the API key
+	profile := &api.Profile{
+		ApiKey:               "example.com:1234:old-key",
+		Host:                 host,
+		Port:                 port,
+		Key:                  "my-key",
+		InsecureUsePlaintext: true,
+	}
+	client, err := profile.NewClient()
+	t.So(err, ShouldBeNil)
+
+	user, _, err := client.GetCurrentUser()
+	t.So(err, ShouldBeNil)
+	t.So(user.Id, ShouldEqual, "my-user")
+	t.So(auth, ShouldEqual, "scitran-user my-key")
+
+	// Invalid profiles return errors instead of panicking
+	_, err = (&api.Profile{Host: "example.com"}).NewClient()
+	t.So(err, ShouldNotBeNil)
+
+	_, err = (&api.Profile{ApiKey: "example.com:not-a-port:my-key"}).NewClient()
+	t.So(err, ShouldNotBeNil)
+
+	_, err = (&api.Profile{ApiKey: "example.com:my-key", CAFile: "/does/not/exist"}).NewClient()
+	t.So(err, ShouldNotBeNil)
+}
+
+func (t *F) TestProfileFromApiKey() {
+	profile, err := api.ProfileFromApiKey("example.com:my-key")
+	t.So(err, ShouldBeNil)
+	t.So(profile.ApiKey, ShouldEqual, "example.com:my-key")
+	t.So(profile.InsecureSkipVerify, ShouldBeFalse)
+	t.So(profile.InsecureUsePlaintext, ShouldBeFalse)
+
+	profile, err = api.ProfileFromApiKey("localhost:8443:my-key")
+	t.So(err, ShouldBeNil)
+	t.So(profile.InsecureSkipVerify, ShouldBeTrue)
+
+	// Only the exact suffix is removed
+	profile, err = api.ProfileFromApiKey("localhost:8080:my-keyInsecure,InsecureUsePlaintext")
+	t.So(err, ShouldBeNil)
+	t.So(profile.ApiKey, ShouldEqual, "localhost:8080:my-keyInsecure")
+	t.So(profile.InsecureUsePlaintext, ShouldBeTrue)
+
+	_, err = api.ProfileFromApiKey("not-a-key")
+	t.So(err, ShouldNotBeNil)
+}
@@ -83,3 +83,12 @@ func (t *F) TestProgressReaderAdvanced() {
 	t.So(src1.Len(), ShouldEqual, 25)
 	t.So(src2.Len(), ShouldEqual, 0)
 }
+
+// drainProgress returns the last progress update.
+func drainProgress(progress chan int64) int64 {
+	last := int64(-1)
+	for x := range progress {
+		last = x
+	}
+	return last
+}
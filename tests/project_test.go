@@ -47,6 +47,11 @@ func (t *F) TestProjects() {
 	rProject.Info = nil
 	t.So(projects, ShouldContain, rProject)
 
+	// Get from parent
+	projects, _, err = t.GetGroupProjects(groupId)
+	t.So(err, ShouldBeNil)
+	t.So(projects, ShouldResemble, []*api.Project{rProject})
+
 	// Modify
 	newName := RandString()
 	projectMod := &api.Project{
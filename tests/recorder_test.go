@@ -0,0 +1,98 @@
+package tests
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	. "github.com/smartystreets/assertions"
+
+	"flywheel.io/sdk/api"
+	"flywheel.io/sdk/apitest"
+)
+
+func (t *F) TestRecorder() {
+	ts := httptest.NewServer(apitest.NewServer())
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "cassettes")
+	t.So(err, ShouldBeNil)
+	defer os.RemoveAll(dir)
+	cassette := filepath.Join(dir, "recorder", "cassette.json")
+
+	// Record a group and an upload
+	recorder, err := apitest.NewRecorder(apitest.ModeRecord, cassette)
+	t.So(err, ShouldBeNil)
+	recorder.Variables = []*regexp.Regexp{randomNames}
+	recorder.ApiKey = apitest.RootKey
+	client := apitest.NewClient(ts, api.UseMiddleware(recorder.Middleware))
+
+	recordedId := RandStringLower()
+	_, _, err = client.AddGroup(&api.Group{Id: recordedId})
+	t.So(err, ShouldBeNil)
+	_, _, err = client.GetGroup(recordedId)
+	t.So(err, ShouldBeNil)
+	user, _, err := client.GetCurrentUser()
+	t.So(err, ShouldBeNil)
+	t.So(user.ApiKey.Key, ShouldEqual, apitest.RootKey)
+	projectId, _, err := client.AddProject(&api.Project{Name: "project", GroupId: recordedId})
+	t.So(err, ShouldBeNil)
+	src := UploadSourceFromString("yeats.txt", "Things fall apart; the centre cannot hold;")
+	progress, result := client.UploadToProject(projectId, src)
+	for range progress {
+	}
+	t.So(<-result, ShouldBeNil)
+	t.So(recorder.Close(), ShouldBeNil)
+
+	// The API key is scrubbed, and other words are left alone
+	raw, err := ioutil.ReadFile(cassette)
+	t.So(err, ShouldBeNil)
+	t.So(string(raw), ShouldNotContainSubstring, apitest.RootKey)
+	t.So(string(raw), ShouldNotContainSubstring, "Authorization")
+	t.So(string(raw), ShouldContainSubstring, "Things fall apart; the centre cannot hold;")
+	t.So(randomNames.MatchString(recordedId), ShouldBeTrue)
+	t.So(randomNames.MatchString("acquisition containers"), ShouldBeFalse)
+
+	// Replay with a different random name, against no API at all
+	recorder, err = apitest.NewRecorder(apitest.ModeReplay, cassette)
+	t.So(err, ShouldBeNil)
+	recorder.Variables = []*regexp.Regexp{randomNames}
+	client = api.NewApiKeyClient("127.0.0.1:1:not-a-key", api.InsecureUsePlaintext, api.UseMiddleware(recorder.Middleware))
+
+	replayedId := RandStringLower()
+	_, _, err = client.AddGroup(&api.Group{Id: replayedId})
+	t.So(err, ShouldBeNil)
+
+	// Responses see the live name rather than the recorded one
+	group, _, err := client.GetGroup(replayedId)
+	t.So(err, ShouldBeNil)
+	t.So(group.Id, ShouldEqual, replayedId)
+
+	user, _, err = client.GetCurrentUser()
+	t.So(err, ShouldBeNil)
+	t.So(user.Id, ShouldEqual, apitest.RootUserId)
+	t.So(user.ApiKey.Key, ShouldNotEqual, apitest.RootKey)
+
+	// Server-generated Ids come from the cassette
+	replayedProjectId, _, err := client.AddProject(&api.Project{Name: "project", GroupId: replayedId})
+	t.So(err, ShouldBeNil)
+	t.So(replayedProjectId, ShouldEqual, projectId)
+	src = UploadSourceFromString("yeats.txt", "Things fall apart; the centre cannot hold;")
+	progress, result = client.UploadToProject(replayedProjectId, src)
+	for range progress {
+	}
+	t.So(<-result, ShouldBeNil)
+
+	// Each interaction is replayed once
+	_, _, err = client.GetCurrentUser()
+	t.So(err, ShouldNotBeNil)
+	t.So(err.Error(), ShouldContainSubstring, "No recorded interaction")
+
+	// Replaying needs a cassette
+	_, err = apitest.NewRecorder(apitest.ModeReplay, filepath.Join(dir, "missing.json"))
+	t.So(err, ShouldNotBeNil)
+	_, err = apitest.NewRecorder("rewind", cassette)
+	t.So(err, ShouldNotBeNil)
+}
@@ -0,0 +1,127 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/smartystreets/assertions"
+
+	"flywheel.io/sdk/api"
+	"flywheel.io/sdk/apitest"
+)
+
+func (t *F) TestResolve() {
+	groupId, projectId, sessionId, acquisitionId := t.createTestAcquisition()
+
+	project, _, err := t.GetProject(projectId)
+	t.So(err, ShouldBeNil)
+	session, _, err := t.GetSession(sessionId)
+	t.So(err, ShouldBeNil)
+	acquisition, _, err := t.GetAcquisition(acquisitionId)
+	t.So(err, ShouldBeNil)
+
+	src := UploadSourceFromString("yeats.txt", "Things fall apart; the centre cannot hold;")
+	progress, result := t.UploadToAcquisition(acquisitionId, src)
+	for range progress {
+	}
+	t.So(<-result, ShouldBeNil)
+
+	// Labels
+	path := groupId + "/" + project.Name + "/" + session.Name + "/" + acquisition.Name + "/yeats.txt"
+	resolved, _, err := t.Resolve(path)
+	t.So(err, ShouldBeNil)
+	t.So(resolved.Type(), ShouldEqual, "file")
+	t.So(resolved.Group.Id, ShouldEqual, groupId)
+	t.So(resolved.Project.Id, ShouldEqual, projectId)
+	t.So(resolved.Session.Id, ShouldEqual, sessionId)
+	t.So(resolved.Acquisition.Id, ShouldEqual, acquisitionId)
+	t.So(resolved.File.Name, ShouldEqual, "yeats.txt")
+	t.So(resolved.Leaf(), ShouldEqual, resolved.File)
+
+	// Ids, mixed with labels
+	resolved, _, err = t.Resolve("/" + groupId + "/" + projectId + "/" + session.Name + "/" + acquisitionId + "/")
+	t.So(err, ShouldBeNil)
+	t.So(resolved.Type(), ShouldEqual, "acquisition")
+	t.So(resolved.Acquisition.Files, ShouldHaveLength, 1)
+	t.So(resolved.Leaf(), ShouldEqual, resolved.Acquisition)
+
+	resolved, _, err = t.ResolveSegments([]string{groupId, project.Name})
+	t.So(err, ShouldBeNil)
+	t.So(resolved.Type(), ShouldEqual, "project")
+	t.So(resolved.Session, ShouldBeNil)
+
+	// Missing
+	_, _, err = t.Resolve(groupId + "/" + project.Name + "/not-a-session")
+	t.So(api.IsNotFound(err), ShouldBeTrue)
+	_, _, err = t.Resolve(path + "/child")
+	t.So(api.IsNotFound(err), ShouldBeTrue)
+	_, _, err = t.Resolve(RandStringLower())
+	t.So(api.IsNotFound(err), ShouldBeTrue)
+	_, _, err = t.Resolve("")
+	t.So(err, ShouldNotBeNil)
+	_, _, err = t.Resolve(groupId + "//" + project.Name)
+	t.So(err, ShouldNotBeNil)
+
+	// Ambiguous labels
+	twinId, _, err := t.AddSession(&api.Session{Name: session.Name, ProjectId: projectId})
+	t.So(err, ShouldBeNil)
+
+	_, _, err = t.Resolve(groupId + "/" + project.Name + "/" + session.Name)
+	var ambiguous *api.AmbiguousPathError
+	t.So(errors.As(err, &ambiguous), ShouldBeTrue)
+	t.So(ambiguous.Ids, ShouldResemble, []string{sessionId, twinId})
+	t.So(err.Error(), ShouldContainSubstring, session.Name)
+
+	resolved, _, err = t.Resolve(groupId + "/" + project.Name + "/" + twinId)
+	t.So(err, ShouldBeNil)
+	t.So(resolved.Session.Id, ShouldEqual, twinId)
+}
+
+func (t *F) TestResolveAmbiguousFiles() {
+	// The server keeps file names unique, so a middleware lists the acquisition's file twice
+	ts := httptest.NewServer(apitest.NewServer())
+	defer ts.Close()
+	client := apitest.NewClient(ts, api.UseMiddleware(func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			resp, err := next.RoundTrip(req)
+			if err != nil || req.Method != "GET" || !strings.Contains(req.URL.Path, "/acquisitions/") {
+				return resp, err
+			}
+			var doc map[string]interface{}
+			raw, _ := ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			if json.Unmarshal(raw, &doc) == nil {
+				if files, ok := doc["files"].([]interface{}); ok && len(files) > 0 {
+					doc["files"] = append(files, files[0])
+					raw, _ = json.Marshal(doc)
+				}
+			}
+			resp.Body = ioutil.NopCloser(bytes.NewReader(raw))
+			resp.ContentLength = int64(len(raw))
+			return resp, nil
+		})
+	}))
+
+	groupId, _, err := client.AddGroup(&api.Group{Id: RandStringLower()})
+	t.So(err, ShouldBeNil)
+	projectId, _, err := client.AddProject(&api.Project{Name: RandString(), GroupId: groupId})
+	t.So(err, ShouldBeNil)
+	sessionId, _, err := client.AddSession(&api.Session{Name: RandString(), ProjectId: projectId})
+	t.So(err, ShouldBeNil)
+	acquisitionId, _, err := client.AddAcquisition(&api.Acquisition{Name: RandString(), SessionId: sessionId})
+	t.So(err, ShouldBeNil)
+
+	progress, result := client.UploadToAcquisition(acquisitionId, UploadSourceFromString("yeats.txt", "Mere anarchy is loosed upon the world,"))
+	drainProgress(progress)
+	t.So(<-result, ShouldBeNil)
+
+	_, _, err = client.Resolve(groupId + "/" + projectId + "/" + sessionId + "/" + acquisitionId + "/yeats.txt")
+	var ambiguous *api.AmbiguousPathError
+	t.So(errors.As(err, &ambiguous), ShouldBeTrue)
+	t.So(ambiguous.Ids, ShouldResemble, []string{"file yeats.txt", "file yeats.txt"})
+}
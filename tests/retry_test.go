@@ -0,0 +1,174 @@
+package tests
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	. "github.com/smartystreets/assertions"
+
+	"flywheel.io/sdk/api"
+)
+
+// Given a handler, return a plaintext client for it and a function to close the server.
+func makeTestServerClient(handler http.Handler, options ...api.ApiKeyClientOption) (*api.Client, func()) {
+	server := httptest.NewServer(handler)
+	key := strings.TrimPrefix(server.URL, "http://") + ":my-key"
+
+	options = append(options, api.InsecureUsePlaintext)
+	return api.NewApiKeyClient(key, options...), server.Close
+}
+
+// Returns a handler that fails with status the first failures times it is called, then succeeds with body.
+func failingHandler(failures int32, status int, body string) (http.Handler, *int32) {
+	var count int32
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ioutil.ReadAll(r.Body)
+
+		if atomic.AddInt32(&count, 1) <= failures {
+			w.WriteHeader(status)
+			w.Write([]byte(`{"status_code": ` + strconv.Itoa(status) + `, "message": "try again"}`))
+			return
+		}
+		w.Write([]byte(body))
+	}), &count
+}
+
+var testRetryPolicy = api.RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   time.Millisecond,
+	MaxDelay:    10 * time.Millisecond,
+}
+
+func (t *F) TestRetryIdempotent() {
+	handler, count := failingHandler(2, 503, `{"_id": "my-user"}`)
+	client, done := makeTestServerClient(handler, api.RetryRequests(testRetryPolicy))
+	defer done()
+
+	user, _, err := client.GetCurrentUser()
+	t.So(err, ShouldBeNil)
+	t.So(user.Id, ShouldEqual, "my-user")
+	t.So(atomic.LoadInt32(count), ShouldEqual, 3)
+
+	// Replayed request bodies
+	handler, count = failingHandler(1, 502, `{"modified": 1}`)
+	client, done = makeTestServerClient(handler, api.RetryRequests(testRetryPolicy))
+	defer done()
+
+	_, err = client.ModifyUser("my-user", &api.User{Firstname: RandString()})
+	t.So(err, ShouldBeNil)
+	t.So(atomic.LoadInt32(count), ShouldEqual, 2)
+}
+
+func (t *F) TestRetryGivesUp() {
+	handler, count := failingHandler(5, 503, `{}`)
+	client, done := makeTestServerClient(handler, api.RetryRequests(testRetryPolicy))
+	defer done()
+
+	_, _, err := client.GetCurrentUser()
+	t.So(api.IsStatus(err, 503), ShouldBeTrue)
+	t.So(atomic.LoadInt32(count), ShouldEqual, 3)
+
+	// Non-retryable status
+	handler, count = failingHandler(5, 404, `{}`)
+	client, done = makeTestServerClient(handler, api.RetryRequests(testRetryPolicy))
+	defer done()
+
+	_, _, err = client.GetCurrentUser()
+	t.So(api.IsNotFound(err), ShouldBeTrue)
+	t.So(atomic.LoadInt32(count), ShouldEqual, 1)
+}
+
+func (t *F) TestRetrySkipsPost() {
+	handler, count := failingHandler(1, 503, `{"_id": "my-user"}`)
+	client, done := makeTestServerClient(handler, api.RetryRequests(testRetryPolicy))
+	defer done()
+
+	_, _, err := client.AddUser(&api.User{Id: "my-user"})
+	t.So(api.IsStatus(err, 503), ShouldBeTrue)
+	t.So(atomic.LoadInt32(count), ShouldEqual, 1)
+
+	// Uploads are not retried unless enabled
+	handler, count = failingHandler(1, 503, `[]`)
+	client, done = makeTestServerClient(handler, api.RetryRequests(testRetryPolicy))
+	defer done()
+
+	path := t.createTempFile("The darkness drops again; but now I know")
+	defer os.Remove(path)
+
+	err = client.UploadFileToProject("my-project", path)
+	t.So(api.IsStatus(err, 503), ShouldBeTrue)
+	t.So(atomic.LoadInt32(count), ShouldEqual, 1)
+}
+
+func (t *F) TestRetryUploads() {
+	policy := testRetryPolicy
+	policy.RetryUploads = true
+
+	handler, count := failingHandler(2, 503, `[]`)
+	client, done := makeTestServerClient(handler, api.RetryRequests(policy))
+	defer done()
+
+	path := t.createTempFile("That twenty centuries of stony sleep")
+	defer os.Remove(path)
+
+	err := client.UploadFileToProject("my-project", path)
+	t.So(err, ShouldBeNil)
+	t.So(atomic.LoadInt32(count), ShouldEqual, 3)
+
+	// The caller's sources are left as they were, so they can be used again, and the final total is reported
+	handler, count = failingHandler(1, 503, `[]`)
+	client, done = makeTestServerClient(handler, api.RetryRequests(policy))
+	defer done()
+
+	source := &api.UploadSource{Path: path}
+	for i := 0; i < 2; i++ {
+		progress, result := client.UploadToProject("my-project", source)
+		t.So(<-result, ShouldBeNil)
+		t.So(drainProgress(progress), ShouldEqual, int64(len("That twenty centuries of stony sleep")))
+		t.So(*source, ShouldResemble, api.UploadSource{Path: path})
+	}
+	t.So(atomic.LoadInt32(count), ShouldEqual, 3)
+
+	// Readers cannot be reopened, and are not retried
+	handler, count = failingHandler(1, 503, `[]`)
+	client, done = makeTestServerClient(handler, api.RetryRequests(policy))
+	defer done()
+
+	progress, result := client.UploadToProject("my-project", UploadSourceFromString("yeats.txt", "Were vexed to nightmare by a rocking cradle,"))
+	for range progress {
+	}
+	t.So(api.IsStatus(<-result, 503), ShouldBeTrue)
+	t.So(atomic.LoadInt32(count), ShouldEqual, 1)
+}
+
+func (t *F) TestRetryAfter() {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(429)
+	})
+	client, done := makeTestServerClient(handler, api.RetryRequests(testRetryPolicy))
+	defer done()
+
+	// Retry-After is capped by MaxDelay
+	begin := time.Now()
+	_, _, err := client.GetCurrentUser()
+	t.So(api.IsStatus(err, 429), ShouldBeTrue)
+	t.So(time.Since(begin), ShouldBeLessThan, time.Second)
+}
+
+// Write text to a new temporary file, and return its path.
+func (t *F) createTempFile(text string) string {
+	file, err := ioutil.TempFile("", "sdk-test-")
+	t.So(err, ShouldBeNil)
+	_, err = file.WriteString(text)
+	t.So(err, ShouldBeNil)
+	t.So(file.Close(), ShouldBeNil)
+	return file.Name()
+}
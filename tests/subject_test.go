@@ -0,0 +1,123 @@
+package tests
+
+import (
+	. "github.com/smartystreets/assertions"
+
+	"flywheel.io/sdk/api"
+)
+
+func (t *F) TestSubjects() {
+	_, projectId := t.createTestProject()
+
+	code := RandStringLower()
+	otherCode := RandStringLower()
+	var sessionIds []string
+	for _, x := range []string{code, code, otherCode} {
+		sessionId, _, err := t.AddSession(&api.Session{
+			Name:      RandString(),
+			ProjectId: projectId,
+			Subject: &api.Subject{
+				Code: x,
+				Sex:  "other",
+				Info: map[string]interface{}{
+					"some-key": 37,
+				},
+			},
+		})
+		t.So(err, ShouldBeNil)
+		sessionIds = append(sessionIds, sessionId)
+	}
+
+	// List
+	subjects, _, err := t.GetProjectSubjects(projectId)
+	t.So(err, ShouldBeNil)
+	t.So(subjects, ShouldHaveLength, 2)
+	t.So(subjects[0].Code, ShouldEqual, code)
+	t.So(subjects[0].Sex, ShouldEqual, "other")
+	t.So(subjects[1].Code, ShouldEqual, otherCode)
+
+	sessions, _, err := t.GetSubjectSessions(projectId, code)
+	t.So(err, ShouldBeNil)
+	t.So(sessions, ShouldHaveLength, 2)
+	t.So(sessions[0].Id, ShouldEqual, sessionIds[0])
+	t.So(sessions[1].Id, ShouldEqual, sessionIds[1])
+
+	// Info changes apply to every session
+	results, err := t.SetSubjectInfo(projectId, code, map[string]interface{}{"another-key": "value"})
+	t.So(err, ShouldBeNil)
+	t.So(results, ShouldHaveLength, 2)
+	for _, result := range results {
+		t.So(result.Error, ShouldBeNil)
+
+		session, _, err := t.GetSession(result.SessionId)
+		t.So(err, ShouldBeNil)
+		t.So(session.Subject.Info["some-key"], ShouldEqual, 37)
+		t.So(session.Subject.Info["another-key"], ShouldEqual, "value")
+		t.So(session.Subject.Sex, ShouldEqual, "other")
+	}
+
+	_, err = t.DeleteSubjectInfoFields(projectId, code, []string{"some-key"})
+	t.So(err, ShouldBeNil)
+	session, _, err := t.GetSession(sessionIds[1])
+	t.So(err, ShouldBeNil)
+	t.So(session.Subject.Info, ShouldResemble, map[string]interface{}{"another-key": "value"})
+
+	_, err = t.ReplaceSubjectInfo(projectId, code, map[string]interface{}{"replaced": true})
+	t.So(err, ShouldBeNil)
+	session, _, err = t.GetSession(sessionIds[0])
+	t.So(err, ShouldBeNil)
+	t.So(session.Subject.Info, ShouldResemble, map[string]interface{}{"replaced": true})
+
+	// Deleting the last key leaves the info empty
+	_, err = t.DeleteSubjectInfoFields(projectId, code, []string{"replaced"})
+	t.So(err, ShouldBeNil)
+	for _, id := range sessionIds[:2] {
+		session, _, err = t.GetSession(id)
+		t.So(err, ShouldBeNil)
+		t.So(session.Subject.Info, ShouldBeEmpty)
+	}
+
+	// The other subject is unaffected
+	session, _, err = t.GetSession(sessionIds[2])
+	t.So(err, ShouldBeNil)
+	t.So(session.Subject.Info, ShouldResemble, map[string]interface{}{"some-key": 37.0})
+
+	// Rename
+	newCode := RandStringLower()
+	results, err = t.RenameSubject(projectId, code, newCode)
+	t.So(err, ShouldBeNil)
+	t.So(results, ShouldHaveLength, 2)
+	sessions, _, err = t.GetSubjectSessions(projectId, newCode)
+	t.So(err, ShouldBeNil)
+	t.So(sessions, ShouldHaveLength, 2)
+	_, err = t.RenameSubject(projectId, code, RandStringLower())
+	t.So(api.IsNotFound(err), ShouldBeTrue)
+	_, err = t.RenameSubject(projectId, newCode, otherCode)
+	t.So(api.IsConflict(err), ShouldBeTrue)
+
+	// Merge
+	before, _, err := t.GetSession(sessionIds[2])
+	t.So(err, ShouldBeNil)
+	results, err = t.MergeSubjects(projectId, otherCode, newCode)
+	t.So(err, ShouldBeNil)
+	t.So(results, ShouldHaveLength, 1)
+	t.So(results[0].SessionId, ShouldEqual, sessionIds[2])
+
+	subjects, _, err = t.GetProjectSubjects(projectId)
+	t.So(err, ShouldBeNil)
+	t.So(subjects, ShouldHaveLength, 1)
+	merged, _, err := t.GetSession(sessionIds[2])
+	t.So(err, ShouldBeNil)
+	t.So(merged.Subject.Code, ShouldEqual, subjects[0].Code)
+	t.So(merged.Subject.Sex, ShouldEqual, subjects[0].Sex)
+	t.So(merged.Subject.Info, ShouldResemble, subjects[0].Info)
+
+	// Each session keeps its own subject Id
+	t.So(merged.Subject.Id, ShouldEqual, before.Subject.Id)
+	t.So(merged.Subject.Id, ShouldNotEqual, subjects[0].Id)
+
+	_, err = t.MergeSubjects(projectId, newCode, newCode)
+	t.So(err, ShouldNotBeNil)
+	_, err = t.MergeSubjects(projectId, newCode, RandStringLower())
+	t.So(api.IsNotFound(err), ShouldBeTrue)
+}
@@ -0,0 +1,220 @@
+package tests
+
+import (
+	"net/http"
+
+	. "github.com/smartystreets/assertions"
+
+	"flywheel.io/sdk/api"
+)
+
+func (t *F) TestTags() {
+	groupId, projectId, sessionId, acquisitionId := t.createTestAcquisition()
+	collectionId, _, err := t.AddCollection(&api.Collection{Name: RandString()})
+	t.So(err, ShouldBeNil)
+
+	getTags := func(kind, id string) []string {
+		var tags []string
+		var err error
+		switch kind {
+		case "group":
+			var x *api.Group
+			x, _, err = t.GetGroup(id)
+			tags = x.Tags
+		case "project":
+			var x *api.Project
+			x, _, err = t.GetProject(id)
+			tags = x.Tags
+		case "session":
+			var x *api.Session
+			x, _, err = t.GetSession(id)
+			tags = x.Tags
+		case "acquisition":
+			var x *api.Acquisition
+			x, _, err = t.GetAcquisition(id)
+			tags = x.Tags
+		case "collection":
+			var x *api.Collection
+			x, _, err = t.GetCollection(id)
+			tags = x.Tags
+		}
+		t.So(err, ShouldBeNil)
+		return tags
+	}
+
+	containers := []struct {
+		kind   string
+		id     string
+		add    func(string, string) (*http.Response, error)
+		rename func(string, string, string) (*http.Response, error)
+		delete func(string, string) (*http.Response, error)
+	}{
+		{"group", groupId, t.AddGroupTag, t.RenameGroupTag, t.DeleteGroupTag},
+		{"project", projectId, t.AddProjectTag, t.RenameProjectTag, t.DeleteProjectTag},
+		{"session", sessionId, t.AddSessionTag, t.RenameSessionTag, t.DeleteSessionTag},
+		{"acquisition", acquisitionId, t.AddAcquisitionTag, t.RenameAcquisitionTag, t.DeleteAcquisitionTag},
+		{"collection", collectionId, t.AddCollectionTag, t.RenameCollectionTag, t.DeleteCollectionTag},
+	}
+
+	for _, x := range containers {
+		tag, other, renamed := RandString(), RandString(), RandString()
+		_, err = x.add(x.id, tag)
+		t.So(err, ShouldBeNil)
+		_, err = x.add(x.id, other)
+		t.So(err, ShouldBeNil)
+
+		// Rename
+		_, err = x.rename(x.id, tag, renamed)
+		t.So(err, ShouldBeNil)
+		t.So(getTags(x.kind, x.id), ShouldResemble, []string{renamed, other})
+
+		_, err = x.rename(x.id, renamed, other)
+		t.So(api.IsConflict(err), ShouldBeTrue)
+		_, err = x.rename(x.id, tag, RandString())
+		t.So(api.IsNotFound(err), ShouldBeTrue)
+
+		// Delete
+		_, err = x.delete(x.id, renamed)
+		t.So(err, ShouldBeNil)
+		t.So(getTags(x.kind, x.id), ShouldResemble, []string{other})
+
+		_, err = x.delete(x.id, renamed)
+		t.So(api.IsNotFound(err), ShouldBeTrue)
+	}
+}
+
+func (t *F) TestFileTags() {
+	_, projectId, sessionId, acquisitionId := t.createTestAcquisition()
+	collectionId, _, err := t.AddCollection(&api.Collection{Name: RandString()})
+	t.So(err, ShouldBeNil)
+
+	getFiles := func(kind, id string) []*api.File {
+		var files []*api.File
+		var err error
+		switch kind {
+		case "project":
+			var x *api.Project
+			x, _, err = t.GetProject(id)
+			files = x.Files
+		case "session":
+			var x *api.Session
+			x, _, err = t.GetSession(id)
+			files = x.Files
+		case "acquisition":
+			var x *api.Acquisition
+			x, _, err = t.GetAcquisition(id)
+			files = x.Files
+		case "collection":
+			var x *api.Collection
+			x, _, err = t.GetCollection(id)
+			files = x.Files
+		}
+		t.So(err, ShouldBeNil)
+		t.So(files, ShouldHaveLength, 1)
+		return files
+	}
+
+	containers := []struct {
+		kind   string
+		id     string
+		upload func(string, ...*api.UploadSource) (chan int64, chan error)
+		add    func(string, string, string) (*http.Response, error)
+		rename func(string, string, string, string) (*http.Response, error)
+		delete func(string, string, string) (*http.Response, error)
+	}{
+		{"project", projectId, t.UploadToProject, t.AddProjectFileTag, t.RenameProjectFileTag, t.DeleteProjectFileTag},
+		{"session", sessionId, t.UploadToSession, t.AddSessionFileTag, t.RenameSessionFileTag, t.DeleteSessionFileTag},
+		{"acquisition", acquisitionId, t.UploadToAcquisition, t.AddAcquisitionFileTag, t.RenameAcquisitionFileTag, t.DeleteAcquisitionFileTag},
+		{"collection", collectionId, t.UploadToCollection, t.AddCollectionFileTag, t.RenameCollectionFileTag, t.DeleteCollectionFileTag},
+	}
+
+	for _, x := range containers {
+		t.uploadText(x.upload, x.id, "yeats.txt", "The best lack all conviction, while the worst")
+
+		tag, renamed := RandString(), RandString()
+		_, err = x.add(x.id, "yeats.txt", tag)
+		t.So(err, ShouldBeNil)
+		_, err = x.add(x.id, "yeats.txt", tag)
+		t.So(api.IsConflict(err), ShouldBeTrue)
+		t.So(getFiles(x.kind, x.id)[0].Tags, ShouldResemble, []string{tag})
+
+		_, err = x.rename(x.id, "yeats.txt", tag, renamed)
+		t.So(err, ShouldBeNil)
+		t.So(getFiles(x.kind, x.id)[0].Tags, ShouldResemble, []string{renamed})
+
+		_, err = x.delete(x.id, "yeats.txt", renamed)
+		t.So(err, ShouldBeNil)
+		t.So(getFiles(x.kind, x.id)[0].Tags, ShouldBeEmpty)
+
+		_, err = x.delete(x.id, "yeats.txt", renamed)
+		t.So(api.IsNotFound(err), ShouldBeTrue)
+		_, err = x.add(x.id, "missing.txt", tag)
+		t.So(api.IsNotFound(err), ShouldBeTrue)
+	}
+}
+
+func (t *F) TestBulkTag() {
+	_, _, sessionId := t.createTestSession()
+
+	var targets []*api.ContainerReference
+	for i := 0; i < 6; i++ {
+		acquisitionId, _, err := t.AddAcquisition(&api.Acquisition{
+			Name:      RandString(),
+			SessionId: sessionId,
+		})
+		t.So(err, ShouldBeNil)
+		targets = append(targets, &api.ContainerReference{Id: acquisitionId, Type: "acquisition"})
+	}
+
+	// Some targets already have some of the tags
+	_, err := t.AddAcquisitionTag(targets[0].Id, "reviewed")
+	t.So(err, ShouldBeNil)
+	_, err = t.AddAcquisitionTag(targets[1].Id, "needs-review")
+	t.So(err, ShouldBeNil)
+	_, err = t.AddAcquisitionTag(targets[2].Id, "needs-review")
+	t.So(err, ShouldBeNil)
+	_, err = t.AddAcquisitionTag(targets[2].Id, "reviewed")
+	t.So(err, ShouldBeNil)
+
+	results, err := t.BulkTag(targets, &api.TagChange{
+		Rename: map[string]string{"needs-review": "reviewed"},
+		Add:    []string{"batch"},
+	}, 3)
+	t.So(err, ShouldBeNil)
+	t.So(results, ShouldHaveLength, len(targets))
+
+	for i, result := range results {
+		t.So(result.Target, ShouldEqual, targets[i])
+		t.So(result.Error, ShouldBeNil)
+
+		acquisition, _, err := t.GetAcquisition(targets[i].Id)
+		t.So(err, ShouldBeNil)
+		t.So(acquisition.Tags, ShouldResemble, result.Tags)
+	}
+	t.So(results[0].Tags, ShouldResemble, []string{"reviewed", "batch"})
+	t.So(results[1].Tags, ShouldResemble, []string{"reviewed", "batch"})
+	t.So(results[2].Tags, ShouldResemble, []string{"reviewed", "batch"})
+	t.So(results[3].Tags, ShouldResemble, []string{"batch"})
+	t.So(results[2].Modified, ShouldEqual, 2)
+
+	// Applying the same change again makes no requests
+	results, err = t.BulkTag(targets, &api.TagChange{Add: []string{"batch"}, Remove: []string{"missing"}}, 3)
+	t.So(err, ShouldBeNil)
+	for _, result := range results {
+		t.So(result.Modified, ShouldEqual, 0)
+	}
+
+	// Failures are reported per target
+	bad := []*api.ContainerReference{
+		targets[0],
+		{Id: RandString(), Type: "acquisition"},
+		{Id: targets[1].Id, Type: "subject"},
+	}
+	results, err = t.BulkTag(bad, &api.TagChange{Remove: []string{"batch"}}, 0)
+	t.So(err, ShouldNotBeNil)
+	t.So(results, ShouldHaveLength, 3)
+	t.So(results[0].Error, ShouldBeNil)
+	t.So(results[0].Tags, ShouldResemble, []string{"reviewed"})
+	t.So(api.IsNotFound(results[1].Error), ShouldBeTrue)
+	t.So(results[2].Error, ShouldNotBeNil)
+}
@@ -2,6 +2,13 @@ package tests
 
 import (
 	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"time"
 
 	. "github.com/smartystreets/assertions"
 
@@ -46,7 +53,7 @@ func (t *F) TestClient() {
 func (t *F) TestDebugTransport() {
 	// This could test response content if we exposed the key or allowed regeneration of clients
 	var buffer bytes.Buffer
-	client := api.NewApiKeyClient("hostname.example:80:my-key", api.DebugLogRequests(&buffer))
+	client := api.NewApiKeyClient("hostname.example:80:my-key", api.DebugLogRequests(&buffer), api.DebugLogFormat(api.LogRaw))
 
 	_, _, err := client.GetCurrentUser()
 	t.So(err, ShouldNotBeNil)
@@ -59,5 +66,117 @@ func (t *F) TestDebugTransport() {
 	t.So(message, ShouldContainSubstring, "GET /api")
 	t.So(message, ShouldContainSubstring, "Host: ")
 	t.So(message, ShouldContainSubstring, "User-Agent: ")
-	t.So(message, ShouldContainSubstring, "Authorization: ")
+	t.So(message, ShouldContainSubstring, "Authorization: scitran-user [REDACTED]")
+	t.So(message, ShouldNotContainSubstring, "my-key")
+}
+
+func (t *F) TestBaseTransportAndMiddleware() {
+	var order []string
+	tag := func(name string) api.Middleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				order = append(order, name)
+				req = req.Clone(req.Context())
+				req.Header.Set("X-Middleware", name)
+				return next.RoundTrip(req)
+			})
+		}
+	}
+
+	var seen string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = r.Header.Get("X-Middleware")
+		w.Write([]byte(`{"_id": "my-user"}`))
+	})
+
+	// No network is used with a stub base transport
+	client := api.NewApiKeyClient("hostname.example:my-key",
+		api.BaseTransport(&stubTransport{handler: handler}),
+		api.UseMiddleware(tag("outer"), tag("middle")),
+		api.UseMiddleware(tag("inner")),
+	)
+
+	user, _, err := client.GetCurrentUser()
+	t.So(err, ShouldBeNil)
+	t.So(user.Id, ShouldEqual, "my-user")
+	t.So(order, ShouldResemble, []string{"outer", "middle", "inner"})
+	t.So(seen, ShouldEqual, "inner")
+}
+
+func (t *F) TestTLSOptions() {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 {
+			w.WriteHeader(401)
+			return
+		}
+		w.Write([]byte(`{"_id": "my-user"}`))
+	})
+
+	server := httptest.NewUnstartedServer(handler)
+	server.TLS = &tls.Config{ClientAuth: tls.RequestClientCert}
+	server.StartTLS()
+	defer server.Close()
+	key := strings.TrimPrefix(server.URL, "https://") + ":my-key"
+
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+
+	// Unknown authority
+	_, _, err := api.NewApiKeyClient(key).GetCurrentUser()
+	t.So(err, ShouldNotBeNil)
+
+	// Custom authority, no client certificate
+	_, _, err = api.NewApiKeyClient(key, api.RootCAs(pool)).GetCurrentUser()
+	t.So(api.IsUnauthorized(err), ShouldBeTrue)
+
+	// Custom authority and client certificate
+	client := api.NewApiKeyClient(key, api.RootCAs(pool), api.ClientCertificates(server.TLS.Certificates[0]))
+	user, _, err := client.GetCurrentUser()
+	t.So(err, ShouldBeNil)
+	t.So(user.Id, ShouldEqual, "my-user")
+}
+
+func (t *F) TestProxyOption() {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+		w.Write([]byte(`{"_id": "my-user"}`))
+	}))
+	defer proxy.Close()
+
+	proxyURL, _ := url.Parse(proxy.URL)
+	client := api.NewApiKeyClient("hostname.example:80:my-key", api.InsecureUsePlaintext, api.ProxyURL(proxyURL))
+
+	_, _, err := client.GetCurrentUser()
+	t.So(err, ShouldBeNil)
+	t.So(proxied, ShouldEqual, "http://hostname.example:80/api/users/self")
+}
+
+func (t *F) TestTimeoutOptions() {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		w.Write([]byte(`{"_id": "my-user"}`))
+	})
+
+	client, done := makeTestServerClient(handler, api.Timeout(20*time.Millisecond))
+	defer done()
+	_, _, err := client.GetCurrentUser()
+	t.So(err, ShouldNotBeNil)
+
+	client, done = makeTestServerClient(handler, api.ResponseHeaderTimeout(20*time.Millisecond))
+	defer done()
+	_, _, err = client.GetCurrentUser()
+	t.So(err, ShouldNotBeNil)
+
+	client, done = makeTestServerClient(handler, api.Timeout(time.Minute))
+	defer done()
+	_, _, err = client.GetCurrentUser()
+	t.So(err, ShouldBeNil)
+}
+
+// roundTripperFunc adapts a function to the RoundTripper interface.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
 }
@@ -0,0 +1,175 @@
+package tests
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/smartystreets/assertions"
+
+	"flywheel.io/sdk/api"
+)
+
+// writeTree creates files under root, by slash-separated path.
+func (t *F) writeTree(root string, files map[string]string) {
+	for path, content := range files {
+		path = filepath.Join(root, filepath.FromSlash(path))
+		t.So(os.MkdirAll(filepath.Dir(path), 0700), ShouldBeNil)
+		t.So(ioutil.WriteFile(path, []byte(content), 0600), ShouldBeNil)
+	}
+}
+
+// manifestStatus returns the status of each file in a manifest, by slash-separated path relative to root.
+func manifestStatus(root string, manifest *api.UploadManifest) map[string]string {
+	result := map[string]string{}
+	for _, file := range manifest.Files {
+		relative, _ := filepath.Rel(root, file.Path)
+		result[filepath.ToSlash(relative)] = file.Status
+	}
+	return result
+}
+
+func (t *F) TestUploadDirectory() {
+	_, projectId := t.createTestProject()
+	project := &api.ContainerRef{Type: "project", Id: projectId}
+
+	root, err := ioutil.TempDir("", "sdk-test-")
+	t.So(err, ShouldBeNil)
+	defer os.RemoveAll(root)
+
+	t.writeTree(root, map[string]string{
+		"protocol.txt":              "Mere anarchy is loosed upon the world,",
+		"sub-01/ses-1/notes.txt":    "The ceremony of innocence is drowned;",
+		"sub-01/ses-1/T1/a.txt":     "A shape with lion body and the head of a man,",
+		"sub-01/ses-1/T1/b.txt":     "A gaze blank and pitiless as the sun,",
+		"sub-02/ses-1/T1/a.txt":     "Is moving its slow thighs, while all about it",
+		"sub-02/stray.txt":          "Reel shadows of the indignant desert birds.",
+		"sub-02/ses-1/T1/x/y/z.txt": "Slouches towards Bethlehem to be born?",
+	})
+
+	manifest, err := t.UploadDirectory(root, project, &api.UploadDirectoryOptions{Workers: 2})
+	t.So(err, ShouldNotBeNil)
+	t.So(manifestStatus(root, manifest), ShouldResemble, map[string]string{
+		"protocol.txt":              api.ManifestUploaded,
+		"sub-01/ses-1/notes.txt":    api.ManifestUploaded,
+		"sub-01/ses-1/T1/a.txt":     api.ManifestUploaded,
+		"sub-01/ses-1/T1/b.txt":     api.ManifestUploaded,
+		"sub-02/ses-1/T1/a.txt":     api.ManifestUploaded,
+		"sub-02/stray.txt":          api.ManifestFailed,
+		"sub-02/ses-1/T1/x/y/z.txt": api.ManifestFailed,
+	})
+
+	// Sessions of the same label are kept apart by subject
+	t.So(manifest.Created, ShouldHaveLength, 4)
+	sessions, _, err := t.GetProjectSessions(projectId)
+	t.So(err, ShouldBeNil)
+	t.So(sessions, ShouldHaveLength, 2)
+	for _, session := range sessions {
+		t.So(session.Name, ShouldEqual, "ses-1")
+		t.So([]string{"sub-01", "sub-02"}, ShouldContain, session.Subject.Code)
+
+		acquisitions, _, err := t.GetSessionAcquisitions(session.Id)
+		t.So(err, ShouldBeNil)
+		t.So(acquisitions, ShouldHaveLength, 1)
+		t.So(acquisitions[0].Name, ShouldEqual, "T1")
+	}
+
+	for _, file := range manifest.Files {
+		if file.Status != api.ManifestUploaded {
+			continue
+		}
+		container, _, err := t.GetContainer(file.Target)
+		t.So(err, ShouldBeNil)
+		var names []string
+		for _, x := range container.Files {
+			names = append(names, x.Name)
+		}
+		t.So(names, ShouldContain, file.Name)
+	}
+	p, _, err := t.GetProject(projectId)
+	t.So(err, ShouldBeNil)
+	t.So(p.Files, ShouldHaveLength, 1)
+	t.So(p.Files[0].Name, ShouldEqual, "protocol.txt")
+
+	// Uploading again creates nothing, and skips files already there
+	os.RemoveAll(filepath.Join(root, "sub-02", "stray.txt"))
+	os.RemoveAll(filepath.Join(root, "sub-02", "ses-1", "T1", "x"))
+	manifest, err = t.UploadDirectory(root, project, nil)
+	t.So(err, ShouldBeNil)
+	t.So(manifest.Created, ShouldBeEmpty)
+	for _, status := range manifestStatus(root, manifest) {
+		t.So(status, ShouldEqual, api.ManifestSkipped)
+	}
+
+	// Files of the same size are only uploaded again if hashes are compared
+	t.writeTree(root, map[string]string{
+		"sub-01/ses-1/T1/b.txt": strings.ToUpper("A gaze blank and pitiless as the sun,"),
+	})
+	manifest, err = t.UploadDirectory(root, project, nil)
+	t.So(err, ShouldBeNil)
+	t.So(manifestStatus(root, manifest)["sub-01/ses-1/T1/b.txt"], ShouldEqual, api.ManifestSkipped)
+
+	manifest, err = t.UploadDirectory(root, project, &api.UploadDirectoryOptions{CompareHashes: true})
+	t.So(err, ShouldBeNil)
+	status := manifestStatus(root, manifest)
+	t.So(status["sub-01/ses-1/T1/b.txt"], ShouldEqual, api.ManifestUploaded)
+	t.So(status["sub-01/ses-1/T1/a.txt"], ShouldEqual, api.ManifestSkipped)
+
+	// A mapping can lay out the hierarchy however the local files are named
+	flat, err := ioutil.TempDir("", "sdk-test-")
+	t.So(err, ShouldBeNil)
+	defer os.RemoveAll(flat)
+	t.writeTree(flat, map[string]string{
+		"sub-03_ses-2_T2.txt": "Turning and turning in the widening gyre",
+		"README":              "Left out",
+	})
+
+	manifest, err = t.UploadDirectory(flat, project, &api.UploadDirectoryOptions{
+		Map: func(path string) (*api.UploadLocation, error) {
+			parts := strings.Split(strings.TrimSuffix(path, ".txt"), "_")
+			if len(parts) != 3 {
+				return nil, nil
+			}
+			return &api.UploadLocation{Subject: parts[0], Session: parts[1], Acquisition: parts[2], Name: "scan.txt"}, nil
+		},
+	})
+	t.So(err, ShouldBeNil)
+	t.So(manifest.Files, ShouldHaveLength, 1)
+	t.So(manifest.Files[0].Status, ShouldEqual, api.ManifestUploaded)
+	t.So(manifest.Created, ShouldHaveLength, 2)
+
+	acquisition, _, err := t.GetAcquisition(manifest.Files[0].Target.Id)
+	t.So(err, ShouldBeNil)
+	t.So(acquisition.Name, ShouldEqual, "T2")
+	t.So(acquisition.Files, ShouldHaveLength, 1)
+	t.So(acquisition.Files[0].Name, ShouldEqual, "scan.txt")
+	session, _, err := t.GetSession(acquisition.SessionId)
+	t.So(err, ShouldBeNil)
+	t.So(session.Subject.Code, ShouldEqual, "sub-03")
+
+	// Of several files with one target, only the first found is uploaded
+	twins, err := ioutil.TempDir("", "sdk-test-")
+	t.So(err, ShouldBeNil)
+	defer os.RemoveAll(twins)
+	t.writeTree(twins, map[string]string{
+		"a/scan.txt": "The best lack all conviction, while the worst",
+		"b/scan.txt": "Are full of passionate intensity.",
+	})
+
+	manifest, err = t.UploadDirectory(twins, project, &api.UploadDirectoryOptions{
+		Map: func(path string) (*api.UploadLocation, error) {
+			return &api.UploadLocation{Subject: "sub-03", Session: "ses-2", Acquisition: "T2", Name: "twin.txt"}, nil
+		},
+	})
+	t.So(err, ShouldNotBeNil)
+	t.So(manifestStatus(twins, manifest), ShouldResemble, map[string]string{
+		"a/scan.txt": api.ManifestUploaded,
+		"b/scan.txt": api.ManifestFailed,
+	})
+	t.downloadText(t.DownloadFromAcquisition, acquisition.Id, "twin.txt", "The best lack all conviction, while the worst")
+
+	// Targets must be in the hierarchy
+	_, err = t.UploadDirectory(flat, &api.ContainerRef{Type: "collection", Id: projectId}, nil)
+	t.So(err, ShouldNotBeNil)
+}
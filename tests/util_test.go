@@ -2,6 +2,7 @@ package tests
 
 import (
 	"errors"
+	"fmt"
 	"io/ioutil"
 
 	. "github.com/smartystreets/assertions"
@@ -27,6 +28,14 @@ func (t *F) TestCoalesce() {
 	// Api error
 	res = api.Coalesce(nil, aErr)
 	t.So(res.Error(), ShouldEqual, "(500) This is an api error")
+	t.So(res, ShouldEqual, aErr)
+	t.So(api.IsStatus(res, 500), ShouldBeTrue)
+	t.So(api.IsNotFound(res), ShouldBeFalse)
+
+	// Wrapped api error
+	wrapped := fmt.Errorf("context: %w", &api.Error{StatusCode: 409})
+	t.So(api.IsConflict(wrapped), ShouldBeTrue)
+	t.So(api.IsConflict(err), ShouldBeFalse)
 
 	// Invalid api error
 	aErr = &api.Error{Message: "", StatusCode: 500}
@@ -0,0 +1,123 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+
+	. "github.com/smartystreets/assertions"
+
+	"flywheel.io/sdk/api"
+)
+
+func (t *F) TestWalk() {
+	groupId, projectId, sessionId, acquisitionId := t.createTestAcquisition()
+	secondAcquisitionId, _, err := t.AddAcquisition(&api.Acquisition{Name: RandString(), SessionId: sessionId})
+	t.So(err, ShouldBeNil)
+
+	src := UploadSourceFromString("yeats.txt", "Things fall apart; the centre cannot hold;")
+	progress, result := t.UploadToAcquisition(acquisitionId, src)
+	for range progress {
+	}
+	t.So(<-result, ShouldBeNil)
+	src = UploadSourceFromString("notes.txt", "Mere anarchy is loosed upon the world")
+	progress, result = t.UploadToSession(sessionId, src)
+	for range progress {
+	}
+	t.So(<-result, ShouldBeNil)
+
+	var mu sync.Mutex
+	var visited []string
+	record := func(node *api.ResolvedPath) {
+		mu.Lock()
+		defer mu.Unlock()
+		visited = append(visited, node.Type()+":"+strings.Join(node.Path, "/"))
+	}
+
+	// Everything under a group, with full ancestry
+	err = t.Walk(groupId, func(node *api.ResolvedPath, err error) error {
+		t.So(err, ShouldBeNil)
+		t.So(node.Group.Id, ShouldEqual, groupId)
+		if node.Type() == "file" && node.File.Name == "yeats.txt" {
+			t.So(node.Project.Id, ShouldEqual, projectId)
+			t.So(node.Session.Id, ShouldEqual, sessionId)
+			t.So(node.Acquisition.Id, ShouldEqual, acquisitionId)
+		}
+		record(node)
+		return nil
+	}, api.WalkWorkers(3))
+	t.So(err, ShouldBeNil)
+	t.So(visited, ShouldHaveLength, 7)
+	counts := map[string]int{}
+	for _, x := range visited {
+		counts[strings.SplitN(x, ":", 2)[0]]++
+	}
+	t.So(counts, ShouldResemble, map[string]int{"group": 1, "project": 1, "session": 1, "acquisition": 2, "file": 2})
+
+	// Nodes have resolvable paths
+	for _, x := range visited {
+		resolved, _, err := t.Resolve(strings.SplitN(x, ":", 2)[1])
+		t.So(err, ShouldBeNil)
+		t.So(resolved.Type(), ShouldEqual, strings.SplitN(x, ":", 2)[0])
+	}
+
+	// Filtering by type
+	visited = nil
+	err = t.Walk(groupId, func(node *api.ResolvedPath, err error) error {
+		record(node)
+		return nil
+	}, api.WalkTypes("acquisition"))
+	t.So(err, ShouldBeNil)
+	t.So(visited, ShouldHaveLength, 2)
+	ids := []string{}
+	for _, x := range visited {
+		resolved, _, err := t.Resolve(strings.SplitN(x, ":", 2)[1])
+		t.So(err, ShouldBeNil)
+		ids = append(ids, resolved.Acquisition.Id)
+	}
+	expected := []string{acquisitionId, secondAcquisitionId}
+	sort.Strings(ids)
+	sort.Strings(expected)
+	t.So(ids, ShouldResemble, expected)
+
+	// Skipping a subtree
+	visited = nil
+	err = t.Walk(groupId, func(node *api.ResolvedPath, err error) error {
+		record(node)
+		if node.Type() == "session" {
+			return api.SkipSubtree
+		}
+		return nil
+	})
+	t.So(err, ShouldBeNil)
+	t.So(visited, ShouldHaveLength, 3)
+
+	// Stopping
+	visited = nil
+	err = t.Walk(groupId, func(node *api.ResolvedPath, err error) error {
+		record(node)
+		return api.StopWalk
+	})
+	t.So(err, ShouldBeNil)
+	t.So(visited, ShouldHaveLength, 1)
+
+	failure := errors.New("failure")
+	err = t.Walk(groupId, func(node *api.ResolvedPath, err error) error {
+		return failure
+	})
+	t.So(err, ShouldEqual, failure)
+
+	// Bad arguments
+	err = t.Walk(groupId, func(node *api.ResolvedPath, err error) error { return nil }, api.WalkTypes("subject"))
+	t.So(err, ShouldNotBeNil)
+	err = t.Walk(RandStringLower(), func(node *api.ResolvedPath, err error) error { return nil })
+	t.So(api.IsNotFound(err), ShouldBeTrue)
+
+	// Cancellation
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = t.WithContext(ctx).Walk("", func(node *api.ResolvedPath, err error) error { return nil })
+	t.So(err, ShouldNotBeNil)
+}
@@ -31,12 +31,15 @@ func RandStringOfLength(n int, runes []rune) string {
 	return string(b)
 }
 
+// randPrefix begins every random string, so that recordings can tell them apart from other words.
+const randPrefix = "sdk"
+
 func RandString() string {
-	return RandStringOfLength(10, letterRunes)
+	return randPrefix + RandStringOfLength(10, letterRunes)
 }
 
 func RandStringLower() string {
-	return strings.ToLower(RandStringOfLength(10, letterRunes))
+	return randPrefix + strings.ToLower(RandStringOfLength(10, letterRunes))
 }
 
 func RandHex() string {